@@ -10,6 +10,7 @@
 # - identify the current policy from policy db
 # - calculate the next policy tag
 # - compile the new policy
+# - check raw files of new policy
 # - rename directory 'next' to name of next policy tag
 # - mark new policy as current
 #
@@ -57,7 +58,14 @@ main() {
     while true; do
         prepare_next
         if netspoc $PSRC $PCODE; then
-            handle_success
+            # Check raw files together with generated code,
+            # before policy becomes current.
+            # Raw files aren't part of changeset; don't revert.
+            if drc --lint $PCODE; then
+                handle_success
+            else
+                handle_lint_failure
+            fi
         else
             echo Newest changeset failed to compile
             # Mark data as failed for use in 'newpolicy'.
@@ -182,6 +190,18 @@ handle_success() {
     fi
 }
 
+# Raw files don't match newest changeset.
+handle_lint_failure() {
+    echo Raw files failed to check with newest changeset
+    # Mark data as failed for use in 'newpolicy'.
+    touch $POLICYDB/failed
+    [ "$PREV_POLICY" ] &&
+        echo "Left current policy as '$PREV_POLICY'"
+    ADMIN_EMAILS=$(get-netspoc-approve-conf admin_emails)
+    (cd $PSRC; git log -n 1 --pretty=short; echo ---; cat $PLOG) |
+        mail -s "Newpolicy: check of raw files failed!" "$ADMIN_EMAILS"
+}
+
 # Try to revert bad commit, but only if author has an email address.
 # This prevents automated commits from newpolicy and from netspoc-api
 # from getting reverted.
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"os"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/daemon"
+)
+
+/*
+approve-daemon
+
+Description:
+Long-running service with HTTP/JSON API for compare and approve.
+Runs jobs like do-approve and serves status and history of devices.
+
+https://github.com/hknutzen/Netspoc-Approve
+(c) 2026 by Heinz Knutzen <heinz.knutzen@gmail.com>
+
+This program is free software; you can redistribute it and/or modify
+it under the terms of the GNU General Public License as published by
+the Free Software Foundation; either version 2 of the License, or
+(at your option) any later version.
+
+This program is distributed in the hope that it will be useful,
+but WITHOUT ANY WARRANTY; without even the implied warranty of
+MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+GNU General Public License for more details.
+
+You should have received a copy of the GNU General Public License along
+with this program; if not, write to the Free Software Foundation, Inc.,
+51 Franklin Street, Fifth Floor, Boston, MA 02110-1301 USA.
+*/
+
+func main() {
+	os.Exit(daemon.Main())
+}
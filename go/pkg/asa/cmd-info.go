@@ -36,6 +36,19 @@ object-group service $NAME
  *
 object-group protocol $NAME
  *
+
+# Definition of object and object NAT are shown as two separate
+# commands with same name on device. Both are joined during parsing.
+# Object having subcommand "nat" is anchor and has fixed name.
+# Other objects are handled like SIMPLE_OBJ.
+object_network $NAME
+ host *
+ subnet *
+ range *
+ fqdn *
+ description *
+ nat *
+
 [FIXED_NAME]
 crypto_map $NAME $SEQ match address $access-list
 crypto_map $NAME $SEQ ipsec-isakmp dynamic $crypto_dynamic-map
@@ -80,6 +93,14 @@ crypto_ipsec_ikev1_transform-set $NAME *
 crypto_ipsec_ikev2_ipsec-proposal $NAME
  protocol esp encryption *
  protocol esp integrity *
+# * of "set ikev1 transform-set" and "set ikev2 ipsec-proposal"
+# references one or more $crypto_ipsec_ikev1_transform-set
+# or $crypto_ipsec_ikev2_ipsec-proposal, will be resolved later.
+crypto_ipsec_profile $NAME
+ set ikev1 transform-set *
+ set ikev2 ipsec-proposal *
+ set pfs *
+ set security-association lifetime *
 
 [FIXED_NAME]
 # Are transferred manually, but references must be followed.
@@ -131,11 +152,21 @@ username $NAME attributes
  vpn-group-policy $group-policy
  *
 [ANCHOR]
+# Manual NAT; order of commands is relevant.
+# * references up to four $object_network or $object-group,
+# will be resolved later.
+nat *
 # Other anchors, not referencing any command
 route *
 ipv6_route *
+# Tunnel interface from Netspoc is created on device.
 interface *
  shutdown
  nameif *
+ ip address *
+ tunnel source interface *
+ tunnel destination *
+ tunnel mode *
+ tunnel protection ipsec profile $crypto_ipsec_profile
 no_sysopt_connection_permit-vpn
 `
@@ -1,52 +1,335 @@
 package asa
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/console"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
-type State struct{}
+type State struct {
+	log *errlog.Logger
+	// Device is active unit of failover pair.
+	failover bool
+	// Device is in multiple context mode.
+	multiple bool
+	// Name of file with backup of running config.
+	backup string
+	// Leave backup of running config on device for manual restore.
+	keepBackup bool
+	// Restore of running config is scheduled after this many minutes.
+	restoreMinutes int
+}
+
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
 
-func (s *State) SetTerminal(conn *console.Conn) {
-	out := conn.GetCmdOutput("sh pager")
+func (s *State) SetTerminal(conn *console.Conn) error {
+	out, err := conn.GetCmdOutput("sh pager")
+	if err != nil {
+		return err
+	}
 	if !strings.Contains(out, "no pager") {
-		conn.SendCmd("terminal pager 0")
+		if err := conn.SendCmd("terminal pager 0"); err != nil {
+			return err
+		}
+	}
+	out, err = conn.GetCmdOutput("sh term")
+	if err != nil {
+		return err
 	}
-	out = conn.GetCmdOutput("sh term")
 	if !strings.Contains(out, "511") {
-		conn.SendCmd("configure terminal")
-		conn.SendCmd("terminal width 511")
-		conn.SendCmd("end")
+		for _, cmd := range []string{
+			"configure terminal", "terminal width 511", "end"} {
+			if err := conn.SendCmd(cmd); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
 }
 
-func (s *State) CheckDeviceName(name string, conn *console.Conn) {
-	out := conn.GetCmdOutput("show hostname")
+// CheckDeviceName compares name of device with hostname.
+// In multiple context mode, name of device is name of security
+// context and boxName is name of physical device from info file.
+// Both are checked, because a context with same name could exist on
+// some other physical device.
+func (s *State) CheckDeviceName(name, boxName string, conn *console.Conn) error {
+	out, err := conn.GetCmdOutput("show hostname")
+	if err != nil {
+		return err
+	}
 	out = strings.TrimSuffix(out, "\n")
-	if name != out {
-		errlog.Abort("Wrong device name: %q, expected: %q", out, name)
+	if name == out {
+		s.backup = backupName(name, false)
+		return nil
 	}
+	mode, err := conn.GetCmdOutput("show mode")
+	if err != nil {
+		return err
+	}
+	if strings.Contains(mode, "multiple") {
+		if out != boxName {
+			return errlog.Classify(errlog.WrongName, fmt.Errorf(
+				"Wrong name of physical device: %q, expected: %q", out, boxName))
+		}
+		s.multiple = true
+		s.backup = backupName(name, true)
+		return s.changeContext(name, conn)
+	}
+	return errlog.Classify(errlog.WrongName,
+		fmt.Errorf("Wrong device name: %q, expected: %q", out, name))
 }
 
-func (s *State) PrepareDevice(conn *console.Conn)  {}
-func (s *State) ScheduleReload(conn *console.Conn) {}
-func (s *State) ExtendReload(conn *console.Conn)   {}
-func (s *State) CancelReload(conn *console.Conn)   {}
-func (s *State) RemoveBanner(data []byte) []byte   { return data }
+// In multiple context mode, name of device is name of security
+// context. If we are logged into admin context, change to context
+// of device. Prompt is shown as "HOSTNAME/CONTEXT#".
+func (s *State) changeContext(name string, conn *console.Conn) error {
+	out, err := conn.IssueCmd("", `#[ ]?`)
+	if err != nil {
+		return err
+	}
+	if promptContext(out) == name {
+		return nil
+	}
+	out, err = conn.IssueCmd("changeto context "+name, `#[ ]?`)
+	if err != nil {
+		return err
+	}
+	if ctx := promptContext(out); ctx != name {
+		_, msg, _ := strings.Cut(out, "\n")
+		msg = strings.TrimSpace(msg[:strings.LastIndex(msg, "\n")+1])
+		return errlog.Classify(errlog.WrongName, fmt.Errorf(
+			"Can't change to context %q, current context: %q\n%s", name, ctx, msg))
+	}
+	// Prompt has changed.
+	i := strings.LastIndex(out, "\n")
+	p := out[i:]
+	i = strings.LastIndex(p, "#")
+	conn.SetStdPrompt(regexp.MustCompile(
+		regexp.QuoteMeta(p[:i]) + `\S*` + regexp.QuoteMeta(p[i:])))
+	return nil
+}
+
+// promptContext returns name of context from last line of output.
+func promptContext(out string) string {
+	p := strings.TrimSpace(out[strings.LastIndex(out, "\n")+1:])
+	p = strings.TrimSuffix(p, "#")
+	_, ctx, _ := strings.Cut(p, "/")
+	return ctx
+}
+
+// Output of "show failover" in single context mode:
+//
+//	Failover On
+//	...
+//	        This host: Primary - Active
+//	...
+//	        Other host: Secondary - Standby Ready
+//
+// In multiple context mode, inside some context:
+//
+//	        This context: Active
+//	...
+//	        Peer context: Standby Ready
+var failoverRegex = regexp.MustCompile(
+	`(?m)^\s*(This|Other|Peer) (?:host|context): (?:\w+ - )?(.*\S)`)
+
+// failoverState returns state of this unit and of other unit.
+func failoverState(out string) (this, other string) {
+	for _, m := range failoverRegex.FindAllStringSubmatch(out, -1) {
+		if m[1] == "This" {
+			this = m[2]
+		} else {
+			other = m[2]
+		}
+	}
+	return
+}
+
+// CheckFailover refuses to change standby unit of failover pair,
+// because changes wouldn't be replicated to active unit.
+// Returns description of failover state.
+func (s *State) CheckFailover(conn *console.Conn) (string, error) {
+	out, err := conn.GetCmdOutput("show failover")
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(out, "Failover Off") {
+		return "not enabled", nil
+	}
+	if !strings.HasPrefix(out, "Failover On") {
+		return "", nil
+	}
+	this, _ := failoverState(out)
+	if this != "Active" {
+		return "", fmt.Errorf(
+			"Device isn't active unit of failover pair, state: %q", this)
+	}
+	s.failover = true
+	return this, nil
+}
+
+func (s *State) PrepareDevice(conn *console.Conn) error { return nil }
+func (s *State) RemoveBanner(data []byte) []byte        { return data }
 func (s *State) StripReloadBanner(out string, conn *console.Conn,
-) (string, bool) {
-	return out, false
+) (string, bool, error) {
+	return out, false, nil
+}
+
+// Running config is copied to backup file before changes are applied.
+// If some command fails, running config is replaced by this backup.
+// If connection to device is lost, running config is restored
+// after reconnect. If reconnect fails, the backup is left on device
+// and is restored by scheduled applet or must be restored manually.
+//
+// Name of backup file is derived from name of device, because
+// different contexts of physical device must not share one file.
+// In multiple context mode, backup is stored in private storage of
+// context, because a user context has no access to disk0:.
+func backupName(name string, multiple bool) string {
+	fs := "disk0:"
+	if multiple {
+		fs = "context:"
+	}
+	return fs + "/netspoc-approve-" + name + ".cfg"
+}
+
+// Applet replaces running config by backup after restoreMinutes,
+// if approve doesn't finish in time.
+// Backup is taken before applet is created, hence applet is removed
+// from running config, when backup is restored.
+const restoreApplet = "netspoc-approve-restore"
+
+// Output of "copy /noconfirm running-config FILE":
+// Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+//
+// 4523 bytes copied in 0.220 secs
+func (s *State) ScheduleReload(conn *console.Conn, cfg *program.Config,
+) error {
+	out, err := conn.GetCmdOutput("copy /noconfirm running-config " + s.backup)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, "bytes copied") {
+		return fmt.Errorf("Creating backup of running config failed:\n%s", out)
+	}
+	s.keepBackup = false
+	s.restoreMinutes = 0
+	if cfg.RestoreMinutes <= 0 {
+		return nil
+	}
+	if s.multiple {
+		s.log.Warning("Can't schedule restore of running config" +
+			" in multiple context mode")
+		return nil
+	}
+	for _, cmd := range []string{
+		"configure terminal",
+		"event manager applet " + restoreApplet,
+		fmt.Sprintf("event timer countdown time %d", cfg.RestoreMinutes*60),
+		"action 1 cli command \"configure replace " + s.backup + "\"",
+		"end",
+	} {
+		out, err := conn.GetCmdOutput(cmd)
+		if err != nil {
+			return err
+		}
+		if out != "" {
+			return fmt.Errorf(
+				"Scheduling restore of running config failed:\n%s", out)
+		}
+	}
+	s.restoreMinutes = cfg.RestoreMinutes
+	return nil
 }
 
-func (s *State) WriteMem(conn *console.Conn) {
-	out := conn.GetCmdOutput("write memory")
+func (s *State) ExtendReload(conn *console.Conn) error { return nil }
+
+func (s *State) CancelReload(conn *console.Conn) error {
+	if s.restoreMinutes > 0 {
+		for _, cmd := range []string{
+			"configure terminal",
+			"no event manager applet " + restoreApplet,
+			"end",
+		} {
+			if err := conn.SendCmd(cmd); err != nil {
+				return err
+			}
+		}
+		s.restoreMinutes = 0
+	}
+	if s.keepBackup {
+		return nil
+	}
+	_, err := conn.GetCmdOutput("delete /noconfirm " + s.backup)
+	return err
+}
+
+func (s *State) ReconnectOnLoss() bool { return true }
+
+// Replace running config by backup without reloading device.
+// Parameter conn is nil, if connection to device was lost and
+// reconnect failed.
+func (s *State) Rollback(conn *console.Conn) {
+	s.keepBackup = true
+	if conn == nil {
+		if s.restoreMinutes > 0 {
+			s.log.Warning("Running config will be restored from %s"+
+				" by applet %s", s.backup, restoreApplet)
+		} else {
+			s.log.Warning("Running config must be restored manually from %s",
+				s.backup)
+		}
+		return
+	}
+	out, err := conn.GetCmdOutput("configure replace " + s.backup)
+	if err != nil {
+		s.log.Warning("Restoring running config from %s failed: %v",
+			s.backup, err)
+		return
+	}
+	if errorRegex.MatchString(out) {
+		s.log.Warning("Restoring running config from %s failed:\n%s",
+			s.backup, out)
+		return
+	}
+	// Applet isn't part of backup and has been removed together
+	// with other changes.
+	s.restoreMinutes = 0
+	s.keepBackup = false
+	s.log.Warning("Restored running config from %s", s.backup)
+}
+
+var errorRegex = regexp.MustCompile(`(?i)error`)
+
+// In multiple context mode, we are still in context of device.
+// Here "write memory" saves only startup config of this context,
+// which doesn't interfere with other contexts of same physical device.
+func (s *State) WriteMem(conn *console.Conn) error {
+	out, err := conn.GetCmdOutput("write memory")
+	if err != nil {
+		return err
+	}
 	if !strings.Contains(out, "[OK]") {
-		errlog.Abort("Command 'write memory' failed, missing [OK] in output:\n%s",
-			out)
+		return fmt.Errorf(
+			"Command 'write memory' failed, missing [OK] in output:\n%s", out)
+	}
+	// Configuration is saved on standby unit as well,
+	// if it is synchronized.
+	if s.failover {
+		out, err := conn.GetCmdOutput("show failover")
+		if err != nil {
+			return err
+		}
+		if _, other := failoverState(out); other != "Standby Ready" {
+			return fmt.Errorf("Standby unit of failover pair isn't synchronized,"+
+				" state: %q", other)
+		}
 	}
+	return nil
 }
 
 var sameGroupRegex = regexp.MustCompile(
@@ -79,7 +362,7 @@ LINE:
 			continue
 		}
 		if strings.HasPrefix(line, "WARNING:") {
-			errlog.Warning("Got unexpected output from '%s':\n%s", cmd, line)
+			s.log.Warning("Got unexpected output from '%s':\n%s", cmd, line)
 			continue
 		}
 		return false
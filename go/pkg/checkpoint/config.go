@@ -1,6 +1,7 @@
 package checkpoint
 
 import (
+	"fmt"
 	"slices"
 )
 
@@ -12,7 +13,7 @@ func (s *State) LoadNetspoc(data []byte, fName string) error {
 	if s.spocCfg == nil {
 		s.spocCfg = cfg
 	} else {
-		s.mergeSpoc(cfg)
+		return s.mergeSpoc(cfg)
 	}
 	return nil
 }
@@ -21,29 +22,82 @@ func (s *State) MoveNetspoc2DeviceConfig() {
 	s.deviceCfg, s.spocCfg = s.spocCfg, nil
 }
 
-func (s *State) mergeSpoc(b *chkpConfig) {
+// DumpNetspoc returns parsed configuration from Netspoc
+// in a form suitable for output as JSON.
+func (s *State) DumpNetspoc() any {
+	return s.spocCfg
+}
+
+func (s *State) mergeSpoc(b *chkpConfig) error {
 	a := s.spocCfg
-	a.Networks = append(a.Networks, b.Networks...)
-	a.Hosts = append(a.Hosts, b.Hosts...)
-	a.Groups = append(a.Groups, b.Groups...)
-	a.TCP = append(a.TCP, b.TCP...)
-	a.UDP = append(a.UDP, b.UDP...)
-	a.ICMP = append(a.ICMP, b.ICMP...)
-	a.ICMP6 = append(a.ICMP6, b.ICMP6...)
-	a.SvOther = append(a.SvOther, b.SvOther...)
+	var err error
+	a.Networks, err = mergeObjects(a.Networks, b.Networks, err)
+	a.Hosts, err = mergeObjects(a.Hosts, b.Hosts, err)
+	a.Groups, err = mergeObjects(a.Groups, b.Groups, err)
+	a.TCP, err = mergeObjects(a.TCP, b.TCP, err)
+	a.UDP, err = mergeObjects(a.UDP, b.UDP, err)
+	a.ICMP, err = mergeObjects(a.ICMP, b.ICMP, err)
+	a.ICMP6, err = mergeObjects(a.ICMP6, b.ICMP6, err)
+	a.SvOther, err = mergeObjects(a.SvOther, b.SvOther, err)
+	if err != nil {
+		return err
+	}
 	// Add rules.
 	// Rules are prepended per default.
 	// Rules with attribute .Append are appended after last non Drop line.
+	// Rules with attribute .Replace or .Delete replace resp. delete
+	// rule with same name from Netspoc.
+	// Rules with attribute .Before or .After are inserted before
+	// resp. after rule from Netspoc with given name.
 	for target, bRules := range b.TargetRules {
 		aRules := a.TargetRules[target]
+		find := func(name, attr string) (int, error) {
+			i := slices.IndexFunc(aRules, func(r *chkpRule) bool {
+				return r.Name == name
+			})
+			if i == -1 {
+				return i, fmt.Errorf("Unknown rule %q in %q of %q", name, attr, target)
+			}
+			return i, nil
+		}
 		var prependACL, appendACL []*chkpRule
 		for _, ru := range bRules {
-			if ru.Append {
-				ru.Append = false
+			var i int
+			var err error
+			switch {
+			case ru.Replace:
+				if i, err = find(ru.Name, "replace"); err == nil {
+					aRules[i] = ru
+				}
+			case ru.Delete:
+				if i, err = find(ru.Name, "delete"); err == nil {
+					aRules = slices.Delete(aRules, i, i+1)
+				}
+			case ru.Before != "":
+				if i, err = find(ru.Before, "before"); err == nil {
+					aRules = slices.Insert(aRules, i, ru)
+				}
+			case ru.After != "":
+				if i, err = find(ru.After, "after"); err == nil {
+					// Leave order of multiple rules after same rule.
+					i++
+					for i < len(aRules) && aRules[i].After == ru.After {
+						i++
+					}
+					aRules = slices.Insert(aRules, i, ru)
+				}
+			case ru.Append:
 				appendACL = append(appendACL, ru)
-			} else {
+			default:
 				prependACL = append(prependACL, ru)
 			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, ru := range bRules {
+			ru.Append, ru.Prepend, ru.Replace = false, false, false
+			ru.Before, ru.After = "", ""
 		}
 		if len(prependACL) > 0 {
 			aRules = append(prependACL, aRules...)
@@ -64,4 +118,30 @@ func (s *State) mergeSpoc(b *chkpConfig) {
 	for gw, lb := range b.GatewayRoutes {
 		a.GatewayRoutes[gw] = append(a.GatewayRoutes[gw], lb...)
 	}
+	return nil
+}
+
+// mergeObjects adds objects from raw file. Object with attribute
+// .Replace replaces object with same name from Netspoc.
+// Processing is skipped, if err is already set.
+func mergeObjects[T object](a, b []T, err error) ([]T, error) {
+	if err != nil {
+		return a, err
+	}
+	for _, o := range b {
+		if !o.getReplace() {
+			a = append(a, o)
+			continue
+		}
+		o.clearReplace()
+		i := slices.IndexFunc(a, func(x T) bool {
+			return x.getName() == o.getName()
+		})
+		if i == -1 {
+			return a, fmt.Errorf("Can't replace unknown %s %q",
+				o.getAPIObject(), o.getName())
+		}
+		a[i] = o
+	}
+	return a, nil
 }
@@ -16,6 +16,7 @@ import (
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/httpdevice"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/journal"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
@@ -29,55 +30,30 @@ type State struct {
 	changes        []change
 	installTargets []string
 	routeChanges   []change
+	log            *errlog.Logger
 }
+
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
+
 type change struct {
 	endpoint string
 	postData any
 }
 
+// String returns change as single line for journal.
+func (c change) String() string {
+	postData, _ := json.Marshal(c.postData)
+	return c.endpoint + " " + string(postData)
+}
+
 type jsonMap map[string]any
 
 func (s *State) LoadDevice(
 	spocFile string, cfg *program.Config, logLogin, logConfig *os.File) error {
 
-	// Login to device and get session ID.
-	err := httpdevice.TryReachableHTTPLogin(spocFile, cfg,
-		func(name, ip, user, pass string) error {
-			s.client, s.prefix = httpdevice.GetHTTPClient(cfg, ip)
-			uri := s.prefix + "/web_api/login"
-			errlog.DoLog(logLogin, uri)
-			v := fmt.Sprintf(`{"user":"%s","password":"%s"}`, user, "xxx")
-			errlog.DoLog(logLogin, v)
-			v = fmt.Sprintf(`{"user":"%s","password":"%s"}`, user, pass)
-			resp, err :=
-				s.client.Post(uri, "application/json", strings.NewReader(v))
-			if err != nil {
-				return err
-			}
-			errlog.DoLog(logLogin, resp.Status)
-			defer resp.Body.Close()
-			if resp.StatusCode != http.StatusOK {
-				return fmt.Errorf("status code: %d", resp.StatusCode)
-			}
-			body, err := io.ReadAll(resp.Body)
-			if err != nil {
-				return err
-			}
-			var result struct{ Sid string }
-			err = json.Unmarshal(body, &result)
-			if err != nil {
-				return err
-			}
-			s.sid = result.Sid
-			s.user = user
-			b := strings.ReplaceAll(string(body), s.sid, "xxx")
-			errlog.DoLog(logLogin, b)
-			return nil
-		})
-	if err != nil {
+	if _, err := s.CheckAccess(spocFile, cfg, logLogin); err != nil {
 		return err
 	}
-
 	if err := s.discardSessions(logLogin); err != nil {
 		return err
 	}
@@ -239,6 +215,49 @@ func (s *State) LoadDevice(
 	return nil
 }
 
+// Login to device and get session ID.
+// Device name and management state are only known from device
+// configuration and aren't checked.
+func (s *State) CheckAccess(
+	spocFile string, cfg *program.Config, logLogin *os.File,
+) ([]string, error) {
+
+	err := httpdevice.TryReachableHTTPLogin(spocFile, cfg, s.log,
+		func(name, ip, user, pass string) error {
+			s.client, s.prefix = httpdevice.GetHTTPClient(cfg, ip)
+			uri := s.prefix + "/web_api/login"
+			errlog.DoLog(logLogin, uri)
+			v := fmt.Sprintf(`{"user":"%s","password":"%s"}`, user, "xxx")
+			errlog.DoLog(logLogin, v)
+			v = fmt.Sprintf(`{"user":"%s","password":"%s"}`, user, pass)
+			resp, err :=
+				s.client.Post(uri, "application/json", strings.NewReader(v))
+			if err != nil {
+				return err
+			}
+			errlog.DoLog(logLogin, resp.Status)
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return httpdevice.LoginStatusError(resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			var result struct{ Sid string }
+			err = json.Unmarshal(body, &result)
+			if err != nil {
+				return err
+			}
+			s.sid = result.Sid
+			s.user = user
+			b := strings.ReplaceAll(string(body), s.sid, "xxx")
+			errlog.DoLog(logLogin, b)
+			return nil
+		})
+	return nil, err
+}
+
 func (s *State) sendRequest(path string, body []byte, logFh *os.File,
 ) ([]byte, error) {
 	errlog.DoLog(logFh, path)
@@ -286,8 +305,12 @@ func (s *State) HasChanges() bool {
 }
 
 func (s *State) ShowChanges() string {
+	return showChanges(slices.Concat(s.changes, s.routeChanges))
+}
+
+func showChanges(l []change) string {
 	var collect strings.Builder
-	for _, chg := range slices.Concat(s.changes, s.routeChanges) {
+	for _, chg := range l {
 		postData, _ := json.Marshal(chg.postData)
 		fmt.Fprintln(&collect, chg.endpoint)
 		fmt.Fprintln(&collect, string(postData))
@@ -295,7 +318,32 @@ func (s *State) ShowChanges() string {
 	return collect.String()
 }
 
-func (s *State) ApplyCommands(logFh *os.File) error {
+// ChangeGroups returns changes of objects and rules, which are
+// published and installed together, separated from changes of routes.
+func (s *State) ChangeGroups() []program.ChangeGroup {
+	var result []program.ChangeGroup
+	if len(s.changes) != 0 {
+		result = append(result, program.ChangeGroup{
+			Name: "rules", Category: "rules", Changes: showChanges(s.changes)})
+	}
+	if len(s.routeChanges) != 0 {
+		result = append(result, program.ChangeGroup{
+			Name: "routes", Category: "routes",
+			Changes: showChanges(s.routeChanges)})
+	}
+	return result
+}
+
+func (s *State) DeselectChanges(name string) {
+	switch name {
+	case "rules":
+		s.changes = nil
+	case "routes":
+		s.routeChanges = nil
+	}
+}
+
+func (s *State) ApplyCommands(logFh *os.File, j *journal.Journal) error {
 	simulated := os.Getenv("SIMULATE_ROUTER") != ""
 	sendCmd := func(endpoint string, args any) ([]byte, error) {
 		url := "/web_api/" + endpoint
@@ -332,7 +380,7 @@ func (s *State) ApplyCommands(logFh *os.File) error {
 			case "succeeded":
 				return nil
 			case "succeeded with warnings":
-				errlog.Warning("task %q succeeded with warnings", cmd)
+				s.log.Warning("task %q succeeded with warnings", cmd)
 				return nil
 			default:
 				return fmt.Errorf("Unexpected status of task %q: %q",
@@ -354,27 +402,39 @@ func (s *State) ApplyCommands(logFh *os.File) error {
 		}
 		return waitTask(result.TaskID)
 	}
+	send := func(c change) error {
+		return j.Send(c.String(), func() error {
+			_, err := sendCmd(c.endpoint, c.postData)
+			return err
+		})
+	}
+	wait := func(c change) error {
+		err := j.Send(c.String(), func() error {
+			return waitCmd(c.endpoint, c.postData)
+		})
+		return errlog.Reclassify(errlog.CommitFailed, err)
+	}
 	if len(s.changes) > 0 {
 		for _, c := range s.changes {
-			if _, err := sendCmd(c.endpoint, c.postData); err != nil {
+			if err := send(c); err != nil {
 				return err
 			}
 		}
-		if err := waitCmd("publish", jsonMap{}); err != nil {
+		if err := wait(change{"publish", jsonMap{}}); err != nil {
 			return err
 		}
 		for _, target := range s.installTargets {
 			pName := s.deviceCfg.TargetPolicy[target].Name
-			err := waitCmd("install-policy", jsonMap{
+			err := wait(change{"install-policy", jsonMap{
 				"policy-package": pName,
-				"targets":        []string{target}})
+				"targets":        []string{target}}})
 			if err != nil {
 				return err
 			}
 		}
 	}
 	for _, c := range s.routeChanges {
-		if _, err := sendCmd(c.endpoint, c.postData); err != nil {
+		if err := send(c); err != nil {
 			return err
 		}
 	}
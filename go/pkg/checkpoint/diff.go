@@ -2,10 +2,13 @@ package checkpoint
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/netip"
 	"slices"
 	"strings"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 	"github.com/pkg/diff/myers"
 )
 
@@ -401,3 +404,77 @@ func toLower(s string) string {
 func cmpFold(a, b chkpName) int {
 	return strings.Compare(toLower(string(a)), toLower(string(b)))
 }
+
+// Protect removes rules and routes from configuration of device, that
+// match entries of protect file. Objects matching protect file and
+// objects referenced by protected rules are marked as needed.
+// Hence all these are left unchanged on device.
+// Netspoc must not generate protected rules, objects and routes.
+func (s *State) Protect(l program.ProtectList) error {
+	a, b := s.deviceCfg, s.spocCfg
+	for _, bObj := range getObjList(b) {
+		if l.Match("object", bObj.getName()) {
+			return fmt.Errorf("Must not change protected %s %q",
+				bObj.getAPIObject(), bObj.getName())
+		}
+	}
+	for target, bRules := range b.TargetRules {
+		for _, r := range bRules {
+			if l.Match("rule", r.Name) {
+				return fmt.Errorf("Must not change protected rule %q of %q",
+					r.Name, target)
+			}
+		}
+	}
+	for gw, bRoutes := range b.GatewayRoutes {
+		for _, r := range bRoutes {
+			if l.MatchRoute(r.prefix()) {
+				return fmt.Errorf("Must not change protected route %s/%d of %q",
+					r.Address, r.MaskLength, gw)
+			}
+		}
+	}
+	aObjMap := make(map[string]object)
+	for _, o := range getObjList(a) {
+		aObjMap[toLower(o.getName())] = o
+	}
+	var markNeeded func(l []chkpName)
+	markNeeded = func(l []chkpName) {
+		for _, n := range l {
+			if o, found := aObjMap[toLower(string(n))]; found && !o.getNeeded() {
+				o.setNeeded()
+				if g, ok := o.(*chkpGroup); ok {
+					markNeeded(g.Members)
+				}
+			}
+		}
+	}
+	for _, o := range getObjList(a) {
+		if l.Match("object", o.getName()) {
+			markNeeded([]chkpName{chkpName(o.getName())})
+		}
+	}
+	for target, aRules := range a.TargetRules {
+		a.TargetRules[target] = slices.DeleteFunc(aRules, func(r *chkpRule) bool {
+			if !l.Match("rule", r.Name) {
+				return false
+			}
+			markNeeded(r.Source)
+			markNeeded(r.Destination)
+			markNeeded(r.Service)
+			return true
+		})
+	}
+	for gw, aRoutes := range a.GatewayRoutes {
+		a.GatewayRoutes[gw] = slices.DeleteFunc(aRoutes, func(r *chkpRoute) bool {
+			return l.MatchRoute(r.prefix())
+		})
+	}
+	return nil
+}
+
+func (r *chkpRoute) prefix() netip.Prefix {
+	ip, _ := netip.ParseAddr(r.Address)
+	p, _ := ip.Prefix(r.MaskLength)
+	return p
+}
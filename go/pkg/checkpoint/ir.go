@@ -0,0 +1,174 @@
+package checkpoint
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
+)
+
+// ExportRules converts rules of each target to vendor neutral
+// representation. Referenced groups are expanded.
+func (s *State) ExportRules(device bool) *ir.Config {
+	cf := s.spocCfg
+	if device {
+		cf = s.deviceCfg
+	}
+	result := &ir.Config{}
+	if cf == nil {
+		return result
+	}
+	objects := make(map[string]any)
+	for _, o := range getObjList(cf) {
+		objects[o.getName()] = o
+	}
+	for target, rules := range cf.TargetRules {
+		rs := &ir.RuleSet{
+			Name:     target,
+			Bindings: []*ir.Binding{{Name: target}},
+			Default:  ir.Deny,
+		}
+		for i, r := range rules {
+			if r.Disabled {
+				continue
+			}
+			rs.Rules = append(rs.Rules, convertRule(r, i+1, objects))
+		}
+		result.RuleSets = append(result.RuleSets, rs)
+	}
+	sort.Slice(result.RuleSets, func(i, j int) bool {
+		return result.RuleSets[i].Name < result.RuleSets[j].Name
+	})
+	return result
+}
+
+func convertRule(r *chkpRule, pos int, objects map[string]any) *ir.Rule {
+	result := &ir.Rule{
+		Name:     r.Name,
+		Position: pos,
+		Src:      convertAddresses(r.Source, r.SourceNegate, objects),
+		Dst:      convertAddresses(r.Destination, r.DestinationNegate, objects),
+		Raw:      strings.HasPrefix(r.Name, "Raw "),
+		Orig:     r.Name,
+	}
+	switch r.Action {
+	case "Accept":
+		result.Action = ir.Permit
+	default:
+		result.Action = ir.Deny
+	}
+	if t := r.Track; t != nil && t.Type != "" && t.Type != "None" {
+		result.Log = true
+	}
+	if !isAny(r.Service) {
+		for _, n := range r.Service {
+			result.Services = append(result.Services,
+				convertService(string(n), objects, make(map[string]bool))...)
+		}
+		if r.ServiceNegate {
+			result.Services = []*ir.Service{
+				{Unknown: "negated service " + joinNames(r.Service)}}
+		}
+	}
+	return result
+}
+
+func isAny(l []chkpName) bool {
+	return len(l) == 0 || len(l) == 1 && l[0] == "Any"
+}
+
+func joinNames(l []chkpName) string {
+	var sl []string
+	for _, n := range l {
+		sl = append(sl, string(n))
+	}
+	return strings.Join(sl, ",")
+}
+
+func convertAddresses(
+	l []chkpName, negate bool, objects map[string]any) *ir.AddrSet {
+
+	if isAny(l) {
+		a := ir.AnyAddr()
+		a.Negate = negate
+		return a
+	}
+	a := &ir.AddrSet{Negate: negate}
+	var add func(string, map[string]bool)
+	add = func(n string, seen map[string]bool) {
+		switch o := objects[n].(type) {
+		case *chkpHost:
+			if o.IPv4Address != "" {
+				a.Add(o.IPv4Address)
+			}
+			if o.IPv6Address != "" {
+				a.Add(o.IPv6Address)
+			}
+		case *chkpNetwork:
+			if o.Subnet4 != "" {
+				a.Add(o.Subnet4 + "/" + strconv.Itoa(o.MaskLength4))
+			}
+			if o.Subnet6 != "" {
+				a.Add(o.Subnet6 + "/" + strconv.Itoa(o.MaskLength6))
+			}
+		case *chkpGroup:
+			if seen[n] {
+				a.Unknown = append(a.Unknown, n)
+				return
+			}
+			seen[n] = true
+			for _, m := range o.Members {
+				add(string(m), seen)
+			}
+		default:
+			a.Unknown = append(a.Unknown, n)
+		}
+	}
+	for _, n := range l {
+		add(string(n), make(map[string]bool))
+	}
+	a.Prefixes = uniqPrefixes(a.Prefixes)
+	return a
+}
+
+func uniqPrefixes(l []netip.Prefix) []netip.Prefix {
+	seen := make(map[netip.Prefix]bool)
+	var result []netip.Prefix
+	for _, p := range l {
+		if !seen[p] {
+			seen[p] = true
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
+func convertService(
+	n string, objects map[string]any, seen map[string]bool) []*ir.Service {
+
+	switch o := objects[n].(type) {
+	case *chkpTCP:
+		return []*ir.Service{ir.ServiceFromPorts(ir.TCP, o.SourcePort, o.Port)}
+	case *chkpUDP:
+		return []*ir.Service{ir.ServiceFromPorts(ir.UDP, o.SourcePort, o.Port)}
+	case *chkpICMP:
+		return []*ir.Service{{Proto: ir.ICMP, Type: o.IcmpType, Code: o.IcmpCode}}
+	case *chkpICMP6:
+		return []*ir.Service{
+			{Proto: ir.ICMPv6, Type: o.IcmpType, Code: o.IcmpCode}}
+	case *chkpSvOther:
+		return []*ir.Service{{Proto: ir.ProtoName(strconv.Itoa(o.IpProtocol))}}
+	case *chkpGroup:
+		if !seen[n] {
+			seen[n] = true
+			var result []*ir.Service
+			for _, m := range o.Members {
+				result = append(result, convertService(string(m), objects, seen)...)
+			}
+			return result
+		}
+	}
+	return []*ir.Service{{Unknown: n}}
+}
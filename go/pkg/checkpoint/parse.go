@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"path"
 	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/codefiles"
 )
 
 type chkpConfig struct {
@@ -45,8 +47,15 @@ type chkpRule struct {
 	Track             *chkpTrack   `json:"track,omitempty"`
 	InstallOn         []chkpName   `json:"install-on"`
 	Position          any          `json:"position,omitempty"`
-	Append            bool         `json:"append,omitempty"` // From raw file.
-	needed            bool
+	// Attributes from raw file, that control merging with rules from
+	// Netspoc. Rules are prepended per default.
+	Append  bool   `json:"append,omitempty"`
+	Prepend bool   `json:"prepend,omitempty"`
+	Replace bool   `json:"replace,omitempty"`
+	Delete  bool   `json:"delete,omitempty"`
+	Before  string `json:"before,omitempty"`
+	After   string `json:"after,omitempty"`
+	needed  bool
 }
 
 type chkpName string
@@ -107,6 +116,8 @@ type object interface {
 	setDeletable()
 	getChanged() bool
 	setChanged()
+	getReplace() bool
+	clearReplace()
 }
 
 type chkpObject struct {
@@ -115,6 +126,7 @@ type chkpObject struct {
 	Comments       string `json:"comments,omitempty"`
 	IgnoreWarnings bool   `json:"ignore-warnings,omitempty"`
 	ReadOnly       bool   `json:"read-only,omitempty"`
+	Replace        bool   `json:"replace,omitempty"` // From raw file.
 	needed         bool
 	deletable      bool
 	changed        bool
@@ -133,6 +145,8 @@ func (o *chkpObject) getDeletable() bool  { return o.deletable }
 func (o *chkpObject) setDeletable()       { o.deletable = true }
 func (o *chkpObject) getChanged() bool    { return o.changed }
 func (o *chkpObject) setChanged()         { o.changed = true }
+func (o *chkpObject) getReplace() bool    { return o.Replace }
+func (o *chkpObject) clearReplace()       { o.Replace = false }
 
 func (o *chkpNetwork) getAPIObject() string { return "network" }
 func (o *chkpHost) getAPIObject() string    { return "host" }
@@ -211,11 +225,15 @@ func (s *State) parseConfig(data []byte, fName string,
 	if len(data) == 0 {
 		return cf, nil
 	}
+	isRaw := path.Ext(fName) == ".raw"
 	err := json.Unmarshal(data, cf)
 	if err != nil {
+		if isRaw {
+			err = codefiles.AddJSONLine(data, 0, err)
+		}
 		return nil, err
 	}
-	if path.Ext(fName) == ".raw" {
+	if isRaw {
 		if err := checkRaw(cf); err != nil {
 			return nil, err
 		}
@@ -246,7 +264,20 @@ func checkRaw(cf *chkpConfig) error {
 		return nil
 	}
 	for target, rules := range cf.TargetRules {
+		var added []*chkpRule
 		for _, r := range rules {
+			if err := checkMarkers(r); err != nil {
+				return err
+			}
+			if r.Delete {
+				continue
+			}
+			added = append(added, r)
+			// Replacing rule has name of rule from Netspoc and
+			// may reference names from Netspoc.
+			if r.Replace {
+				continue
+			}
 			if err := checkName(r.Name); err != nil {
 				return err
 			}
@@ -256,16 +287,22 @@ func checkRaw(cf *chkpConfig) error {
 				}
 			}
 		}
-		if err := checkInstallOn(rules, target); err != nil {
+		if err := checkInstallOn(added, target); err != nil {
 			return err
 		}
 	}
 	for _, g := range cf.Groups {
+		if g.Replace {
+			continue
+		}
 		if err := checkRef(g.Name, g.Members); err != nil {
 			return err
 		}
 	}
 	for _, o := range getObjList(cf) {
+		if o.getReplace() {
+			continue
+		}
 		if err := checkName(o.getName()); err != nil {
 			return err
 		}
@@ -273,6 +310,22 @@ func checkRaw(cf *chkpConfig) error {
 	return nil
 }
 
+func checkMarkers(r *chkpRule) error {
+	n := 0
+	for _, b := range []bool{r.Append, r.Prepend, r.Replace, r.Delete,
+		r.Before != "", r.After != ""} {
+		if b {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("Must use only one of \"append\", \"prepend\","+
+			" \"replace\", \"delete\", \"before\", \"after\" in rule %q",
+			r.Name)
+	}
+	return nil
+}
+
 func checkInstallOn(l []*chkpRule, target string) error {
 	for _, r := range l {
 		if l := r.InstallOn; len(l) == 1 {
@@ -2,10 +2,9 @@ package cisco
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
-
-	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 )
 
 func (s *state) LoadNetspoc(data []byte, fName string) error {
@@ -15,29 +14,89 @@ func (s *state) LoadNetspoc(data []byte, fName string) error {
 	}
 	if s.spocCfg == nil {
 		s.spocCfg = cfg
-	} else {
-		s.mergeSpoc(cfg)
+		return nil
 	}
-	return nil
+	return s.mergeSpoc(cfg)
 }
 
 func (s *state) MoveNetspoc2DeviceConfig() {
 	s.deviceCfg, s.spocCfg = s.spocCfg, nil
 }
 
-// Check that non anchor commands from raw file are referenced by some
-// anchor and are referenced only once.
-var isReferenced map[*cmd]bool
+type dumpConfig struct {
+	Lookup  map[string]map[string][]*dumpCmd `json:"lookup"`
+	Ignored []string                         `json:"ignored,omitempty"`
+}
 
-func (s *state) mergeSpoc(b *config) {
+type dumpCmd struct {
+	Orig      string     `json:"orig"`
+	Parsed    string     `json:"parsed"`
+	Name      string     `json:"name,omitempty"`
+	Seq       int        `json:"seq,omitempty"`
+	Ref       []string   `json:"ref,omitempty"`
+	Anchor    bool       `json:"anchor,omitempty"`
+	FixedName bool       `json:"fixed_name,omitempty"`
+	SimpleObj bool       `json:"simple_obj,omitempty"`
+	ClearConf bool       `json:"clear_conf,omitempty"`
+	Marker    string     `json:"marker,omitempty"`
+	Sub       []*dumpCmd `json:"sub,omitempty"`
+	Ignored   []string   `json:"ignored,omitempty"`
+}
+
+// DumpNetspoc returns parsed configuration from Netspoc
+// in a form suitable for output as JSON.
+func (s *state) DumpNetspoc() any {
+	var conv func(c *cmd) *dumpCmd
+	conv = func(c *cmd) *dumpCmd {
+		d := &dumpCmd{
+			Orig:      c.orig,
+			Parsed:    c.parsed,
+			Name:      c.name,
+			Seq:       c.seq,
+			Ref:       c.ref,
+			Anchor:    c.typ.anchor || c.anchor,
+			FixedName: c.typ.fixedName || c.fixedName,
+			SimpleObj: c.typ.simpleObj,
+			ClearConf: c.typ.clearConf,
+			Ignored:   c.ignored,
+		}
+		if c.mark.kind != markPrepend {
+			d.Marker = c.mark.String()
+		}
+		for _, sc := range c.sub {
+			d.Sub = append(d.Sub, conv(sc))
+		}
+		return d
+	}
+	result := &dumpConfig{Lookup: make(map[string]map[string][]*dumpCmd)}
+	if cf := s.spocCfg; cf != nil {
+		for prefix, m := range cf.lookup {
+			dm := make(map[string][]*dumpCmd)
+			for name, l := range m {
+				for _, c := range l {
+					dm[name] = append(dm[name], conv(c))
+				}
+			}
+			if len(dm) != 0 {
+				result.Lookup[prefix] = dm
+			}
+		}
+		result.Ignored = cf.ignored
+	}
+	return result
+}
+
+func (s *state) mergeSpoc(b *config) error {
 	a := s.spocCfg
+	a.ignored = append(a.ignored, b.ignored...)
 	lookup := a.lookup
 	for prefix := range b.lookup {
 		if lookup[prefix] == nil {
 			lookup[prefix] = make(map[string][]*cmd)
 		}
 	}
-	isReferenced = make(map[*cmd]bool)
+	b.isReferenced = make(map[*cmd]bool)
+	isReferenced := b.isReferenced
 	for prefix, bMap := range b.lookup {
 		aMap := lookup[prefix]
 		for name, bl := range bMap {
@@ -54,7 +113,16 @@ func (s *state) mergeSpoc(b *config) {
 					aCmds: al,
 					bCmds: bl,
 				}
-				mergeCmds(ab, name, prefix)
+				if err := mergeCmds(ab, name, prefix); err != nil {
+					return err
+				}
+			} else if replacesObject(bCmd) {
+				if !isReferenced[bCmd] {
+					err := replaceObject(&cmdsPair{a: a, b: b}, bl, name, prefix)
+					if err != nil {
+						return err
+					}
+				}
 			} else if b.isRaw && !isReferenced[bCmd] {
 				isReferenced[bCmd] = false
 			}
@@ -70,50 +138,107 @@ func (s *state) mergeSpoc(b *config) {
 	}
 	sort.Strings(warnings)
 	for _, w := range warnings {
-		errlog.Warning("%v", w)
+		s.log.Warning("%v", w)
+	}
+	return nil
+}
+
+// replacesObject returns true, if command from raw file replaces
+// object with same name from Netspoc. Lines of ACLs are replaced
+// in mergeACLMarked.
+func replacesObject(c *cmd) bool {
+	if c.typ.prefix == "access-list" || isIOSACL(c.typ.prefix) {
+		return false
 	}
+	return c.mark.kind == markReplace
 }
 
-func mergeCmds(ab *cmdsPair, name, prefix string) {
+// replaceObject replaces object from Netspoc by object with same
+// name from raw file.
+func replaceObject(ab *cmdsPair, bl []*cmd, name, prefix string) error {
+	if _, found := ab.a.lookup[prefix][name]; !found {
+		return fmt.Errorf("Can't replace unknown '%s %s' from raw", prefix, name)
+	}
+	for _, b := range bl {
+		ab.b.isReferenced[b] = true
+		if err := mergeRefsWithSub(ab, b); err != nil {
+			return err
+		}
+	}
+	ab.a.lookup[prefix][name] = bl
+	return nil
+}
+
+func mergeCmds(ab *cmdsPair, name, prefix string) error {
 	key := byParsedCmd
 	switch prefix {
 	case "crypto map":
-		mergeCryptoMap(ab, name, prefix)
-		return
+		return mergeCryptoMap(ab, name, prefix)
 	case "crypto dynamic-map":
-		mergeCryptoDynMap(ab, name, prefix)
-		return
+		return mergeCryptoDynMap(ab, name, prefix)
 	case "access-list":
-		mergeASAACLs(ab, name, prefix)
-		return
-	case "ip access-list extended":
-		mergeIOSACLs(ab, name, prefix)
-		return
+		return mergeASAACLs(ab, name, prefix)
+	case "nat":
+		return mergeNAT(ab, name, prefix)
 	case "tunnel-group-map":
 		key = byCertMapKey
 	}
+	if isIOSACL(prefix) {
+		return mergeIOSACLs(ab, name, prefix)
+	}
 	al := ab.aCmds
 	bl := ab.bCmds
+	if slices.ContainsFunc(bl, func(b *cmd) bool {
+		return b.mark.kind == markReplace
+	}) {
+		al = nil
+	}
 	m := make(map[string]*cmd)
 	for _, a := range al {
 		m[key(ab.a, a)] = a
 	}
 	for _, b := range bl {
+		if b.mark.kind == markDelete {
+			k := key(ab.b, b)
+			if _, found := m[k]; !found {
+				return fmt.Errorf("Can't delete unknown '%s' from raw", b.orig)
+			}
+			delete(m, k)
+			al = slices.DeleteFunc(al, func(a *cmd) bool {
+				return key(ab.a, a) == k
+			})
+			continue
+		}
 		if a, found := m[key(ab.b, b)]; found {
-			mergeSubCmds(ab, a, b)
-			mergeRefs(ab, a, b)
+			if err := mergeSubCmds(ab, a, b); err != nil {
+				return err
+			}
+			if err := mergeRefs(ab, a, b); err != nil {
+				return err
+			}
 		} else {
-			for _, bs := range b.sub {
-				mergeRefs(ab, nil, bs)
+			if err := mergeRefsWithSub(ab, b); err != nil {
+				return err
 			}
-			mergeRefs(ab, nil, b)
 			al = append(al, b)
 		}
 	}
 	ab.a.lookup[prefix][name] = al
+	return nil
 }
 
-func mergeSubCmds(ab *cmdsPair, a, b *cmd) {
+// mergeRefsWithSub adds objects referenced by added command b and
+// by its subcommands.
+func mergeRefsWithSub(ab *cmdsPair, b *cmd) error {
+	for _, bs := range b.sub {
+		if err := mergeRefs(ab, nil, bs); err != nil {
+			return err
+		}
+	}
+	return mergeRefs(ab, nil, b)
+}
+
+func mergeSubCmds(ab *cmdsPair, a, b *cmd) error {
 	key := byParsedCmd
 	if a.typ.prefix == "webvpn" {
 		key = byCertMapKey
@@ -124,24 +249,33 @@ func mergeSubCmds(ab *cmdsPair, a, b *cmd) {
 	}
 	for _, bs := range b.sub {
 		as := m[key(ab.b, bs)]
-		mergeRefs(ab, as, bs)
+		if err := mergeRefs(ab, as, bs); err != nil {
+			return err
+		}
 		if as == nil {
 			a.sub = append(a.sub, bs)
 		}
 	}
+	return nil
 }
 
-func mergeRefs(ab *cmdsPair, a, b *cmd) {
+func mergeRefs(ab *cmdsPair, a, b *cmd) error {
 	for i, bName := range b.ref {
 		prefix := b.typ.ref[i]
 		bl := ab.b.lookup[prefix][bName]
 		refCmd := bl[0]
 		if refCmd.typ.simpleObj {
-			isReferenced[refCmd] = true
-			al := findSimpleObject(bl, ab.a)
+			ab.b.isReferenced[refCmd] = true
+			al := findSimpleObject(bl, ab.a, ab.b)
 			if al == nil {
-				if _, found := ab.a.lookup[prefix][bName]; found && ab.b.isRaw {
-					errlog.Abort("Name clash for '%s %s' from raw", prefix, bName)
+				if _, found := ab.a.lookup[prefix][bName]; found && ab.b.isRaw &&
+					!replacesObject(refCmd) {
+					return fmt.Errorf("Name clash for '%s %s' from raw",
+						prefix, bName)
+				}
+				// Add objects referenced by added simple object.
+				if err := mergeRefsWithSub(ab, refCmd); err != nil {
+					return err
 				}
 				ab.a.lookup[prefix][bName] = bl
 				al = bl
@@ -154,11 +288,19 @@ func mergeRefs(ab *cmdsPair, a, b *cmd) {
 			}
 			continue
 		}
+		if replacesObject(refCmd) {
+			if !ab.b.isReferenced[refCmd] {
+				if err := replaceObject(ab, bl, bName, prefix); err != nil {
+					return err
+				}
+			}
+			continue
+		}
 		var al []*cmd = nil
 		storeName := bName
 		if a != nil {
-			if ab.b.isRaw && isReferenced[refCmd] {
-				errlog.Abort("Must reference '%s %s' only once in raw",
+			if ab.b.isRaw && ab.b.isReferenced[refCmd] {
+				return fmt.Errorf("Must reference '%s %s' only once in raw",
 					prefix, bName)
 			}
 			storeName = a.ref[i]
@@ -167,27 +309,40 @@ func mergeRefs(ab *cmdsPair, a, b *cmd) {
 				b.name = storeName
 			}
 		} else if _, found := ab.a.lookup[prefix][bName]; found && ab.b.isRaw {
-			errlog.Abort("Name clash for '%s %s' from raw", prefix, bName)
+			return fmt.Errorf("Name clash for '%s %s' from raw", prefix, bName)
 		}
-		isReferenced[refCmd] = true
+		ab.b.isReferenced[refCmd] = true
 		refPair := *ab
 		refPair.aCmds = al
 		refPair.bCmds = bl
-		mergeCmds(&refPair, storeName, prefix)
+		if err := mergeCmds(&refPair, storeName, prefix); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
-func mergeASAACLs(ab *cmdsPair, name, prefix string) {
-	acl := ab.aCmds
-	var prependACL, appendACL []*cmd
-	for _, b := range ab.bCmds {
-		if b.append {
-			appendACL = append(appendACL, b)
-		} else {
-			prependACL = append(prependACL, b)
+// mergeRefsOfLines adds, not merges objects referenced by lines of
+// ACL or NAT from raw file.
+func mergeRefsOfLines(ab *cmdsPair, l []*cmd) error {
+	for _, b := range l {
+		if b.mark.kind != markDelete {
+			if err := mergeRefs(ab, nil, b); err != nil {
+				return err
+			}
 		}
-		// Add, not merge referenced object-groups.
-		mergeRefs(ab, nil, b)
+	}
+	return nil
+}
+
+func mergeASAACLs(ab *cmdsPair, name, prefix string) error {
+	// Add, not merge referenced object-groups.
+	if err := mergeRefsOfLines(ab, ab.bCmds); err != nil {
+		return err
+	}
+	acl, prependACL, appendACL, err := mergeACLMarked(ab.aCmds, ab.bCmds, name)
+	if err != nil {
+		return err
 	}
 	if len(prependACL) > 0 {
 		// By default prepend ACL lines, but append
@@ -213,23 +368,42 @@ func mergeASAACLs(ab *cmdsPair, name, prefix string) {
 	}
 	// Store changed ACL.
 	ab.a.lookup[prefix][name] = acl
+	return nil
+}
+
+// mergeNAT merges manual NAT commands from raw file like lines of ACL.
+// Commands without marker are prepended.
+func mergeNAT(ab *cmdsPair, name, prefix string) error {
+	if err := mergeRefsOfLines(ab, ab.bCmds); err != nil {
+		return err
+	}
+	l, prepend, appnd, err := mergeACLMarked(ab.aCmds, ab.bCmds, prefix)
+	if err != nil {
+		return err
+	}
+	l = append(prepend, l...)
+	l = append(l, appnd...)
+	ab.a.lookup[prefix][name] = l
+	return nil
 }
 
-func mergeIOSACLs(ab *cmdsPair, name, prefix string) {
+func mergeIOSACLs(ab *cmdsPair, name, prefix string) error {
 	var acl []*cmd
 	if l := ab.aCmds; len(l) > 0 {
 		acl = ab.aCmds[0].sub
 	}
-	var prependACL, appendACL []*cmd
 	// Allow multiple occurences of same ACL in raw.
+	var raw []*cmd
 	for _, b := range ab.bCmds {
-		for _, sb := range b.sub {
-			if sb.append {
-				appendACL = append(appendACL, sb)
-			} else {
-				prependACL = append(prependACL, sb)
-			}
-		}
+		raw = append(raw, b.sub...)
+	}
+	// Add, not merge referenced object-groups.
+	if err := mergeRefsOfLines(ab, raw); err != nil {
+		return err
+	}
+	acl, prependACL, appendACL, err := mergeACLMarked(acl, raw, name)
+	if err != nil {
+		return err
 	}
 	if len(prependACL) > 0 {
 		acl = append(prependACL, acl...)
@@ -250,20 +424,96 @@ func mergeIOSACLs(ab *cmdsPair, name, prefix string) {
 	b0 := ab.bCmds[0]
 	b0.sub = acl
 	ab.a.lookup[prefix][name] = []*cmd{b0}
+	return nil
+}
+
+// mergeACLMarked merges lines of ACL from raw file, that are marked
+// with [REPLACE], [DELETE], [BEFORE ...] or [AFTER ...], into lines
+// of ACL from Netspoc. Lines to be prepended and lines marked
+// with [APPEND] are returned for further processing.
+func mergeACLMarked(acl, raw []*cmd, name string,
+) (l, prepend, appnd []*cmd, err error) {
+	var replace []*cmd
+	for _, b := range raw {
+		if b.mark.kind == markReplace {
+			replace = append(replace, b)
+		}
+	}
+	if replace != nil {
+		acl = replace
+	}
+	// Find line from Netspoc or line added by preceeding marker.
+	find := func(line string) int {
+		line = aclLine(line)
+		return slices.IndexFunc(acl, func(a *cmd) bool {
+			return aclLine(a.orig) == line
+		})
+	}
+	for i := 0; i < len(raw); {
+		// Process group of adjacent lines with same marker.
+		b := raw[i]
+		j := i + 1
+		for j < len(raw) && raw[j].mark == b.mark {
+			j++
+		}
+		group := raw[i:j]
+		i = j
+		switch b.mark.kind {
+		case markDelete:
+			for _, d := range group {
+				line := aclLine(d.orig)
+				k := find(line)
+				if k == -1 {
+					return nil, nil, nil, fmt.Errorf(
+						"Can't delete unknown line '%s' of ACL %s from raw",
+						line, name)
+				}
+				acl = slices.Delete(acl, k, k+1)
+			}
+		case markBefore, markAfter:
+			k := find(b.mark.line)
+			if k == -1 {
+				return nil, nil, nil, fmt.Errorf(
+					"Can't find line of [%s] in ACL %s from raw", b.mark, name)
+			}
+			if b.mark.kind == markAfter {
+				k++
+			}
+			acl = slices.Insert(acl, k, group...)
+		case markAppend:
+			appnd = append(appnd, group...)
+		case markPrepend:
+			prepend = append(prepend, group...)
+		}
+	}
+	return acl, prepend, appnd, nil
+}
+
+// aclLine returns line of ACL with normalized whitespace and without
+// prefix "access-list NAME extended" of ASA.
+func aclLine(line string) string {
+	w := strings.Fields(line)
+	if len(w) > 3 && w[0] == "access-list" {
+		w = w[3:]
+	}
+	return strings.Join(w, " ")
 }
 
-func mergeCryptoMap(ab *cmdsPair, name, prefix string) {
+func mergeCryptoMap(ab *cmdsPair, name, prefix string) error {
 	al := ab.aCmds
-	matchCryptoMap(al, ab.bCmds, func(aSeqL, bSeqL []*cmd) {
-		add := mergeCryptoCommon(ab, aSeqL, bSeqL)
+	err := matchCryptoMap(al, ab.bCmds, func(aSeqL, bSeqL []*cmd) error {
+		add, err := mergeCryptoCommon(ab, aSeqL, bSeqL)
 		al = append(al, add...)
+		return err
 	})
 	ab.a.lookup[prefix][name] = al
+	return err
 }
 
-func mergeCryptoDynMap(ab *cmdsPair, name, prefix string) {
-	add := mergeCryptoCommon(ab, ab.aCmds, ab.bCmds)
+func mergeCryptoDynMap(ab *cmdsPair, name, prefix string) error {
+	add, err := mergeCryptoCommon(ab, ab.aCmds, ab.bCmds)
 	ab.a.lookup[prefix][name] = append(ab.aCmds, add...)
+	return err
 }
 
 // When comparing commands, take 5. and 6. word as key.
@@ -291,7 +541,7 @@ func mergeCryptoDynMap(ab *cmdsPair, name, prefix string) {
 // crypto dynamic-map $NAME $SEQ set reverse-route
 //	crypto dynamic-map $NAME $SEQ set security-association lifetime *
 
-func mergeCryptoCommon(ab *cmdsPair, al, bl []*cmd) []*cmd {
+func mergeCryptoCommon(ab *cmdsPair, al, bl []*cmd) ([]*cmd, error) {
 	key := func(c *cmd) [2]string {
 		tokens := strings.Split(c.parsed, " ")
 		return [2]string(tokens[4:6])
@@ -302,11 +552,12 @@ func mergeCryptoCommon(ab *cmdsPair, al, bl []*cmd) []*cmd {
 		m[key(aCmd)] = aCmd
 	}
 	for _, bCmd := range bl {
+		var err error
 		if aCmd, found := m[key(bCmd)]; found {
 			if aCmd.parsed == bCmd.parsed {
-				mergeRefs(ab, aCmd, bCmd)
+				err = mergeRefs(ab, aCmd, bCmd)
 			} else {
-				mergeRefs(ab, nil, bCmd)
+				err = mergeRefs(ab, nil, bCmd)
 				aCmd.parsed = bCmd.parsed
 				aCmd.ref = bCmd.ref
 			}
@@ -315,8 +566,11 @@ func mergeCryptoCommon(ab *cmdsPair, al, bl []*cmd) []*cmd {
 				bCmd.seq = al[0].seq
 			}
 			add = append(add, bCmd)
-			mergeRefs(ab, nil, bCmd)
+			err = mergeRefs(ab, nil, bCmd)
+		}
+		if err != nil {
+			return nil, err
 		}
 	}
-	return add
+	return add, nil
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/codefiles"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/console"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/journal"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
@@ -17,25 +18,34 @@ type state struct {
 	realCisco
 	parser
 	conn         *console.Conn
+	spocFile     string
+	cfg          *program.Config
+	log          *errlog.Logger
 	errUnmanaged []error
 	deviceCfg    *config
 	spocCfg      *config
 	changes      []string
+	changeGroup  []string // Name of group of each element of changes
+	diffErr      error
 	subCmdOf     string
 }
 
 type realCisco interface {
 	GetCmdInfo() string
 	RemoveBanner(data []byte) []byte
-	SetTerminal(*console.Conn)
-	CheckDeviceName(string, *console.Conn)
-	PrepareDevice(*console.Conn)
-	ScheduleReload(*console.Conn)
-	ExtendReload(*console.Conn)
-	CancelReload(*console.Conn)
-	StripReloadBanner(string, *console.Conn) (string, bool)
+	SetTerminal(*console.Conn) error
+	CheckDeviceName(name, boxName string, conn *console.Conn) error
+	CheckFailover(*console.Conn) (string, error)
+	PrepareDevice(*console.Conn) error
+	ScheduleReload(*console.Conn, *program.Config) error
+	ExtendReload(*console.Conn) error
+	CancelReload(*console.Conn) error
+	ReconnectOnLoss() bool
+	Rollback(*console.Conn)
+	StripReloadBanner(string, *console.Conn) (string, bool, error)
 	IsValidOutput(string, string) bool
-	WriteMem(*console.Conn)
+	WriteMem(*console.Conn) error
+	SetLogger(*errlog.Logger)
 }
 
 func Setup(d realCisco) *state {
@@ -44,68 +54,132 @@ func Setup(d realCisco) *state {
 	return s
 }
 
+func (s *state) SetLogger(l *errlog.Logger) {
+	s.log = l
+	s.realCisco.SetLogger(l)
+}
+
 func (s *state) LoadDevice(
 	spocFile string, cfg *program.Config, logLogin, logConfig *os.File) error {
 
-	user, pass, err := cfg.GetUserPass(codefiles.GetHostname(spocFile))
+	_, err := s.CheckAccess(spocFile, cfg, logLogin)
 	if err != nil {
 		return err
 	}
-	s.conn, err = console.GetSSHConn(spocFile, user, cfg, logLogin)
+	s.conn.SetLogFH(logConfig)
+	s.log.Info("Requesting device config")
+	out, err := s.conn.GetCmdOutput("sh run")
 	if err != nil {
 		return err
 	}
-	hostName := codefiles.GetHostname(spocFile)
-	s.loginEnable(pass, cfg)
-	s.SetTerminal(s.conn)
-	s.logVersion()
-	s.CheckDeviceName(hostName, s.conn)
-
-	s.conn.SetLogFH(logConfig)
-	errlog.Info("Requesting device config")
-	out := s.conn.GetCmdOutput("sh run")
-	errlog.Info("Got device config")
+	s.log.Info("Got device config")
 	s.deviceCfg, err = s.parseConfig([]byte(out), "<device>")
-	errlog.Info("Parsed device config")
+	s.log.Info("Parsed device config")
 	if err != nil {
 		err = fmt.Errorf("While reading device: %v", err)
 	}
 	return err
 }
 
-func (s *state) loginEnable(pass string, cfg *program.Config) {
+// Login to device, check device name and banner.
+// Return description of checked properties.
+func (s *state) CheckAccess(
+	spocFile string, cfg *program.Config, logLogin *os.File,
+) ([]string, error) {
+
+	s.spocFile, s.cfg = spocFile, cfg
+	user, pass, err := cfg.GetUserPass(codefiles.GetHostname(spocFile))
+	if err != nil {
+		return nil, err
+	}
+	s.conn, err = console.GetSSHConn(spocFile, user, cfg, logLogin)
+	if err != nil {
+		return nil, err
+	}
+	hostName := codefiles.GetHostname(spocFile)
+	if err := s.loginEnable(pass, cfg); err != nil {
+		return nil, err
+	}
+	if err := s.SetTerminal(s.conn); err != nil {
+		return nil, err
+	}
+	if _, err := s.conn.GetCmdOutput("sh ver"); err != nil {
+		return nil, err
+	}
+	boxName, err := codefiles.GetDeviceName(spocFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckDeviceName(hostName, boxName, s.conn); err != nil {
+		return nil, err
+	}
+	result := []string{"device name: " + hostName}
+	ha, err := s.CheckFailover(s.conn)
+	if err != nil {
+		return nil, err
+	}
+	if ha != "" {
+		result = append(result, "HA state: "+ha)
+	}
+	if cfg.CheckBanner != nil {
+		banner := "found"
+		if s.errUnmanaged != nil {
+			banner = "missing"
+		}
+		result = append(result, "banner: "+banner)
+	}
+	return result, nil
+}
+
+func (s *state) loginEnable(pass string, cfg *program.Config) error {
 	var bannerLines string
 	conn := s.conn
-	out := conn.WaitLogin(`(?i)password:|\(yes/no.*\)\?`)
+	out, err := conn.WaitLogin(`(?i)password:|\(yes/no.*\)\?`)
+	if err != nil {
+		return err
+	}
 	if strings.HasSuffix(out, "?") {
-		out = conn.IssueCmd("yes", `(?i)password:`)
+		if out, err = conn.IssueCmd("yes", `(?i)password:`); err != nil {
+			return err
+		}
 	}
 	bannerLines += out
 	// Look for prompt. Ignore prompt lines with whitespace or multiple
 	// hash that may occur in lines of banner.
-	waitPrompt := func(enter, suffix string) bool {
+	waitPrompt := func(enter, suffix string) (bool, error) {
 		stdPrompt := `\n\r?[^#> ]+[>#] ?$`
-		out = conn.IssueCmd(enter, `(?i)password:|`+stdPrompt)
+		out, err = conn.IssueCmd(enter, `(?i)password:|`+stdPrompt)
 		bannerLines += out
 		out = strings.TrimSuffix(out, " ")
-		return strings.HasSuffix(out, suffix)
+		return strings.HasSuffix(out, suffix), err
 	}
-	if waitPrompt(pass, ">") {
+	authFailed := func(msg string) error {
+		return errlog.Classify(errlog.AuthFailed, errors.New(msg))
+	}
+	if ok, err := waitPrompt(pass, ">"); err != nil {
+		return err
+	} else if ok {
 		// Enter enable mode.
-		if !waitPrompt("enable", "#") {
+		if ok, err := waitPrompt("enable", "#"); err != nil {
+			return err
+		} else if !ok {
 			// Enable password required.
 			// Use login password as enable password.
-			if !waitPrompt(pass, "#") {
-				errlog.Abort("Authentication for enable mode failed")
+			if ok, err := waitPrompt(pass, "#"); err != nil {
+				return err
+			} else if !ok {
+				return authFailed("Authentication for enable mode failed")
 			}
 		}
 	} else if !strings.HasSuffix(out, "#") {
-		errlog.Abort("Authentication failed")
+		return authFailed("Authentication failed")
 	}
 
 	// Force new prompt by issuing empty command.
 	// Use this prompt because of performance impact of standard prompt.
-	out = conn.IssueCmd("", `#[ ]?`)
+	if out, err = conn.IssueCmd("", `#[ ]?`); err != nil {
+		return err
+	}
 	i := strings.LastIndex(out, "\n")
 	// Current prompt: "\n\rHOSTNAME# "
 	p := out[i:]
@@ -115,10 +189,7 @@ func (s *state) loginEnable(pass string, cfg *program.Config) {
 		regexp.QuoteMeta(p[:i]) + `\S*` + regexp.QuoteMeta(p[i:]))
 	conn.SetStdPrompt(rx)
 	s.checkBanner(bannerLines, cfg)
-}
-
-func (s *state) logVersion() {
-	s.conn.GetCmdOutput("sh ver")
+	return nil
 }
 
 func (s *state) checkBanner(lines string, cfg *program.Config) {
@@ -132,45 +203,99 @@ func (s *state) GetErrUnmanaged() []error {
 	return s.errUnmanaged
 }
 
-func (s *state) ApplyCommands(logFh *os.File) error {
+func (s *state) ApplyCommands(logFh *os.File, j *journal.Journal) error {
 	s.conn.SetLogFH(logFh)
-	s.PrepareDevice(s.conn)
-	func() {
-		s.ScheduleReload(s.conn)
-		defer s.CancelReload(s.conn)
-		s.conn.SendCmd("configure terminal")
-		defer s.conn.SendCmd("end")
-		for _, chg := range s.changes {
-			s.cmd(chg)
+	if err := s.PrepareDevice(s.conn); err != nil {
+		return err
+	}
+	if err := s.applyChanges(logFh, j); err != nil {
+		return err
+	}
+	return errlog.Reclassify(errlog.CommitFailed, s.WriteMem(s.conn))
+}
+
+// applyChanges sends changes while reload is scheduled.
+// Restore previous configuration if some command fails.
+// If connection to device was lost, reconnect for restore,
+// unless device restores configuration by itself.
+func (s *state) applyChanges(logFh *os.File, j *journal.Journal) (err error) {
+	if err := s.ScheduleReload(s.conn, s.cfg); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if errlog.ClassOf(err) == errlog.Unreachable {
+				if !s.ReconnectOnLoss() {
+					return
+				}
+				if err2 := s.reconnect(logFh); err2 != nil {
+					s.log.Warning("Can't reconnect to device: %v", err2)
+					s.Rollback(nil)
+					return
+				}
+			}
+			s.Rollback(s.conn)
+		}
+		if err2 := s.CancelReload(s.conn); err == nil {
+			err = err2
 		}
 	}()
-	s.WriteMem(s.conn)
-	return nil
+	if err := s.conn.SendCmd("configure terminal"); err != nil {
+		return err
+	}
+	for _, chg := range s.changes {
+		if err = j.Send(chg, func() error { return s.cmd(chg) }); err != nil {
+			break
+		}
+	}
+	if err2 := s.conn.SendCmd("end"); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// reconnect opens new session to device after connection was lost.
+func (s *state) reconnect(logFh *os.File) error {
+	s.log.Info("Reconnecting to device")
+	s.conn.Close()
+	_, err := s.CheckAccess(s.spocFile, s.cfg, logFh)
+	return err
 }
 
-// Send 1 or 2 commands in one data packet to device.
+// Send one or more commands in one data packet to device.
+// Multiple commands are separated by "\n".
 // No output expected from commands.
-func (s *state) cmd(cmd string) {
-	c1, c2, _ := strings.Cut(cmd, "\n")
+func (s *state) cmd(cmd string) error {
 	s.conn.Send(cmd)
 	needReload := false
-	check := func(ci string) {
-		out := s.conn.GetOutput()
-		out, needReload = s.StripReloadBanner(out, s.conn)
-		out = s.conn.StripEcho(ci, out)
-		if out != "" {
-			if !s.IsValidOutput(ci, out) {
-				errlog.Abort("Got unexpected output from '%s':\n%s", ci, out)
-			}
+	check := func(ci string) error {
+		out, err := s.conn.GetOutput()
+		if err != nil {
+			return err
+		}
+		out, reload, err := s.StripReloadBanner(out, s.conn)
+		if err != nil {
+			return err
 		}
+		needReload = needReload || reload
+		out, err = s.conn.StripEcho(ci, out)
+		if err != nil {
+			return err
+		}
+		if out != "" && !s.IsValidOutput(ci, out) {
+			return fmt.Errorf("Got unexpected output from '%s':\n%s", ci, out)
+		}
+		return nil
 	}
-	check(c1)
-	if c2 != "" {
-		check(c2)
+	for _, ci := range strings.Split(cmd, "\n") {
+		if err := check(ci); err != nil {
+			return err
+		}
 	}
 	if needReload {
-		s.ExtendReload(s.conn)
+		return s.ExtendReload(s.conn)
 	}
+	return nil
 }
 
 func (s *state) CloseConnection() {
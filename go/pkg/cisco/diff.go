@@ -12,8 +12,9 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/ios"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/nxos"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 	"github.com/pkg/diff/edit"
 	"github.com/pkg/diff/myers"
 )
@@ -22,13 +23,142 @@ func (s *state) addChange(chg string) {
 	s.changes = append(s.changes, chg)
 }
 
+// setDiffErr records first error found while comparing
+// configurations. Comparison continues, but changes are discarded.
+func (s *state) setDiffErr(format string, args ...any) {
+	if s.diffErr == nil {
+		s.diffErr = fmt.Errorf(format, args...)
+	}
+}
+
 func (s *state) GetChanges() error {
 	s.alignVRFs()
 	if err := s.checkInterfaces(); err != nil {
 		return err
 	}
 	s.ignoreCryptoGDOI()
+	s.ignoreUnmanagedZBF()
 	s.diffConfig()
+	if err := s.diffErr; err != nil {
+		s.changes = nil
+		s.changeGroup = nil
+		return err
+	}
+	return nil
+}
+
+// Protect marks commands on device as needed, that match entries of
+// protect file. These commands and commands referenced by them are
+// neither changed nor deleted. Netspoc gets a fresh copy instead.
+// Protected routes are removed from comparison.
+func (s *state) Protect(l program.ProtectList) error {
+	for prefix, m := range s.deviceCfg.lookup {
+		switch prefix {
+		case "route", "ip route", "ipv6 route":
+			for _, c := range s.spocCfg.lookup[prefix][""] {
+				if l.MatchRoute(dstOfRoute(c).dst) {
+					return fmt.Errorf("Must not change protected route: %s",
+						c.orig)
+				}
+			}
+			m[""] = slices.DeleteFunc(m[""], func(c *cmd) bool {
+				return l.MatchRoute(dstOfRoute(c).dst)
+			})
+		case "vrf context":
+			for _, c := range s.spocCfg.lookup[prefix][""] {
+				for _, sc := range c.sub {
+					if isRoute(sc) && l.MatchRoute(dstOfRoute(sc).dst) {
+						return fmt.Errorf("Must not change protected route: %s",
+							sc.orig)
+					}
+				}
+			}
+			for _, c := range m[""] {
+				c.sub = slices.DeleteFunc(c.sub, func(sc *cmd) bool {
+					return isRoute(sc) && l.MatchRoute(dstOfRoute(sc).dst)
+				})
+			}
+		default:
+			kind := protectKind[prefix]
+			if kind == "" {
+				continue
+			}
+			for name, cl := range m {
+				if l.Match(kind, name) {
+					s.markNeeded(cl)
+				}
+			}
+		}
+	}
+	return s.protectBindings(l)
+}
+
+// protectKind gives kind of protect entry for prefix of command.
+// Other commands can't be protected.
+var protectKind = map[string]string{
+	"access-list":             "acl",
+	"ip access-list extended": "acl",
+	"ip access-list":          "acl",
+	"ipv6 access-list":        "acl",
+	"object network":          "object",
+	"object service":          "object",
+	"object-group network":    "object",
+	"object-group service":    "object",
+	"object-group protocol":   "object",
+	"object-group ip address": "object",
+	"object-group ip port":    "object",
+}
+
+// protectBindings leaves access-group of protected ACL unchanged on
+// device. It is an error, if Netspoc binds other ACL at same place.
+func (s *state) protectBindings(l program.ProtectList) error {
+	isProtected := func(c *cmd) bool {
+		for i, name := range c.ref {
+			if protectKind[c.typ.ref[i]] == "acl" && l.Match("acl", name) {
+				return true
+			}
+		}
+		return false
+	}
+	// ASA: access-group $REF in interface NAME
+	// IOS, NX-OS: interface with subcommand ip access-group $REF in
+	keep := func(al, bl []*cmd) ([]*cmd, error) {
+		j := 0
+		for _, a := range al {
+			if isProtected(a) {
+				for _, b := range bl {
+					if b.parsed == a.parsed {
+						return nil, fmt.Errorf(
+							"Must not change binding of protected ACL: %s", a.orig)
+					}
+				}
+				continue
+			}
+			al[j] = a
+			j++
+		}
+		return al[:j], nil
+	}
+	if m := s.deviceCfg.lookup["access-group"]; m != nil {
+		l, err := keep(m[""], s.spocCfg.lookup["access-group"][""])
+		if err != nil {
+			return err
+		}
+		m[""] = l
+	}
+	for _, a := range s.deviceCfg.lookup["interface"][""] {
+		var bSub []*cmd
+		for _, b := range s.spocCfg.lookup["interface"][""] {
+			if b.parsed == a.parsed {
+				bSub = b.sub
+			}
+		}
+		l, err := keep(a.sub, bSub)
+		if err != nil {
+			return err
+		}
+		a.sub = l
+	}
 	return nil
 }
 
@@ -37,14 +167,48 @@ func (s *state) HasChanges() bool {
 }
 
 func (s *state) ShowChanges() string {
+	return showChanges(s.changes)
+}
+
+func showChanges(l []string) string {
 	var collect strings.Builder
-	for _, chg := range s.changes {
-		chg = strings.Replace(chg, "\n", "\\N ", 1)
+	for _, chg := range l {
+		chg = strings.ReplaceAll(chg, "\n", "\\N ")
 		fmt.Fprintln(&collect, chg)
 	}
 	return collect.String()
 }
 
+func (s *state) ChangeGroups() []program.ChangeGroup {
+	var result []program.ChangeGroup
+	for _, name := range []string{"acl", "crypto", "routes"} {
+		var l []string
+		for i, chg := range s.changes {
+			if s.changeGroup[i] == name {
+				l = append(l, chg)
+			}
+		}
+		if l != nil {
+			result = append(result,
+				program.ChangeGroup{
+					Name: name, Category: name, Changes: showChanges(l)})
+		}
+	}
+	return result
+}
+
+func (s *state) DeselectChanges(name string) {
+	var changes, groups []string
+	for i, chg := range s.changes {
+		if g := s.changeGroup[i]; g != name {
+			changes = append(changes, chg)
+			groups = append(groups, g)
+		}
+	}
+	s.changes = changes
+	s.changeGroup = groups
+}
+
 func (s *state) diffConfig() {
 	s.addDefaults(s.deviceCfg)
 	s.addDefaults(s.spocCfg)
@@ -53,14 +217,25 @@ func (s *state) diffConfig() {
 	sortRoutes(s.deviceCfg)
 	sortRoutes(s.spocCfg)
 	s.generateNamesForTransfer()
+	s.setChangeGroup("")
 	comb := make(objLookup)
 	maps.Copy(comb, s.deviceCfg.lookup)
 	maps.Copy(comb, s.spocCfg.lookup)
-	for _, prefix := range slices.Sorted(maps.Keys(comb)) {
+	// Process interfaces first, because tunnel interface must be
+	// created, before it is referenced by its name.
+	prefixes := slices.Sorted(maps.Keys(comb))
+	if i := slices.Index(prefixes, "interface"); i > 0 {
+		prefixes = slices.Insert(slices.Delete(prefixes, i, i+1), 0, "interface")
+	}
+	for _, prefix := range prefixes {
 		if prefix == "tunnel-group-map" {
 			s.diffTunnelGroupMap()
 		} else if prefix == "webvpn" {
 			s.diffWebVPN()
+		} else if prefix == "nat" {
+			s.diffNAT()
+		} else if prefix == "object network" {
+			s.diffObjectNAT()
 		} else {
 			var anchor bool
 			for _, l := range comb[prefix] {
@@ -73,8 +248,41 @@ func (s *state) diffConfig() {
 				s.diffSomeAnchors(prefix)
 			}
 		}
+		s.setChangeGroup(prefix)
 	}
 	s.deleteUnused()
+	s.setChangeGroup("")
+}
+
+// setChangeGroup assigns new elements of s.changes to category of
+// commands with given prefix.
+func (s *state) setChangeGroup(prefix string) {
+	s.setChangeGroupName(changeGroupOf(prefix))
+}
+
+// changeGroupOf returns category of commands with given prefix.
+// Routes and crypto commands are changed independently of other
+// commands. ACLs referenced by crypto commands are changed while
+// crypto commands are processed and hence are part of group
+// "crypto". All other commands form a single group, because ACLs,
+// object-groups and interfaces reference each other.
+func changeGroupOf(prefix string) string {
+	switch {
+	case prefix == "route" || prefix == "ip route" || prefix == "ipv6 route" ||
+		prefix == "vrf context":
+		return "routes"
+	case strings.HasPrefix(prefix, "crypto") ||
+		strings.HasPrefix(prefix, "tunnel-group"):
+		return "crypto"
+	}
+	return "acl"
+}
+
+func (s *state) setChangeGroupName(name string) {
+	s.changeGroup = s.changeGroup[:min(len(s.changeGroup), len(s.changes))]
+	for len(s.changeGroup) < len(s.changes) {
+		s.changeGroup = append(s.changeGroup, name)
+	}
 }
 
 func byParsedCmd(_ *config, c *cmd) string {
@@ -162,6 +370,193 @@ func (s *state) diffWebVPN() {
 	}
 }
 
+// spocHasNAT tells if Netspoc manages NAT of device. Otherwise NAT
+// on device has been configured manually and is left unchanged.
+func (s *state) spocHasNAT() bool {
+	if len(s.spocCfg.lookup["nat"][""]) != 0 {
+		return true
+	}
+	for _, l := range s.spocCfg.lookup["object network"] {
+		if l[0].anchor {
+			return true
+		}
+	}
+	return false
+}
+
+// diffObjectNAT compares objects having NAT as subcommand.
+// Other objects are only compared, if referenced by some other command.
+// If NAT of some object on device is unknown to Netspoc, only the NAT
+// subcommand is removed, because the object may be referenced by
+// other commands on device. This is only done, if Netspoc manages NAT
+// of device.
+func (s *state) diffObjectNAT() {
+	prefix := "object network"
+	aMap := s.deviceCfg.lookup[prefix]
+	bMap := s.spocCfg.lookup[prefix]
+	names := make(map[string]bool)
+	for _, m := range []map[string][]*cmd{aMap, bMap} {
+		for name, l := range m {
+			if l[0].anchor {
+				names[name] = true
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		al := aMap[name]
+		bl := bMap[name]
+		if bl != nil && bl[0].anchor {
+			s.diffCmds(al, bl, byParsedCmd)
+			continue
+		}
+		a := al[0]
+		if a.needed || !s.spocHasNAT() {
+			continue
+		}
+		var natL []*cmd
+		for _, sc := range a.sub {
+			if strings.HasPrefix(sc.parsed, "nat ") {
+				natL = append(natL, sc)
+			}
+		}
+		s.delCmds(natL)
+	}
+}
+
+// Manual NAT commands are equal, if they reference objects with
+// equal definition and object-groups that will be equalized later.
+func byNATKey(cf *config, c *cmd) string {
+	key := c.parsed
+	for i, name := range c.ref {
+		prefix := c.typ.ref[i]
+		key += " " + prefix
+		if prefix == "object network" {
+			var l []string
+			for _, sc := range cf.lookup[prefix][name][0].sub {
+				l = append(l, sc.parsed)
+			}
+			sort.Strings(l)
+			key += ":" + strings.Join(l, ":")
+		}
+	}
+	return key
+}
+
+// diffNAT compares manual NAT commands. These are ordered and divided
+// into two sections. Commands with "after-auto" are applied after
+// object NAT. Line numbers are counted separately in each section.
+// If Netspoc doesn't manage NAT of device, only those commands are
+// removed, that reference some object generated by Netspoc.
+func (s *state) diffNAT() {
+	split := func(l []*cmd) (before, after []*cmd) {
+		for _, c := range l {
+			if strings.Contains(c.parsed, " after-auto ") {
+				after = append(after, c)
+			} else {
+				before = append(before, c)
+			}
+		}
+		return
+	}
+	al := s.deviceCfg.lookup["nat"][""]
+	if !s.spocHasNAT() {
+		al = slices.DeleteFunc(slices.Clone(al), func(c *cmd) bool {
+			if slices.ContainsFunc(c.ref, func(name string) bool {
+				return strings.Contains(name, "-DRC-")
+			}) {
+				return false
+			}
+			s.markNeeded([]*cmd{c})
+			return true
+		})
+	}
+	a1, a3 := split(al)
+	b1, b3 := split(s.spocCfg.lookup["nat"][""])
+	s.diffNATSection(a1, b1)
+	s.diffNATSection(a3, b3)
+}
+
+func (s *state) diffNATSection(al, bl []*cmd) {
+	ab := &cmdsPair{
+		a:     s.deviceCfg,
+		b:     s.spocCfg,
+		aCmds: al,
+		bCmds: bl,
+		key:   byNATKey,
+	}
+	diff := myers.Diff(nil, ab).Ranges
+	// Number of lines on device before current position.
+	pos := 0
+	// Number of lines at current position, that will be deleted later.
+	// New lines are added in front of these lines.
+	pending := 0
+	var del []*cmd
+
+	// nat (in,out) [after-auto] source ...
+	// ==>
+	// nat (in,out) [after-auto] line N source ...
+	addNAT := func(b *cmd) {
+		pos++
+		b.parsed = strings.Replace(
+			b.parsed, " source ", " line "+strconv.Itoa(pos)+" source ", 1)
+		s.addCmds([]*cmd{b})
+	}
+	// Referenced objects are equal, if they can be changed on device.
+	equalRefs := func(a, b *cmd) bool {
+		eq := true
+		for i, aName := range a.ref {
+			bName := b.ref[i]
+			if a.typ.ref[i] == "object-group" {
+				if !s.equalizedGroups(aName, bName) {
+					eq = false
+				}
+			} else {
+				prefix := a.typ.ref[i]
+				aRef := s.deviceCfg.lookup[prefix][aName]
+				bRef := s.spocCfg.lookup[prefix][bName]
+				if s.diffCmds(aRef, bRef, byParsedCmd) != aName {
+					eq = false
+				}
+			}
+		}
+		return eq
+	}
+	for _, r := range diff {
+		if r.IsInsert() {
+			for _, b := range bl[r.LowB:r.HighB] {
+				for i, bName := range b.ref {
+					if b.typ.ref[i] == "object-group" {
+						s.findGroupOnDevice(bName)
+					}
+				}
+				addNAT(b)
+			}
+		} else if r.IsDelete() {
+			del = append(del, al[r.LowA:r.HighA]...)
+			pending += r.HighA - r.LowA
+		} else if r.IsEqual() {
+			pos += pending
+			pending = 0
+			for i, a := range al[r.LowA:r.HighA] {
+				b := bl[r.LowB+i]
+				if equalRefs(a, b) {
+					a.needed = true
+					b.ready = true
+				} else {
+					addNAT(b)
+					del = append(del, a)
+				}
+				pos++
+			}
+		}
+	}
+	// Delete lines on device from bottom to top.
+	slices.Reverse(del)
+	for _, a := range del {
+		s.delCmds([]*cmd{a})
+	}
+}
+
 type keyFunc func(*config, *cmd) string
 
 type cmdsPair struct {
@@ -216,19 +611,15 @@ func (s *state) diffCmds(al, bl []*cmd, key keyFunc) string {
 			isEq = false
 		}
 	}
-	if len(al) > 0 {
-		if p := al[0].typ.prefix; p == "route" ||
-			p == "ip route" || p == "ipv6 route" {
-
-			s.diffRoutes(al, bl, diff)
-			return ""
-		}
+	if len(al) > 0 && isRoute(al[0]) {
+		s.diffRoutes(al, bl, diff)
+		return ""
 	}
 
 	// Do not incrementally alter empty IOS ACL to prevent connectivity issues.
 	if isEq {
 		for i, c := range al {
-			if strings.HasPrefix(c.parsed, "ip access-list extended $NAME") {
+			if isIOSACL(c.typ.prefix) {
 				if len(c.sub) == 0 && len(bl[i].sub) > 0 {
 					hasEq = false
 					break
@@ -286,8 +677,7 @@ func (s *state) diffCmds(al, bl []*cmd, key keyFunc) string {
 		s.diffASAACLs(al, bl, diff)
 		return al[0].name
 	}
-	if c := al[0].subCmdOf; c != nil &&
-		c.typ.prefix == "ip access-list extended" {
+	if c := al[0].subCmdOf; c != nil && isIOSACL(c.typ.prefix) {
 
 		s.diffIOSACLs(al, bl, diff)
 		return al[0].name
@@ -318,7 +708,11 @@ func (s *state) makeEqual(al, bl []*cmd) {
 		b.name = a.name
 		b.seq = a.seq
 		b.ready = true
-		s.diffCmds(a.sub, b.sub, byParsedCmd)
+		key := byParsedCmd
+		if a.typ.prefix == "policy-map type inspect" {
+			key = byInspectClass
+		}
+		s.diffCmds(a.sub, b.sub, key)
 		changedRef := false
 		for i, aName := range a.ref {
 			prefix := a.typ.ref[i]
@@ -326,7 +720,10 @@ func (s *state) makeEqual(al, bl []*cmd) {
 			aRef := s.deviceCfg.lookup[prefix][aName]
 			bRef := s.spocCfg.lookup[prefix][bName]
 			var refName string
-			if prefix == "aaa-server" && aName != bName {
+			// Manually configured object is referenced by name.
+			if isManualObj(prefix) && prefix != "ldap attribute-map" &&
+				aName != bName {
+
 				refName = bName
 				s.addCmds(bRef)
 			} else if prefix == "crypto map" {
@@ -342,18 +739,42 @@ func (s *state) makeEqual(al, bl []*cmd) {
 			if strings.Contains(b.parsed, "$NAME $SEQ set ikev") {
 				s.addChange("no " + a.orig)
 			}
+			// Policy-map may have multiple classes.
+			// Remove class that references old class-map.
+			if isInspectClass(a) {
+				s.setCmdConfMode(s.printNetspocCmd(b.subCmdOf))
+				s.addChange("no " + a.orig)
+			}
+			s.addCmd(b)
+		} else if a.parsed != b.parsed {
+			// Change action of class in inspect policy-map.
 			s.addCmd(b)
 		}
 	}
 }
 
+// isInspectClass returns true for "class type inspect ..." with
+// joined action in inspect policy-map.
+func isInspectClass(c *cmd) bool {
+	sup := c.subCmdOf
+	return sup != nil && sup.typ.prefix == "policy-map type inspect" &&
+		strings.HasPrefix(c.parsed, "class type inspect ")
+}
+
+// Classes of inspect policy-map are equal, if they reference equal
+// class-map. Changed action is applied to existing class.
+func byInspectClass(_ *config, c *cmd) string {
+	cl, _, _ := strings.Cut(c.parsed, "\n")
+	return cl
+}
+
 func (s *state) equalizeSimpleObject(al, bl []*cmd) string {
-	if !simpleObjEqual(al, bl) {
+	if !simpleObjEqual(s.deviceCfg, s.spocCfg, al, bl) {
 		s.markDeleted(al)
 		al = s.findSimpleObjOnDevice(bl)
 	}
 	if al != nil {
-		al[0].needed = true
+		s.markNeeded(al)
 		bl[0].ready = true
 		bl[0].name = al[0].name
 		return al[0].name
@@ -363,38 +784,87 @@ func (s *state) equalizeSimpleObject(al, bl []*cmd) string {
 }
 
 func (s *state) findSimpleObjOnDevice(bl []*cmd) []*cmd {
-	return findSimpleObject(bl, s.deviceCfg)
+	return findSimpleObject(bl, s.deviceCfg, s.spocCfg)
 }
 
-func findSimpleObject(bl []*cmd, a *config) []*cmd {
+func findSimpleObject(bl []*cmd, a, b *config) []*cmd {
 	prefix := bl[0].typ.prefix
 	m := a.lookup[prefix]
 	for _, name := range slices.Sorted(maps.Keys(m)) {
 		al := m[name]
-		if simpleObjEqual(al, bl) {
+		if simpleObjEqual(a, b, al, bl) {
 			return al
 		}
 	}
 	return nil
 }
 
-func simpleObjEqual(al, bl []*cmd) bool {
+// Simple objects are equal, if they have equal subcommands and
+// reference equal simple objects or other objects with same name.
+func simpleObjEqual(a, b *config, al, bl []*cmd) bool {
 	// Simple objects are known to have exactly one toplevel command.
 	ac, bc := al[0], bl[0]
-	sortedSub := func(c *cmd) []string {
-		l := make([]string, len(c.sub))
-		for i, s := range c.sub {
-			l[i] = s.parsed
-		}
-		sort.Strings(l)
+	sortedSub := func(c *cmd) []*cmd {
+		l := slices.Clone(c.sub)
+		slices.SortStableFunc(l, func(x, y *cmd) int {
+			return cmp.Compare(x.parsed, y.parsed)
+		})
 		return l
 	}
-	return ac.parsed == bc.parsed && slices.Equal(sortedSub(ac), sortedSub(bc))
+	equal := func(ac, bc *cmd) bool {
+		if ac.parsed != bc.parsed {
+			return false
+		}
+		for i, aName := range ac.ref {
+			prefix := ac.typ.ref[i]
+			bName := bc.ref[i]
+			aRef := a.lookup[prefix][aName]
+			bRef := b.lookup[prefix][bName]
+			if bRef[0].typ.simpleObj {
+				if !simpleObjEqual(a, b, aRef, bRef) {
+					return false
+				}
+			} else if aName != bName {
+				return false
+			}
+		}
+		return true
+	}
+	if !equal(ac, bc) || len(ac.sub) != len(bc.sub) {
+		return false
+	}
+	bSub := sortedSub(bc)
+	for i, as := range sortedSub(ac) {
+		if !equal(as, bSub[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 func (s *state) diffIOSACLs(al, bl []*cmd, diff []edit.Range) {
-	aclName := al[0].subCmdOf.name
-	s.addToplevel("ip access-list resequence " + aclName + " 10000 10000")
+	acl := al[0].subCmdOf
+	resequence := func(start string) {
+		switch acl.typ.prefix {
+		case "ip access-list":
+			// NX-OS
+			s.addToplevel(
+				"resequence ip access-list " + acl.name + " " + start + " " + start)
+		case "ipv6 access-list":
+			s.addToplevel(
+				"ipv6 access-list resequence " + acl.name + " " + start + " " + start)
+		default:
+			s.addToplevel(
+				"ip access-list resequence " + acl.name + " " + start + " " + start)
+		}
+	}
+	// IOS IPv6 ACL uses "sequence N" instead of plain line number.
+	seqPrefix := ""
+	if acl.typ.prefix == "ipv6 access-list" {
+		seqPrefix = "sequence "
+	}
+	diff = s.splitChangedGroups(al, bl, diff)
+	resequence("10000")
 	chgLen := len(s.changes)
 	idx2Block, maxID := markIOSPermitDenyBlocks(al)
 	type cmdAndPos struct {
@@ -408,12 +878,12 @@ func (s *state) diffIOSACLs(al, bl []*cmd, diff []edit.Range) {
 	stripLogRX := regexp.MustCompile(` log(?:-input)?`)
 	addACL := func(b *cmd, before, i int) {
 		lineNr := before*10000 + i + 1
-		b.parsed = strconv.Itoa(lineNr) + " " + b.parsed
+		b.parsed = seqPrefix + strconv.Itoa(lineNr) + " " + b.parsed
 		s.addCmds([]*cmd{b})
 	}
 	delACL := func(a *cmdAndPos) {
 		lineNr := (a.pos + 1) * 10000
-		a.cmd.orig = strconv.Itoa(lineNr)
+		a.cmd.orig = seqPrefix + strconv.Itoa(lineNr)
 		s.delCmds([]*cmd{a.cmd})
 	}
 	// Generate move command which sends add and delete command together
@@ -509,7 +979,8 @@ func (s *state) diffIOSACLs(al, bl []*cmd, diff []edit.Range) {
 	for _, r := range diff {
 		if r.IsInsert() {
 			if r.HighB-r.LowB >= 10000 {
-				errlog.Abort("Can't insert more than 9999 ACL lines at once")
+				s.setDiffErr("Can't insert more than 9999 ACL lines at once")
+				return
 			}
 			action0 := getIOSAction(bl[r.LowB])
 			moveOK := true
@@ -539,8 +1010,59 @@ func (s *state) diffIOSACLs(al, bl []*cmd, diff []edit.Range) {
 		// No changes found; remove initial resequence command.
 		s.changes = s.changes[:chgLen-1]
 	} else {
-		s.addToplevel("ip access-list resequence " + aclName + " 10 10")
+		resequence("10")
+	}
+}
+
+// splitChangedGroups equalizes object-groups referenced from equal
+// lines of IOS ACL. Equal lines, where referenced object-groups
+// can't be equalized, are changed to be deleted and inserted.
+func (s *state) splitChangedGroups(al, bl []*cmd, diff []edit.Range,
+) []edit.Range {
+	// Check for identical groups early and equalize groups later.
+	for _, r := range diff {
+		if r.IsInsert() {
+			for _, c := range bl[r.LowB:r.HighB] {
+				for _, bName := range c.ref {
+					s.findGroupOnDevice(bName)
+				}
+			}
+		}
+	}
+	var result []edit.Range
+	for _, r := range diff {
+		if !r.IsEqual() {
+			result = append(result, r)
+			continue
+		}
+		lowA, lowB := r.LowA, r.LowB
+		for i := range r.HighA - r.LowA {
+			a, b := al[r.LowA+i], bl[r.LowB+i]
+			changedRef := false
+			for j, aName := range a.ref {
+				if !s.equalizedGroups(aName, b.ref[j]) {
+					changedRef = true
+				}
+			}
+			if !changedRef {
+				continue
+			}
+			posA, posB := r.LowA+i, r.LowB+i
+			if lowA < posA {
+				result = append(result, edit.Range{
+					LowA: lowA, HighA: posA, LowB: lowB, HighB: posB})
+			}
+			result = append(result,
+				edit.Range{LowA: posA, HighA: posA + 1, LowB: posB, HighB: posB},
+				edit.Range{LowA: posA + 1, HighA: posA + 1, LowB: posB, HighB: posB + 1})
+			lowA, lowB = posA+1, posB+1
+		}
+		if lowA < r.HighA {
+			result = append(result, edit.Range{
+				LowA: lowA, HighA: r.HighA, LowB: lowB, HighB: r.HighB})
+		}
 	}
+	return result
 }
 
 // Mark rules belonging to block of successive rules with identical action,
@@ -810,10 +1332,10 @@ func (s *state) diffRoutes(al, bl []*cmd, diff []edit.Range) {
 				if del, found := delDst[dstOfRoute(c)]; found {
 					// ASA doesn't allow two routes to identical
 					// destination. Remove and add routes in one transaction.
-					s.addToplevel("no " + del.orig + "\n" + add)
+					s.addRoute(c, "no "+del.orig+"\n"+add)
 					del.needed = true
 				} else {
-					s.addToplevel(add)
+					s.addRoute(c, add)
 				}
 			}
 		}
@@ -834,7 +1356,7 @@ func (s *state) diffRoutes(al, bl []*cmd, diff []edit.Range) {
 					if vrf != "" {
 						forVRF = " for VRF " + vrf
 					}
-					errlog.Info("No %s routing specified%s, leaving untouched",
+					s.log.Info("No %s routing specified%s, leaving untouched",
 						ipv, forVRF)
 				}
 			}
@@ -842,6 +1364,17 @@ func (s *state) diffRoutes(al, bl []*cmd, diff []edit.Range) {
 	}
 }
 
+// addRoute adds change of route. Route may be subcommand of NX-OS
+// "vrf context".
+func (s *state) addRoute(c *cmd, chg string) {
+	if sup := c.subCmdOf; sup != nil {
+		s.setCmdConfMode(s.printNetspocCmd(sup))
+		s.addChange(chg)
+	} else {
+		s.addToplevel(chg)
+	}
+}
+
 // Recursively transfer commands referenced from command and subcommands.
 // Then transfer command and its subcommands.
 // Mark transferred commands.
@@ -873,7 +1406,7 @@ func (s *state) addCmds(l []*cmd) {
 		b0.ready = true
 		if b0.typ.simpleObj {
 			if al := s.findSimpleObjOnDevice(bl); al != nil {
-				al[0].needed = true
+				s.markNeeded(al)
 				b0.name = al[0].name
 				return
 			}
@@ -882,9 +1415,10 @@ func (s *state) addCmds(l []*cmd) {
 			prefix := b0.typ.prefix
 			name := b0.name
 			if _, found := s.deviceCfg.lookup[prefix][name]; !found {
-				switch prefix {
-				case "aaa-server", "ldap attribute-map":
-					errlog.Abort("'%s %s' must be transferred manually", prefix, name)
+				if isManualObj(prefix) {
+					s.setDiffErr("'%s %s' must be transferred manually",
+						prefix, name)
+					return
 				}
 			}
 		}
@@ -900,10 +1434,17 @@ func (s *state) addCmds(l []*cmd) {
 }
 
 func (s *state) addCmd(c *cmd) {
-	switch c.typ.prefix {
-	case "aaa-server", "ldap attribute-map", "interface":
+	if isManualObj(c.typ.prefix) {
 		return
 	}
+	switch c.typ.prefix {
+	case "interface":
+		// Only tunnel interface is created.
+		// Other interfaces must already exist on device.
+		if !isTunnelIntf(c) {
+			return
+		}
+	}
 	pr := s.printNetspocCmd(c)
 	// If current command is subcommand of some command x
 	// then write x and remember that x has been written,
@@ -922,6 +1463,17 @@ func (s *state) addCmd(c *cmd) {
 	}
 }
 
+// isManualObj returns true for prefix of commands, that are
+// configured manually on device. These are never changed by approve.
+func isManualObj(prefix string) bool {
+	switch prefix {
+	case "aaa-server", "ldap attribute-map",
+		"crypto isakmp profile", "crypto ikev2 profile":
+		return true
+	}
+	return false
+}
+
 func (s *state) addToplevel(c string) {
 	s.addChange(c)
 	s.subCmdOf = ""
@@ -946,8 +1498,19 @@ func (s *state) delCmds(l []*cmd) {
 		return
 	}
 	switch l[0].typ.prefix {
-	// Leave these commands unchanged on device:
 	case "interface":
+		// Tunnel interface previously created by Netspoc is deleted
+		// later together with other unused commands,
+		// after routes referencing this interface have been removed.
+		// Leave other interfaces unchanged on device.
+		for _, c := range l {
+			if isDRCTunnel(c) {
+				c.toDelete = true
+			}
+		}
+		return
+	case "vrf context":
+		// Leave unchanged on device.
 		return
 	}
 	for _, c := range l {
@@ -976,9 +1539,10 @@ func (s *state) markDeleted(al []*cmd) {
 		}
 	}
 	del = func(al []*cmd) {
-		switch al[0].typ.prefix {
 		// Leave these commands unchanged on device:
-		case "aaa-server", "ldap attribute-map", "interface":
+		switch prefix := al[0].typ.prefix; {
+		case isManualObj(prefix), prefix == "interface",
+			prefix == "vrf context":
 			return
 		}
 		for _, c := range al {
@@ -1052,6 +1616,33 @@ func (s *state) deleteUnused() {
 	if len(toDelete) > 0 && s.subCmdOf != "" {
 		s.addChange("exit")
 	}
+	// Commands referenced by crypto commands on device are deleted
+	// in same group as crypto commands.
+	cryptoRef := make(map[pair]bool)
+	var followCrypto func(c *cmd)
+	followCrypto = func(c *cmd) {
+		for i, name := range c.ref {
+			p := pair{c.typ.ref[i], name}
+			if !cryptoRef[p] {
+				cryptoRef[p] = true
+				for _, c2 := range s.deviceCfg.lookup[p[0]][p[1]] {
+					followCrypto(c2)
+				}
+			}
+		}
+		for _, sc := range c.sub {
+			followCrypto(sc)
+		}
+	}
+	for prefix, m := range s.deviceCfg.lookup {
+		if changeGroupOf(prefix) == "crypto" {
+			for _, l := range m {
+				for _, c := range l {
+					followCrypto(c)
+				}
+			}
+		}
+	}
 	for len(toDelete) > 0 {
 		// Mark commands that are still referenced by other to be
 		// deleted commands. Delete them afterwards.
@@ -1093,6 +1684,11 @@ func (s *state) deleteUnused() {
 					}
 				}
 			}
+			if cryptoRef[pair] {
+				s.setChangeGroupName("crypto")
+			} else {
+				s.setChangeGroup(prefix)
+			}
 		}
 	}
 }
@@ -1179,13 +1775,17 @@ GROUP:
 }
 
 func (s *state) diffCryptoMap(al, bl []*cmd) string {
-	matchCryptoMap(al, bl, func(aSeqL, bSeqL []*cmd) {
+	err := matchCryptoMap(al, bl, func(aSeqL, bSeqL []*cmd) error {
 		s.diffCmds(aSeqL, bSeqL, byParsedCmd)
+		return nil
 	})
+	if err != nil {
+		s.setDiffErr("%v", err)
+	}
 	return al[0].name
 }
 
-func matchCryptoMap(al, bl []*cmd, f func([]*cmd, []*cmd)) {
+func matchCryptoMap(al, bl []*cmd, f func([]*cmd, []*cmd) error) error {
 	mapBySeq := func(l []*cmd) map[int][]*cmd {
 		m := make(map[int][]*cmd)
 		for _, c := range l {
@@ -1193,7 +1793,7 @@ func matchCryptoMap(al, bl []*cmd, f func([]*cmd, []*cmd)) {
 		}
 		return m
 	}
-	getPeer := func(l []*cmd) string {
+	getPeer := func(l []*cmd) (string, error) {
 		name, seq := l[0].name, l[0].seq
 		// For IOS look into subcommands.
 		if len(l) == 1 && l[0].sub != nil {
@@ -1211,30 +1811,43 @@ func matchCryptoMap(al, bl []*cmd, f func([]*cmd, []*cmd)) {
 			}
 		}
 		if peer == "" {
-			errlog.Abort("Missing peer or dynamic in crypto map %s %d", name, seq)
+			return "", fmt.Errorf("Missing peer or dynamic in crypto map %s %d",
+				name, seq)
 		}
-		return peer
+		return peer, nil
 	}
-	mapPeerToSeq := func(seqMap map[int][]*cmd) map[string]int {
+	mapPeerToSeq := func(seqMap map[int][]*cmd) (map[string]int, error) {
 		m := make(map[string]int)
 		for seq, l := range seqMap {
-			m[getPeer(l)] = seq
+			peer, err := getPeer(l)
+			if err != nil {
+				return nil, err
+			}
+			m[peer] = seq
 		}
-		return m
+		return m, nil
 	}
 
 	aSeqMap := mapBySeq(al)
 	bSeqMap := mapBySeq(bl)
-	bPeer2Seq := mapPeerToSeq(bSeqMap)
+	bPeer2Seq, err := mapPeerToSeq(bSeqMap)
+	if err != nil {
+		return err
+	}
 	// Match commands having same peer.
 	for _, aSeq := range slices.Sorted(maps.Keys(aSeqMap)) {
 		aSeqL := aSeqMap[aSeq]
-		aPeer := getPeer(aSeqL)
+		aPeer, err := getPeer(aSeqL)
+		if err != nil {
+			return err
+		}
+		var bSeqL []*cmd
 		if bSeq, found := bPeer2Seq[aPeer]; found {
-			f(aSeqL, bSeqMap[bSeq])
+			bSeqL = bSeqMap[bSeq]
 			delete(bSeqMap, bSeq) // Mark as already processed.
-		} else {
-			f(aSeqL, nil)
+		}
+		if err := f(aSeqL, bSeqL); err != nil {
+			return err
 		}
 	}
 	// Use fresh sequence numbers for added commands.
@@ -1244,7 +1857,8 @@ func matchCryptoMap(al, bl []*cmd, f func([]*cmd, []*cmd)) {
 		bSeqL := bSeqMap[bSeq]
 		seq := &dynamic
 		incr := -1
-		if strings.HasPrefix(getPeer(bSeqL), "peer ") {
+		bPeer, _ := getPeer(bSeqL) // Already checked above.
+		if strings.HasPrefix(bPeer, "peer ") {
 			seq = &static
 			incr = 1
 		}
@@ -1258,21 +1872,44 @@ func matchCryptoMap(al, bl []*cmd, f func([]*cmd, []*cmd)) {
 				bCmd.name = al[0].name
 			}
 		}
-		f(nil, bSeqL)
+		if err := f(nil, bSeqL); err != nil {
+			return err
+		}
 		*seq += incr
 	}
+	return nil
 }
 
 // Add routes with long mask first. If we switch the default
 // route, this ensures, that we have the new routes available
 // before deleting the old default route.
 func sortRoutes(cf *config) {
-	for _, prefix := range []string{"route", "ip route", "ipv6 route"} {
-		l := cf.lookup[prefix][""]
+	sortL := func(l []*cmd) {
 		sort.Slice(l, func(i, j int) bool {
 			return byMoreSpecificRoute(l[i]) < byMoreSpecificRoute(l[j])
 		})
 	}
+	for _, prefix := range []string{"route", "ip route", "ipv6 route"} {
+		sortL(cf.lookup[prefix][""])
+	}
+	for _, c := range cf.lookup["vrf context"][""] {
+		sortL(c.sub)
+	}
+}
+
+// isRoute tells if command is a static route. Routes of NX-OS are
+// also given as subcommand of "vrf context".
+func isRoute(c *cmd) bool {
+	if sup := c.subCmdOf; sup != nil {
+		return sup.typ.prefix == "vrf context" &&
+			(strings.HasPrefix(c.parsed, "ip route ") ||
+				strings.HasPrefix(c.parsed, "ipv6 route "))
+	}
+	switch c.typ.prefix {
+	case "route", "ip route", "ipv6 route":
+		return true
+	}
+	return false
 }
 
 func byMoreSpecificRoute(c *cmd) string {
@@ -1285,6 +1922,14 @@ func dstOfRoute(c *cmd) routeDst {
 	vrf := ""
 	var ipp netip.Prefix
 	l := strings.Split(c.parsed, " ")
+	// NX-OS: vrf context NAME
+	//         ip route ip/len gw
+	//         ipv6 route ip/len gw
+	if sup := c.subCmdOf; sup != nil {
+		vrf = strings.Fields(sup.parsed)[2]
+		ipp, _ = netip.ParsePrefix(l[2])
+		return routeDst{vrf, ipp}
+	}
 	if c.typ.prefix == "ipv6 route" {
 		// ASA: ipv6 route intf ip/len gw
 		// IOS: ipv6 route [vrf NAME] ip/len gw
@@ -1296,11 +1941,16 @@ func dstOfRoute(c *cmd) routeDst {
 	} else {
 		// ASA: route intf ip mask gw
 		// IOS: ip route [vrf NAME] ip mask gw
+		// NX-OS: ip route ip/len gw
 		i := 2
 		if l[0] == "ip" && l[2] == "vrf" {
 			vrf = l[3]
 			i = 4
 		}
+		if strings.Contains(l[i], "/") {
+			ipp, _ = netip.ParsePrefix(l[i])
+			return routeDst{vrf, ipp}
+		}
 		ip, err1 := netip.ParseAddr(l[i])
 		mask, err2 := netip.ParseAddr(l[i+1])
 		if err1 == nil && err2 == nil {
@@ -1318,7 +1968,7 @@ func diffCmdLists(ab *cmdsPair) []edit.Range {
 			return myers.Diff(nil, ab).Ranges
 		} else {
 			s := al[0].subCmdOf
-			if s != nil && s.typ.prefix == "ip access-list extended" {
+			if s != nil && isIOSACL(s.typ.prefix) {
 				return myers.Diff(nil, ab).Ranges
 			}
 		}
@@ -1372,13 +2022,61 @@ func diffUnordered(ab *cmdsPair) []edit.Range {
 
 func (s *state) checkInterfaces() error {
 	switch s.realCisco.(type) {
-	case *ios.State:
+	case *ios.State, *nxos.State:
 		return s.checkIOSInterfaces()
 	default:
 		return s.checkASAInterfaces()
 	}
 }
 
+// isTunnelIntf returns true for "interface TunnelN".
+func isTunnelIntf(c *cmd) bool {
+	_, name, _ := strings.Cut(c.parsed, " ")
+	return strings.HasPrefix(name, "Tunnel")
+}
+
+// isSpocTunnel returns true for tunnel interface, that is configured
+// by Netspoc with subcommands "tunnel ...".
+func isSpocTunnel(c *cmd) bool {
+	return isTunnelIntf(c) && slices.ContainsFunc(c.sub, func(sc *cmd) bool {
+		return strings.HasPrefix(sc.parsed, "tunnel ")
+	})
+}
+
+// isDRCTunnel returns true for tunnel interface on device,
+// that references IPsec profile created by Netspoc.
+func isDRCTunnel(c *cmd) bool {
+	if !isTunnelIntf(c) {
+		return false
+	}
+	return slices.ContainsFunc(c.sub, func(sc *cmd) bool {
+		return slices.ContainsFunc(sc.ref, func(name string) bool {
+			return strings.Contains(name, "-DRC-")
+		})
+	})
+}
+
+// stripTunnelCmds removes subcommands with given prefixes from
+// interface on device. These are only compared at tunnel interface
+// configured by Netspoc. Otherwise they would be removed from
+// manually configured tunnel or from other interface.
+func stripTunnelCmds(c *cmd, prefixes ...string) {
+	c.sub = slices.DeleteFunc(c.sub, func(sc *cmd) bool {
+		return slices.ContainsFunc(prefixes, func(p string) bool {
+			return strings.HasPrefix(sc.parsed, p)
+		})
+	})
+}
+
+func getNameif(c *cmd) string {
+	for _, sc := range c.sub {
+		if name, found := strings.CutPrefix(sc.parsed, "nameif "); found {
+			return name
+		}
+	}
+	return ""
+}
+
 func (s *state) checkASAInterfaces() error {
 	// For ASA collect implicit interfaces from Netspoc.
 	// These are defined by commands
@@ -1400,30 +2098,41 @@ func (s *state) checkASAInterfaces() error {
 		return m
 	}
 	bIntf2cmd := getImplicitInterfaces(s.spocCfg)
+	// Tunnel interfaces are defined explicitly in Netspoc.
+	var tunnels []string
+	bTunnel := make(map[string]bool)
+	for _, c := range s.spocCfg.lookup["interface"][""] {
+		bTunnel[c.parsed] = isSpocTunnel(c)
+		if name := getNameif(c); name != "" {
+			if _, found := bIntf2cmd[name]; !found {
+				bIntf2cmd[name] = nil
+			}
+			if isTunnelIntf(c) {
+				tunnels = append(tunnels, name)
+			}
+		}
+	}
 
 	// Collect and check named interfaces from device.
 	// Add implicit interfaces when comparing two Netspoc generated configs.
 	aIntf2cmd := getImplicitInterfaces(s.deviceCfg)
 	for _, c := range s.deviceCfg.lookup["interface"][""] {
-		name := ""
-		shut := false
-		for _, sc := range c.sub {
-			tokens := strings.Fields(sc.parsed)
-			switch tokens[0] {
-			case "shutdown":
-				shut = true
-			case "nameif":
-				name = tokens[1]
-			}
+		if t, found := bTunnel[c.parsed]; found && !t {
+			stripTunnelCmds(c, "ip address ", "tunnel ")
 		}
+		name := getNameif(c)
+		shut := slices.ContainsFunc(c.sub, func(sc *cmd) bool {
+			return sc.parsed == "shutdown"
+		})
 		if name != "" {
-			if _, found := bIntf2cmd[name]; !found {
+			// Tunnel previously created by Netspoc is deleted silently.
+			if _, found := bIntf2cmd[name]; !found && !isDRCTunnel(c) {
 				// If some ACL or crypto map is bound to this unmanaged
 				// interface, these commands must not accidently be deleted.
 				s.markNeeded(aIntf2cmd[name])
 
 				if !shut {
-					errlog.Warning(
+					s.log.Warning(
 						"Interface '%s' on device is not known by Netspoc", name)
 				}
 			}
@@ -1432,6 +2141,12 @@ func (s *state) checkASAInterfaces() error {
 		}
 	}
 
+	// Tunnel interface from Netspoc will be created.
+	for _, name := range tunnels {
+		if _, found := aIntf2cmd[name]; !found {
+			aIntf2cmd[name] = nil
+		}
+	}
 	// Check interfaces from Netspoc
 	for _, name := range slices.Sorted(maps.Keys(bIntf2cmd)) {
 		if _, found := aIntf2cmd[name]; !found {
@@ -1472,6 +2187,8 @@ func (s *state) checkIOSInterfaces() error {
 				addrList = append(addrList, "unnumbered")
 			} else if _, v, ok := strings.Cut(p, "vrf forwarding "); ok {
 				info.vrf = v
+			} else if v, ok := strings.CutPrefix(p, "vrf member "); ok {
+				info.vrf = v
 			} else if strings.HasPrefix(p, "ip inspect") {
 				info.inspect = "enabled"
 			} else {
@@ -1486,18 +2203,29 @@ func (s *state) checkIOSInterfaces() error {
 		return info
 	}
 	aKnown := make(map[string]bool)
+	for _, c := range s.deviceCfg.lookup["interface"][""] {
+		aKnown[strings.Fields(c.parsed)[1]] = true
+	}
 	bIntf := make(map[string]*intfInfo)
-	for _, c := range s.spocCfg.lookup["interface"][""] {
+	bTunnel := make(map[string]bool)
+	bList := s.spocCfg.lookup["interface"][""]
+	for _, c := range bList {
 		name := strings.Fields(c.parsed)[1]
-		bIntf[name] = extractIntfInfo(c)
+		bTunnel[name] = isSpocTunnel(c)
+		// Unknown tunnel interface is created with all subcommands.
+		if aKnown[name] {
+			bIntf[name] = extractIntfInfo(c)
+		}
 	}
 	for _, c := range s.deviceCfg.lookup["interface"][""] {
 		name := strings.Fields(c.parsed)[1]
 		aInfo := extractIntfInfo(c)
-		aKnown[name] = true
+		if t, found := bTunnel[name]; found && !t {
+			stripTunnelCmds(c, "tunnel ")
+		}
 		if bInfo := bIntf[name]; bInfo != nil {
 			if aInfo.addr != bInfo.addr && bInfo.addr != "negotiated" {
-				errlog.Warning(
+				s.log.Warning(
 					"Different address defined for interface %s:"+
 						" Device: %q, Netspoc: %q", name, aInfo.addr, bInfo.addr)
 			}
@@ -1516,15 +2244,17 @@ func (s *state) checkIOSInterfaces() error {
 			// If config from Netspoc has no interface definitions, it is
 			// probably of type "managed=routing_only", and Netspoc won't
 			// change any interface config.
-			if !aInfo.shut && aInfo.addr != "" && len(bIntf) != 0 {
-				errlog.Warning(
+			// Tunnel previously created by Netspoc is deleted silently.
+			if !aInfo.shut && aInfo.addr != "" && len(bList) != 0 &&
+				!isDRCTunnel(c) {
+				s.log.Warning(
 					"Interface '%s' on device is not known by Netspoc", name)
 			}
 		}
 	}
-	for _, c := range s.spocCfg.lookup["interface"][""] {
+	for _, c := range bList {
 		name := strings.Fields(c.parsed)[1]
-		if !aKnown[name] {
+		if !aKnown[name] && !isTunnelIntf(c) {
 			return fmt.Errorf(
 				"Interface '%s' from Netspoc not known on device", name)
 		}
@@ -1538,6 +2268,9 @@ func (s *state) alignVRFs() {
 			if _, v, found := strings.Cut(s.orig, "vrf forwarding "); found {
 				return v
 			}
+			if v, found := strings.CutPrefix(s.orig, "vrf member "); found {
+				return v
+			}
 		}
 		return ""
 	}
@@ -1548,6 +2281,10 @@ func (s *state) alignVRFs() {
 		}
 		return ""
 	}
+	// NX-OS: vrf context NAME
+	contextVRF := func(c *cmd) string {
+		return strings.Fields(c.parsed)[2]
+	}
 	pairs := []struct {
 		name string
 		get  func(c *cmd) string
@@ -1555,6 +2292,7 @@ func (s *state) alignVRFs() {
 		{"interface", interfaceVRF},
 		{"ip route", routeVRF},
 		{"ipv6 route", routeVRF},
+		{"vrf context", contextVRF},
 	}
 	// Find VRFs used in Netspoc configuration.
 	bVRF := make(map[string]bool)
@@ -1598,7 +2336,7 @@ func (s *state) alignVRFs() {
 		if vrf == "" {
 			vrf = "<global>"
 		}
-		errlog.Info("Leaving VRF %s untouched", vrf)
+		s.log.Info("Leaving VRF %s untouched", vrf)
 	}
 }
 
@@ -1614,6 +2352,43 @@ func (s *state) markNeeded(l []*cmd) {
 	}
 }
 
+// spocHasZBF tells if Netspoc manages zone-based firewall of device.
+// Otherwise zones on device have been configured manually.
+func (s *state) spocHasZBF() bool {
+	l := s.spocCfg.lookup
+	return len(l["zone security"]) != 0 || len(l["zone-pair security"]) != 0
+}
+
+// If Netspoc doesn't manage zone-based firewall of device,
+// "zone-member" of interfaces and zone-pairs on device are left
+// unchanged. Only those zone-pairs are removed, that reference some
+// policy-map generated by Netspoc.
+func (s *state) ignoreUnmanagedZBF() {
+	if s.spocHasZBF() {
+		return
+	}
+	for _, c := range s.deviceCfg.lookup["interface"][""] {
+		c.sub = slices.DeleteFunc(c.sub, func(sc *cmd) bool {
+			if strings.HasPrefix(sc.parsed, "zone-member security ") {
+				s.markNeeded([]*cmd{sc})
+				return true
+			}
+			return false
+		})
+	}
+	m := s.deviceCfg.lookup["zone-pair security"]
+	for name, l := range m {
+		if !slices.ContainsFunc(l[0].sub, func(sc *cmd) bool {
+			return slices.ContainsFunc(sc.ref, func(name string) bool {
+				return strings.Contains(name, "-DRC-")
+			})
+		}) {
+			s.markNeeded(l)
+			delete(m, name)
+		}
+	}
+}
+
 // 'crypto map gdoi' is currently not supported by Netspoc.
 // That commands must be left unchanged on device.
 func (s *state) ignoreCryptoGDOI() {
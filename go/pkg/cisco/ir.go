@@ -0,0 +1,343 @@
+package cisco
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
+)
+
+// ExportRules converts extended ACLs to vendor neutral representation.
+// Referenced object-groups are expanded.
+func (s *state) ExportRules(device bool) *ir.Config {
+	cf := s.spocCfg
+	if device {
+		cf = s.deviceCfg
+	}
+	result := &ir.Config{}
+	if cf == nil {
+		return result
+	}
+	bindings := cf.aclBindings()
+	add := func(name string, l []*cmd, isIOS bool) {
+		rs := &ir.RuleSet{
+			Name:     name,
+			Bindings: bindings[name],
+			Default:  ir.Deny,
+		}
+		for _, c := range l {
+			if r := cf.convertACLLine(c, isIOS); r != nil {
+				r.Position = len(rs.Rules) + 1
+				rs.Rules = append(rs.Rules, r)
+			}
+		}
+		result.RuleSets = append(result.RuleSets, rs)
+	}
+	for name, l := range cf.lookup["access-list"] {
+		if strings.HasPrefix(l[0].parsed, "access-list $NAME extended ") {
+			add(name, l, false)
+		}
+	}
+	for name, l := range cf.lookup["ip access-list extended"] {
+		add(name, l[0].sub, true)
+	}
+	for name, l := range cf.lookup["ip access-list"] {
+		add(name, l[0].sub, true)
+	}
+	sort.Slice(result.RuleSets, func(i, j int) bool {
+		return result.RuleSets[i].Name < result.RuleSets[j].Name
+	})
+	return result
+}
+
+// aclBindings returns interfaces where ACLs are bound, indexed by
+// name of ACL.
+func (cf *config) aclBindings() map[string][]*ir.Binding {
+	result := make(map[string][]*ir.Binding)
+	// ASA: access-group $REF in|out interface NAME
+	// ASA: access-group $REF global
+	for _, c := range cf.lookup["access-group"][""] {
+		tokens := strings.Fields(c.parsed)
+		b := &ir.Binding{}
+		if tokens[2] != "global" && len(tokens) == 5 {
+			b.Direction = tokens[2]
+			b.Name = tokens[4]
+		}
+		result[c.ref[0]] = append(result[c.ref[0]], b)
+	}
+	// IOS: interface NAME
+	//       ip access-group $REF in|out
+	for _, c := range cf.lookup["interface"][""] {
+		_, intf, _ := strings.Cut(c.parsed, " ")
+		for _, sc := range c.sub {
+			if strings.HasPrefix(sc.parsed, "ip access-group $REF ") {
+				tokens := strings.Fields(sc.parsed)
+				result[sc.ref[0]] = append(result[sc.ref[0]],
+					&ir.Binding{Name: intf, Direction: tokens[3]})
+			}
+		}
+	}
+	return result
+}
+
+type aclParser struct {
+	cf     *config
+	tokens []string
+	refs   []string
+	isIOS  bool
+}
+
+func (p *aclParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *aclParser) next() string {
+	t := p.peek()
+	if t != "" {
+		p.tokens = p.tokens[1:]
+	}
+	return t
+}
+
+// ref returns name of object-group for next "$REF" token.
+func (p *aclParser) ref() string {
+	if t := p.next(); t != "$REF" {
+		return t
+	}
+	if len(p.refs) == 0 {
+		return ""
+	}
+	r := p.refs[0]
+	p.refs = p.refs[1:]
+	return r
+}
+
+// convertACLLine converts a single line of ACL, given in parsed form
+// - ASA: access-list $NAME extended permit|deny PROTO SRC [PORT] DST [PORT] ...
+// - IOS: permit|deny PROTO SRC [PORT] DST [PORT] ...
+// Returns nil for remark and inactive line.
+func (cf *config) convertACLLine(c *cmd, isIOS bool) *ir.Rule {
+	tokens := strings.Fields(c.parsed)
+	if !isIOS {
+		tokens = tokens[3:]
+	}
+	p := &aclParser{cf: cf, tokens: tokens, refs: c.ref, isIOS: isIOS}
+	r := &ir.Rule{Orig: c.orig, Raw: c.raw}
+	switch p.next() {
+	case "permit":
+		r.Action = ir.Permit
+	case "deny":
+		r.Action = ir.Deny
+	default:
+		return nil
+	}
+	var srv *ir.Service
+	switch t := p.peek(); t {
+	case "object-group":
+		p.next()
+		srv = &ir.Service{Unknown: "object-group " + p.ref()}
+	case "object":
+		p.next()
+		srv = &ir.Service{Unknown: "object " + p.next()}
+	default:
+		p.next()
+		srv = &ir.Service{Proto: ir.ProtoName(t)}
+	}
+	r.Src = p.addr()
+	proto := srv.Proto
+	var unknownPort []string
+	ports := func() []ir.PortRange {
+		l, unknown := p.ports()
+		if unknown != "" {
+			unknownPort = append(unknownPort, unknown)
+		}
+		return l
+	}
+	if proto == ir.TCP || proto == ir.UDP {
+		srv.SrcPorts = ports()
+	}
+	r.Dst = p.addr()
+	switch proto {
+	case ir.TCP, ir.UDP:
+		srv.DstPorts = ports()
+	case ir.ICMP, ir.ICMPv6:
+		if n, err := strconv.Atoi(p.peek()); err == nil {
+			p.next()
+			srv.Type = ir.IntPtr(n)
+			if n, err := strconv.Atoi(p.peek()); err == nil {
+				p.next()
+				srv.Code = ir.IntPtr(n)
+			}
+		}
+	}
+	if unknownPort != nil {
+		srv.Unknown = proto + " " + strings.Join(unknownPort, " ")
+	}
+	if srv.Proto != ir.IP || srv.Unknown != "" {
+		r.Services = []*ir.Service{srv}
+	}
+	for _, t := range p.tokens {
+		switch t {
+		case "log", "log-input":
+			r.Log = true
+		case "inactive":
+			return nil
+		}
+	}
+	return r
+}
+
+// addr converts next tokens
+// any|any4|any6|host IP|IP MASK|IP/LEN|object-group $REF|object NAME
+// to set of addresses.
+func (p *aclParser) addr() *ir.AddrSet {
+	a := &ir.AddrSet{}
+	switch t := p.next(); t {
+	case "any":
+		if p.isIOS {
+			a.Prefixes = []netip.Prefix{ir.Any4}
+		} else {
+			a = ir.AnyAddr()
+		}
+	case "any4":
+		a.Prefixes = []netip.Prefix{ir.Any4}
+	case "any6":
+		a.Prefixes = []netip.Prefix{ir.Any6}
+	case "host":
+		a.Add(p.next())
+	case "object-group", "addrgroup":
+		p.cf.expandNetworkGroup(a, p.ref(), make(map[string]bool))
+	case "object", "interface", "object-group-security", "object-group-user",
+		"security-group", "user", "user-group":
+		a.Unknown = append(a.Unknown, t+" "+p.next())
+	default:
+		if strings.Contains(t, "/") {
+			a.Add(t)
+		} else {
+			addMasked(a, t, p.next(), p.isIOS)
+		}
+	}
+	return a
+}
+
+// ports converts next tokens
+// eq N|lt N|gt N|neq N|range N M|object-group $REF
+// to port ranges. Returns text of unconvertible ports as second value.
+// Ports matching no port at all, like "lt 0", are unconvertible,
+// because an empty list of port ranges would match all ports.
+func (p *aclParser) ports() ([]ir.PortRange, string) {
+	num := func(t string) (int, bool) {
+		n, err := strconv.Atoi(t)
+		return n, err == nil && n >= 0 && n <= 65535
+	}
+	switch t := p.peek(); t {
+	case "eq", "lt", "gt", "neq":
+		p.next()
+		v := p.next()
+		n, ok := num(v)
+		if !ok {
+			return nil, t + " " + v
+		}
+		var l []ir.PortRange
+		switch t {
+		case "eq":
+			l = []ir.PortRange{{Low: n, High: n}}
+		case "lt":
+			l = []ir.PortRange{{Low: 0, High: n - 1}}
+		case "gt":
+			l = []ir.PortRange{{Low: n + 1, High: 65535}}
+		default:
+			l = []ir.PortRange{{Low: 0, High: n - 1}, {Low: n + 1, High: 65535}}
+		}
+		// Remove empty range, e.g. from "neq 0".
+		l = slices.DeleteFunc(l, func(r ir.PortRange) bool {
+			return r.Low > r.High
+		})
+		if len(l) == 0 {
+			return nil, t + " " + v
+		}
+		return l, ""
+	case "range":
+		p.next()
+		v1, v2 := p.next(), p.next()
+		n1, ok1 := num(v1)
+		n2, ok2 := num(v2)
+		if !ok1 || !ok2 || n1 > n2 {
+			return nil, "range " + v1 + " " + v2
+		}
+		return []ir.PortRange{{Low: n1, High: n2}}, ""
+	case "object-group":
+		// Object-group may also be a network object-group of
+		// destination.
+		if len(p.refs) > 0 {
+			if l := p.cf.lookup["object-group"][p.refs[0]]; len(l) > 0 &&
+				strings.HasPrefix(l[0].parsed, "object-group service ") {
+				p.next()
+				return nil, "object-group " + p.ref()
+			}
+		}
+	}
+	return nil, ""
+}
+
+// expandNetworkGroup adds elements of network object-group to a.
+func (cf *config) expandNetworkGroup(
+	a *ir.AddrSet, name string, seen map[string]bool) {
+	l := cf.lookup["object-group"][name]
+	if len(l) == 0 || seen[name] {
+		a.Unknown = append(a.Unknown, "object-group "+name)
+		return
+	}
+	seen[name] = true
+	for _, sc := range l[0].sub {
+		tokens := strings.Fields(sc.parsed)
+		if tokens[0] == "network-object" {
+			tokens = tokens[1:]
+		}
+		switch tokens[0] {
+		case "description":
+		case "host":
+			a.Add(tokens[1])
+		case "group-object":
+			cf.expandNetworkGroup(a, tokens[1], seen)
+		case "object":
+			a.Unknown = append(a.Unknown, sc.parsed)
+		default:
+			if len(tokens) == 1 {
+				a.Add(tokens[0])
+			} else {
+				// Object-groups of IOS use netmask, not wildcard.
+				addMasked(a, tokens[0], tokens[1], false)
+			}
+		}
+	}
+}
+
+// addMasked adds IPv4 address with netmask or wildcard mask to a.
+// Address with non contiguous mask is added as unknown element.
+func addMasked(a *ir.AddrSet, ip, mask string, wildcard bool) {
+	addr, err1 := netip.ParseAddr(ip)
+	m, err2 := netip.ParseAddr(mask)
+	if err1 == nil && err2 == nil && addr.Is4() && m.Is4() {
+		b := m.As4()
+		v := binary.BigEndian.Uint32(b[:])
+		if wildcard {
+			v = ^v
+		}
+		ones := bits.LeadingZeros32(^v)
+		if v == ^uint32(0)<<(32-ones) {
+			a.Prefixes = append(a.Prefixes,
+				netip.PrefixFrom(addr, ones).Masked())
+			return
+		}
+	}
+	a.Unknown = append(a.Unknown, ip+" "+mask)
+}
@@ -9,8 +9,6 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
-
-	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 )
 
 func (s *state) setupParser(cmdInfo string) {
@@ -27,6 +25,12 @@ type config struct {
 	// prefix -> name -> commands with same prefix and name
 	lookup objLookup
 	isRaw  bool
+	// Used while merging raw file: Check that non anchor commands
+	// are referenced by some anchor and are referenced only once.
+	isReferenced map[*cmd]bool
+	// Unknown or ignored toplevel commands together with their
+	// subcommands. Only used when dumping config.
+	ignored []string
 }
 
 type objLookup map[string]map[string][]*cmd
@@ -47,6 +51,56 @@ type cmdType struct {
 	fixedName bool
 }
 
+// Marker in raw file controls, how following commands are merged
+// into configuration from Netspoc.
+type marker struct {
+	kind markerKind
+	// Line of ACL from Netspoc, given in [BEFORE ...] or [AFTER ...].
+	line string
+}
+
+type markerKind int
+
+const (
+	markPrepend markerKind = iota
+	markAppend
+	markReplace
+	markDelete
+	markBefore
+	markAfter
+)
+
+var markerNames = []string{
+	"PREPEND", "APPEND", "REPLACE", "DELETE", "BEFORE", "AFTER"}
+
+func (m marker) String() string {
+	if m.line != "" {
+		return markerNames[m.kind] + " " + m.line
+	}
+	return markerNames[m.kind]
+}
+
+// parseMarker parses line "[KIND]" or "[KIND LINE]".
+func parseMarker(line string) (marker, bool) {
+	var m marker
+	s, found := strings.CutPrefix(line, "[")
+	if !found {
+		return m, false
+	}
+	if s, found = strings.CutSuffix(s, "]"); !found {
+		return m, false
+	}
+	word, rest, _ := strings.Cut(s, " ")
+	i := slices.Index(markerNames, word)
+	if i == -1 {
+		return m, false
+	}
+	m.kind = markerKind(i)
+	m.line = strings.Join(strings.Fields(rest), " ")
+	withLine := m.kind == markBefore || m.kind == markAfter
+	return m, withLine == (m.line != "")
+}
+
 type cmd struct {
 	typ   *cmdType
 	ready bool // cmd from Netspoc was found on or transferred to device
@@ -56,7 +110,8 @@ type cmd struct {
 	toDelete  bool // Remove cmd on device if it is not needed
 	anchor    bool
 	fixedName bool
-	append    bool // Command was found after [APPEND] marker in raw file
+	mark      marker // Last marker before command in raw file
+	raw       bool   // Command was read from raw file
 
 	orig string // e.g. "crypto map abc 10 match address xyz"
 	// "*" and `"` of template are only used for matching,
@@ -67,6 +122,7 @@ type cmd struct {
 	ref      []string // Values of $REF, e.g. ["xyz"]
 	sub      []*cmd
 	subCmdOf *cmd
+	ignored  []string // Unknown or ignored subcommands, only used in dump
 }
 
 func (s *state) parseConfig(data []byte, fName string) (*config, error) {
@@ -83,31 +139,39 @@ func (p *parser) parseConfig(data []byte, fName string) (*config, error) {
 	isFirstSubCmd := false
 	// Indentation count of subcommand.
 	indent := 1
-	// Mark commands found after [APPEND] marker.
-	isAppend := false
+	// Mark commands found after [APPEND] or other marker.
+	var mark marker
 	isRaw := path.Ext(fName) == ".raw"
+	var ignored []string
+	lineNo := 0
 	for len(data) > 0 {
 		first, rest, _ := bytes.Cut(data, []byte("\n"))
 		data = rest
+		lineNo++
 		line := string(first)
 		// Remove whitespace at end of line in manually created raw file.
 		line = strings.TrimRightFunc(line, unicode.IsSpace)
 		if line == "" || line[0] == '!' {
 			continue
 		}
-		if line == "[APPEND]" {
-			isAppend = true
+		if m, ok := parseMarker(line); ok {
+			mark = m
 			continue
 		}
 		if line[0] != ' ' {
 			// Handle toplevel command.
-			c := p.lookupCmd(line)
+			c, err := p.lookupCmd(line)
+			if err != nil {
+				return nil, err
+			}
 			prev = c // Set to next command or nil.
 			isFirstSubCmd = true
 			if c == nil {
 				if isRaw {
-					return nil, fmt.Errorf("Unexpected command:\n>>%s<<", line)
+					return nil, fmt.Errorf(
+						"Unexpected command in line %d:\n>>%s<<", lineNo, line)
 				}
+				ignored = append(ignored, line)
 			} else {
 				p := c.typ.prefix
 				m := lookup[p]
@@ -115,7 +179,8 @@ func (p *parser) parseConfig(data []byte, fName string) (*config, error) {
 					m = make(map[string][]*cmd)
 					lookup[p] = m
 				}
-				c.append = isAppend
+				c.mark = mark
+				c.raw = isRaw
 				m[c.name] = append(m[c.name], c)
 			}
 		} else if prev != nil {
@@ -137,24 +202,47 @@ func (p *parser) parseConfig(data []byte, fName string) (*config, error) {
 				}
 			}
 			line = line[indent:]
-			// Ignore sub-sub command.
 			if line[0] == ' ' {
+				// IOS: Join action to class of inspect policy-map.
+				// Action may consist of multiple lines, e.g.
+				// "inspect" followed by "police ...".
+				// Class and action are sent together as multiple lines.
+				if l := prev.sub; prev.typ.prefix == "policy-map type inspect" &&
+					len(l) > 0 {
+					sc := l[len(l)-1]
+					sc.parsed += "\n" + strings.Join(strings.Fields(line), " ")
+					continue
+				}
+				// Ignore sub-sub command.
+				prev.ignored = append(prev.ignored, line)
 				continue
 			}
 			// Get arguments.  Use strings.Fields, not strings.Split to
 			// remove extra indentation between arguments.
 			// Example:  "map-name  memberOf ..."
 			words := strings.Fields(line)
-			if c := matchCmd("", words, prev.typ.sub); c != nil {
+			c, err := matchCmd("", words, prev.typ.sub)
+			if err != nil {
+				return nil, err
+			}
+			if c != nil {
 				prev.sub = append(prev.sub, c)
 				c.subCmdOf = prev
-				c.append = isAppend
+				c.mark = mark
+				c.raw = isRaw
+			} else {
+				prev.ignored = append(prev.ignored, line)
 			}
+		} else {
+			// Sub command of ignored command.
+			ignored = append(ignored, line)
 		}
 	}
-	postprocessParsed(lookup)
+	if err := postprocessParsed(lookup); err != nil {
+		return nil, err
+	}
 	err := p.checkReferences(lookup, isRaw)
-	return &config{lookup: lookup, isRaw: isRaw}, err
+	return &config{lookup: lookup, isRaw: isRaw, ignored: ignored}, err
 }
 
 func (p *parser) checkReferences(lookup objLookup, isRaw bool) error {
@@ -166,7 +254,7 @@ func (p *parser) checkReferences(lookup objLookup, isRaw bool) error {
 					if _, found := lookup[prefix][name]; !found {
 						if vl := defaultObjects[[2]string{prefix, name}]; vl != nil {
 							p.addDefaultObject(lookup, prefix, name, vl)
-						} else if !isRaw && prefix == "ip access-list extended" {
+						} else if !isRaw && isIOSACL(prefix) {
 							// Remove reference to unknown ACL.
 							c.ref = nil
 							c.parsed = c.orig
@@ -211,7 +299,7 @@ func (p *parser) addDefaultObject(lookup objLookup, prefix, name string, vl []st
 	}
 OBJ:
 	for _, arg := range vl {
-		c := p.lookupCmd(prefix + " " + name + " " + arg)
+		c, _ := p.lookupCmd(prefix + " " + name + " " + arg)
 		l := m[name]
 		// Do only add, if not already parsed previously.
 		for _, c2 := range l {
@@ -390,13 +478,13 @@ func (p *parser) setupLookup() {
 	}
 }
 
-func (p *parser) lookupCmd(line string) *cmd {
+func (p *parser) lookupCmd(line string) (*cmd, error) {
 	words := strings.Split(line, " ")
 	m := p.prefixMap
 	for i, w1 := range words {
 		cl := m[w1]
 		if cl == nil {
-			return nil
+			return nil, nil
 		}
 		if l := cl.descrList; l != nil {
 			prefix := strings.Join(words[:i+1], " ")
@@ -405,10 +493,10 @@ func (p *parser) lookupCmd(line string) *cmd {
 		}
 		m = cl.prefixMap
 	}
-	return nil
+	return nil, nil
 }
 
-func matchCmd(prefix string, words []string, l []*cmdType) *cmd {
+func matchCmd(prefix string, words []string, l []*cmdType) (*cmd, error) {
 DESCR:
 	for _, descr := range l {
 		args := words
@@ -450,7 +538,7 @@ DESCR:
 					strg = `"` + w + `"`
 				}
 				if strg == "" {
-					panic(fmt.Errorf("Incomplete string in: %v", words))
+					return nil, fmt.Errorf("Incomplete string in: %v", words)
 				}
 				parsed = append(parsed, strg)
 			case "*":
@@ -469,7 +557,7 @@ DESCR:
 			continue
 		}
 		if descr.ignore {
-			return nil
+			return nil, nil
 		}
 		if prefix != "" {
 			words = append([]string{prefix}, words...)
@@ -483,12 +571,12 @@ DESCR:
 			seq:    seq,
 			ref:    ref,
 		}
-		return c
+		return c, nil
 	}
-	return nil
+	return nil, nil
 }
 
-func postprocessParsed(lookup objLookup) {
+func postprocessParsed(lookup objLookup) error {
 	// In access-list, replace "object-group NAME" by "$REF" in cmd.parsed
 	// and add "NAME" to cmd.ref .
 	for _, l := range lookup["access-list"] {
@@ -501,11 +589,74 @@ func postprocessParsed(lookup objLookup) {
 			}
 		}
 	}
+	// IOS: ACL line may reference up to three object-groups
+	// with "object-group NAME" for service, source and destination.
 	for _, l := range lookup["ip access-list extended"] {
 		for _, c := range l[0].sub {
 			postprocessIOSACL(c)
+			c.typ.ref = []string{"object-group", "object-group", "object-group"}
 		}
 	}
+	for _, l := range lookup["ipv6 access-list"] {
+		for _, c := range l[0].sub {
+			postprocessIOSACL(c)
+		}
+	}
+	// NX-OS: ACL line may reference up to two object-groups
+	// with "addrgroup NAME".
+	for _, l := range lookup["ip access-list"] {
+		for _, c := range l[0].sub {
+			postprocessIOSACL(c)
+			c.typ.ref = []string{"object-group", "object-group"}
+		}
+	}
+	// NX-OS: Remove sequence number from elements of object-group.
+	for _, l := range lookup["object-group"] {
+		for _, c := range l[0].sub {
+			if seq, rest, found := strings.Cut(c.parsed, " "); found &&
+				seq == "$SEQ" {
+				c.parsed = rest
+				_, c.orig, _ = strings.Cut(c.orig, " ")
+			}
+		}
+	}
+	// IOS: Replace named ports and ICMP types in elements of
+	// service object-group.
+	for _, l := range lookup["object-group"] {
+		if strings.HasPrefix(l[0].parsed, "object-group service ") {
+			for _, c := range l[0].sub {
+				postprocessIOSServiceGroup(c)
+			}
+		}
+	}
+	// ASA: Join definition of object and object NAT, that are shown
+	// as separate commands with same name.
+	// Object with NAT is anchor and must not be renamed.
+	// Other objects are simple objects.
+	for name, l := range lookup["object network"] {
+		c := l[0]
+		for _, c2 := range l[1:] {
+			for _, sc := range c2.sub {
+				sc.subCmdOf = c
+			}
+			c.sub = append(c.sub, c2.sub...)
+		}
+		lookup["object network"][name] = l[:1]
+		if slices.ContainsFunc(c.sub, func(sc *cmd) bool {
+			return strings.HasPrefix(sc.parsed, "nat ")
+		}) {
+			c.anchor = true
+			c.fixedName = true
+		} else {
+			typ := *c.typ
+			typ.simpleObj = true
+			c.typ = &typ
+		}
+	}
+	// ASA: Replace names of objects in manual NAT by $REF.
+	for _, c := range lookup["nat"][""] {
+		postprocessNAT(c, lookup)
+	}
 	// Move crypto map interface commands to different prefix for
 	// easier subsequent processing.
 	if l := lookup["crypto map"][""]; l != nil {
@@ -540,6 +691,33 @@ func postprocessParsed(lookup objLookup) {
 	setTransRef("crypto map", "ikev2 ipsec-proposal")
 	setTransRef("crypto dynamic-map", "ikev1 transform-set")
 	setTransRef("crypto dynamic-map", "ikev2 ipsec-proposal")
+	// NAME in subcommands
+	// - IOS: "set transform-set NAME ..." of
+	//   "crypto map $NAME $SEQ ipsec-isakmp" and "crypto ipsec profile $NAME"
+	//   may reference up to 6 $crypto_ipsec_transform-set
+	// - ASA: "set ikev1 transform-set NAME ..." and
+	//   "set ikev2 ipsec-proposal NAME ..." of "crypto ipsec profile $NAME"
+	//   may reference up to 11 $crypto_ipsec_ikev1_transform-set
+	//   or $crypto_ipsec_ikev2_ipsec-proposal
+	setSubTransRef := func(prefix, part string, max int) {
+		cmdPart := "set " + part + " "
+		for _, l := range lookup[prefix] {
+			for _, c := range l {
+				for _, sc := range c.sub {
+					if names, found := strings.CutPrefix(sc.parsed, cmdPart); found {
+						nl := strings.Fields(names)
+						sc.ref = nl
+						sc.parsed = cmdPart + strings.Repeat("$REF ", len(nl)-1) + "$REF"
+						sc.typ.ref = slices.Repeat([]string{"crypto ipsec " + part}, max)
+					}
+				}
+			}
+		}
+	}
+	setSubTransRef("crypto map", "transform-set", 6)
+	setSubTransRef("crypto ipsec profile", "transform-set", 6)
+	setSubTransRef("crypto ipsec profile", "ikev1 transform-set", 11)
+	setSubTransRef("crypto ipsec profile", "ikev2 ipsec-proposal", 11)
 
 	// Strip default value group14 from "crypto [dynamic-]map set pfs group14"
 	// The default is group2 for releases prior to 9.13,
@@ -626,7 +804,7 @@ func postprocessParsed(lookup objLookup) {
 						ref = c.sub[0].ref[0]
 					}
 					if ldapMap != " " && ldapMap != ref {
-						errlog.Abort("aaa-server %s must not use different values"+
+						return fmt.Errorf("aaa-server %s must not use different values"+
 							" in 'ldap-attribute-map'",
 							name)
 					}
@@ -658,6 +836,7 @@ func postprocessParsed(lookup objLookup) {
 			}
 		}
 	}
+	return nil
 }
 
 var protoNames = map[string]int{
@@ -868,6 +1047,59 @@ var logNames = map[string]int{
 	"debugging":     7,
 }
 
+// postprocessNAT replaces names of objects and object-groups in
+// manual NAT command by $REF and adds names to cmd.ref.
+// nat (real_ifc,mapped_ifc) [after-auto]
+// - source static|dynamic REAL MAPPED
+// - [destination static MAPPED REAL]
+// - [service REAL MAPPED] ...
+// Other words, e.g. "any", "interface" or names of service objects
+// are left unchanged.
+// Since objects may be of type "object network" or "object-group",
+// each command gets its own copy of cmdType with individual references.
+func postprocessNAT(c *cmd, lookup objLookup) {
+	tokens := strings.Fields(c.parsed)
+	var refs []string
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "source", "destination":
+		default:
+			continue
+		}
+		if i+1 < len(tokens) &&
+			(tokens[i+1] == "static" || tokens[i+1] == "dynamic") {
+			i++
+		}
+		for j := i + 1; j <= i+2 && j < len(tokens); j++ {
+			name := tokens[j]
+			for _, prefix := range []string{"object network", "object-group"} {
+				if _, found := lookup[prefix][name]; found {
+					c.ref = append(c.ref, name)
+					refs = append(refs, prefix)
+					tokens[j] = "$REF"
+					break
+				}
+			}
+		}
+	}
+	if refs != nil {
+		typ := *c.typ
+		typ.ref = refs
+		c.typ = &typ
+		c.parsed = strings.Join(tokens, " ")
+	}
+}
+
+// isIOSACL returns true for prefix of ACL having its entries as
+// subcommands:
+// - IOS: ip access-list extended NAME
+// - IOS: ipv6 access-list NAME
+// - NX-OS: ip access-list NAME
+func isIOSACL(prefix string) bool {
+	return prefix == "ip access-list extended" ||
+		prefix == "ipv6 access-list" || prefix == "ip access-list"
+}
+
 func postprocessIOSACL(c *cmd) {
 	tokens := strings.Fields(c.parsed)
 	// Remove sequence number shown since IOS-XE 16.12.
@@ -876,6 +1108,18 @@ func postprocessIOSACL(c *cmd) {
 		c.parsed = strings.Join(tokens, " ")
 		_, c.orig, _ = strings.Cut(c.orig, " ")
 	}
+	// IOS IPv6: Remove "sequence N" from beginning or end of line.
+	if tokens[0] == "sequence" {
+		tokens = tokens[2:]
+		c.parsed = strings.Join(tokens, " ")
+		words := strings.Fields(c.orig)
+		c.orig = strings.Join(words[2:], " ")
+	} else if l := len(tokens); l > 2 && tokens[l-2] == "sequence" {
+		tokens = tokens[:l-2]
+		c.parsed = strings.Join(tokens, " ")
+		words := strings.Fields(c.orig)
+		c.orig = strings.Join(words[:len(words)-2], " ")
+	}
 	if tokens[0] == "remark" {
 		return
 	}
@@ -887,6 +1131,50 @@ func postprocessIOSACL(c *cmd) {
 	c.parsed = strings.Join(tokens, " ")
 }
 
+// Postprocess element of IOS service object-group
+// tcp|udp|tcp-udp [source PORT] [PORT] | icmp [TYPE] | PROTO
+// where PORT is eq N|lt N|gt N|neq N|range N M.
+// cmd.orig is changed as well, because elements of object-groups are
+// compared by cmd.orig.
+func postprocessIOSServiceGroup(c *cmd) {
+	tokens := strings.Fields(c.parsed)
+	switch tokens[0] {
+	case "tcp", "tcp-udp":
+		convPorts(tokens[1:], tcpNames)
+	case "udp":
+		convPorts(tokens[1:], udpNames)
+	case "icmp":
+		if len(tokens) > 1 {
+			if replace, found := icmpTypeCodes[tokens[1]]; found {
+				tokens[1] = replace
+			}
+		}
+	default:
+		return
+	}
+	c.parsed = strings.Join(tokens, " ")
+	c.orig = c.parsed
+}
+
+func convPorts(tokens []string, m map[string]int) {
+	conv := func(i int) {
+		if i < len(tokens) {
+			if num, found := m[tokens[i]]; found {
+				tokens[i] = strconv.Itoa(num)
+			}
+		}
+	}
+	for i, w := range tokens {
+		switch w {
+		case "eq", "gt", "lt", "neq":
+			conv(i + 1)
+		case "range":
+			conv(i + 1)
+			conv(i + 2)
+		}
+	}
+}
+
 // Postprocess command
 // access-list $NAME extended deny|permit PROTO SRC [PORT] DST [PORT]
 // - Replace named TCP, UDP ports and ICMP type by number
@@ -948,7 +1236,7 @@ func postprocessACLParts(c *cmd, parts []string) {
 	convObject := func() {
 		if len(parts) > 0 {
 			switch parts[0] {
-			case "object-group":
+			case "object-group", "addrgroup":
 				convObjectGroup()
 			case "log", "log-input":
 				parts = parts[1:]
@@ -974,6 +1262,11 @@ func postprocessACLParts(c *cmd, parts []string) {
 						parts[0] = "any6"
 					case "128":
 						parts[0] = "host " + ip
+					case "32":
+						// NX-OS uses IP/LEN for IPv4 as well.
+						if !strings.Contains(ip, ":") {
+							parts[0] = "host " + ip
+						}
 					}
 					parts = parts[1:]
 				} else if len(parts) >= 2 {
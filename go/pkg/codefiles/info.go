@@ -15,34 +15,49 @@ type codeInfo struct {
 	PolicyDistributionPoint string   `json:"policy_distribution_point,omitempty"`
 }
 
-func LoadInfoFile(path string) (*codeInfo, []string) {
+// LoadInfoFile reads info file of IPv4 and IPv6 code file.
+// Returns info together with names of files read.
+func LoadInfoFile(path string) (*codeInfo, []string, error) {
 	path6 := GetIPv6Fname(path)
 	info := &codeInfo{}
 	var checked []string
 	for _, file := range []string{path, path6} {
 		file += ".info"
-		fd, err := os.Open(file)
+		data, err := os.ReadFile(file)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				continue
 			}
-			panic(err)
+			return nil, nil, fmt.Errorf("Can't %v", err)
 		}
 		checked = append(checked, file)
-		defer fd.Close()
-		if err := json.NewDecoder(fd).Decode(&info); err != nil {
-			panic(err)
+		if err := json.Unmarshal(data, info); err != nil {
+			return nil, nil, fmt.Errorf("Invalid JSON in %s: %v", file, err)
 		}
 		// Must also read IPv6 file if v4 file has no IP.
 		if len(info.IPList) > 0 {
 			break
 		}
 	}
-	return info, checked
+	return info, checked, nil
+}
+
+// GetDeviceName returns name of device, that is reached at first IP
+// address. If file describes virtual device, this is name of
+// physical device.
+func GetDeviceName(fName string) (string, error) {
+	info, _, err := LoadInfoFile(fName)
+	if err != nil || len(info.NameList) == 0 {
+		return "", err
+	}
+	return info.NameList[0], nil
 }
 
 func GetIPPDP(fName string) (string, string, error) {
-	info, checked := LoadInfoFile(fName)
+	info, checked, err := LoadInfoFile(fName)
+	if err != nil {
+		return "", "", err
+	}
 	ipList := info.IPList
 	if len(ipList) == 0 {
 		return "", "", fmt.Errorf("Missing IP address in %v", checked)
@@ -0,0 +1,19 @@
+package codefiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// AddJSONLine adds number of line to syntax error err,
+// found while unmarshaling JSON from data[start:].
+func AddJSONLine(data []byte, start int, err error) error {
+	var se *json.SyntaxError
+	if !errors.As(err, &se) {
+		return err
+	}
+	end := min(start+int(se.Offset), len(data))
+	return fmt.Errorf("%v in line %d", err, 1+bytes.Count(data[:end], []byte("\n")))
+}
@@ -1,6 +1,7 @@
 package console
 
 import (
+	"fmt"
 	"os"
 	"regexp"
 	"strings"
@@ -54,7 +55,7 @@ func GetSSHConn(spocFile, user string, cfg *program.Config, logLogin *os.File) (
 		con, _, err = expect.SpawnWithArgs(cmd, short, expect.PartialMatch(true))
 	}
 	if err != nil {
-		return nil, err
+		return nil, errlog.Classify(errlog.Unreachable, err)
 	}
 
 	return &Conn{
@@ -106,28 +107,31 @@ func (c *Conn) expectLog(prompt *regexp.Regexp, t time.Duration,
 	return out, err
 }
 
-func (c *Conn) WaitLogin(prompt string) string {
+func (c *Conn) WaitLogin(prompt string) (string, error) {
 	out, err := c.expectLog(regexp.MustCompile(prompt), c.ShortTimeout)
 	if err != nil {
-		errlog.Abort("while waiting for login prompt '%s': %v", prompt, err)
+		return "", errlog.Classify(errlog.Unreachable, fmt.Errorf(
+			"while waiting for login prompt '%s': %v", prompt, err))
 	}
-	return out
+	return out, nil
 }
 
-func (c *Conn) WaitShort(prompt string) string {
+func (c *Conn) WaitShort(prompt string) (string, error) {
 	out, err := c.expectLog(regexp.MustCompile(prompt), c.ShortTimeout)
 	if err != nil {
-		errlog.Abort("while waiting for prompt '%s': %v", prompt, err)
+		return "", errlog.Classify(errlog.Unreachable, fmt.Errorf(
+			"while waiting for prompt '%s': %v", prompt, err))
 	}
-	return out
+	return out, nil
 }
 
-func (c *Conn) waitPrompt(re *regexp.Regexp) string {
+func (c *Conn) waitPrompt(re *regexp.Regexp) (string, error) {
 	out, err := c.expectLog(re, c.Timeout)
 	if err != nil {
-		errlog.Abort("while waiting for prompt '%s': %v", re, err)
+		return "", errlog.Classify(errlog.Unreachable, fmt.Errorf(
+			"while waiting for prompt '%s': %v", re, err))
 	}
-	return out
+	return out, nil
 }
 
 func (c *Conn) TryPrompt() bool {
@@ -142,46 +146,55 @@ func (c *Conn) Send(cmd string) {
 	c.con.Send(cmd + "\n")
 }
 
-func (c *Conn) IssueCmd(cmd, re string) string {
+func (c *Conn) IssueCmd(cmd, re string) (string, error) {
 	c.Send(cmd)
 	return c.waitPrompt(regexp.MustCompile(re))
 }
 
-func (c *Conn) SendCmd(cmd string) {
+func (c *Conn) SendCmd(cmd string) error {
 	c.Send(cmd)
-	c.waitPrompt(c.promptRE)
+	_, err := c.waitPrompt(c.promptRE)
+	return err
 }
 
-func (c *Conn) GetCmdOutput(cmd string) string {
+func (c *Conn) GetCmdOutput(cmd string) (string, error) {
 	c.Send(cmd)
-	return c.StripEcho(cmd, c.GetOutput())
+	out, err := c.GetOutput()
+	if err != nil {
+		return "", err
+	}
+	return c.StripEcho(cmd, out)
 }
 
-func (c *Conn) GetOutput() string {
-	out := c.waitPrompt(c.promptRE)
-	out = c.StripStdPrompt(out)
-	return out
+func (c *Conn) GetOutput() (string, error) {
+	out, err := c.waitPrompt(c.promptRE)
+	if err != nil {
+		return "", err
+	}
+	return c.StripStdPrompt(out)
 }
 
 func (c *Conn) SetStdPrompt(p *regexp.Regexp) {
 	c.promptRE = p
 }
 
-func (c *Conn) StripStdPrompt(s string) string {
+func (c *Conn) StripStdPrompt(s string) (string, error) {
 	loc := c.promptRE.FindStringIndex(s)
 	if loc == nil {
-		errlog.Abort("Missing prompt '%s' in response:\n'%v'", c.promptRE, s)
+		return "", fmt.Errorf("Missing prompt '%s' in response:\n'%v'",
+			c.promptRE, s)
 	}
 	i := loc[0]
 	// Don't remove trailing "\n".
-	return s[:i+1]
+	return s[:i+1], nil
 }
 
-func (c *Conn) StripEcho(cmd, s string) string {
+func (c *Conn) StripEcho(cmd, s string) (string, error) {
 	cShort := cmd
 	cmd += "\n"
 	if len(s) < len(cmd) || s[:len(cmd)] != cmd {
-		errlog.Abort("Got unexpected echo in response to '%s':\n%v", cShort, s)
+		return "", fmt.Errorf("Got unexpected echo in response to '%s':\n%v",
+			cShort, s)
 	}
-	return s[len(cmd):]
+	return s[len(cmd):], nil
 }
@@ -0,0 +1,284 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/status"
+	"github.com/spf13/pflag"
+)
+
+func Main() int {
+	fs := pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n%s",
+			os.Args[0], fs.FlagUsages())
+	}
+	listen := fs.StringP("listen", "l", "localhost:8310",
+		"Listen for HTTP requests at `ADDRESS`")
+	keep := fs.IntP("keep", "k", 100,
+		"Keep results of last `N` finished jobs")
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if err == pflag.ErrHelp {
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fs.Usage()
+		return 1
+	}
+	if len(fs.Args()) != 0 {
+		fs.Usage()
+		return 1
+	}
+	cfg, err := program.LoadConfig()
+	if err != nil {
+		return abort("%v", err)
+	}
+	if _, err := readUsers(cfg.BaseDir); err != nil {
+		return abort("%v", err)
+	}
+	err = http.ListenAndServe(*listen, NewHandler(cfg, *keep))
+	return abort("%v", err)
+}
+
+func abort(format string, args ...any) int {
+	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	return 1
+}
+
+// NewHandler returns handler of HTTP API:
+//
+//	POST /jobs                start job, returns job
+//	GET  /jobs/{id}           returns job
+//	GET  /jobs/{id}/log       streams messages of job, until finished
+//	GET  /status/{device}     returns status file of device
+//	GET  /history/{device}    returns lines of history file of device
+//
+// Body of POST is JSON object with attributes "action" and "device"
+// and optional "only", "exclude", "resume" and "force", like
+// arguments of do-approve. Jobs of the same device are queued and run one after
+// the other. A job waits while device is locked by do-approve.
+// A job can only be read by user who started it or by user with
+// permission "all-jobs". Only the last keep finished jobs are
+// remembered.
+func NewHandler(cfg *program.Config, keep int) http.Handler {
+	s := &server{
+		cfg:    cfg,
+		keep:   keep,
+		jobs:   make(map[int]*job),
+		queues: make(map[string][]*job),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", s.auth(s.postJob))
+	mux.HandleFunc("GET /jobs/{id}", s.auth(s.getJob))
+	mux.HandleFunc("GET /jobs/{id}/log", s.auth(s.getJobLog))
+	mux.HandleFunc("GET /status/{device}", s.auth(s.getStatus))
+	mux.HandleFunc("GET /history/{device}", s.auth(s.getHistory))
+	return mux
+}
+
+type server struct {
+	cfg   *program.Config
+	keep  int
+	mutex sync.Mutex
+	jobs  map[int]*job
+	// ID of last started job.
+	lastID int
+	// IDs of finished jobs, oldest first.
+	finished []int
+	// Queued jobs of each device. First job is running.
+	queues map[string][]*job
+}
+
+type handlerFunc func(w http.ResponseWriter, r *http.Request, u *user)
+
+// auth authenticates user of request by bearer token.
+func (s *server) auth(f handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users, err := readUsers(s.cfg.BaseDir)
+		if err != nil {
+			httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"),
+			"Bearer ")
+		u := findUser(users, token)
+		if !found || u == nil {
+			httpError(w, http.StatusUnauthorized,
+				errors.New("Authentication failed"))
+			return
+		}
+		f(w, r, u)
+	}
+}
+
+func (s *server) postJob(w http.ResponseWriter, r *http.Request, u *user) {
+	j := new(job)
+	if err := json.NewDecoder(r.Body).Decode(&j.jobRequest); err != nil {
+		httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !slices.Contains(jobActions, j.Action) {
+		httpError(w, http.StatusBadRequest,
+			fmt.Errorf("Unknown action %q", j.Action))
+		return
+	}
+	if err := checkDevice(j.Device); err != nil {
+		httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if j.Action != "approve" &&
+		(j.Only != nil || j.Exclude != nil || j.Resume || j.Force) {
+		httpError(w, http.StatusBadRequest, fmt.Errorf(
+			"Must use 'only', 'exclude', 'resume' and 'force'"+
+				" only with approve"))
+		return
+	}
+	if j.Resume && j.Force {
+		httpError(w, http.StatusBadRequest,
+			errors.New("Must not use 'resume' together with 'force'"))
+		return
+	}
+	action := j.Action
+	if j.Force {
+		action = "force"
+	}
+	if !u.mayRun(action) {
+		httpError(w, http.StatusForbidden,
+			fmt.Errorf("User %q must not %s", u.name, action))
+		return
+	}
+	j.User = u.name
+	j.groups = u.groups
+	j.State = queued
+	j.cond = sync.NewCond(&j.mutex)
+	s.mutex.Lock()
+	s.lastID++
+	j.ID = s.lastID
+	s.jobs[j.ID] = j
+	q := s.queues[j.Device]
+	s.queues[j.Device] = append(q, j)
+	if len(q) == 0 {
+		go s.work(j.Device)
+	}
+	s.mutex.Unlock()
+	writeJSON(w, http.StatusAccepted, j.info())
+}
+
+// work runs queued jobs of device one after the other.
+func (s *server) work(device string) {
+	for {
+		s.mutex.Lock()
+		q := s.queues[device]
+		if len(q) == 0 {
+			delete(s.queues, device)
+			s.mutex.Unlock()
+			return
+		}
+		j := q[0]
+		s.mutex.Unlock()
+		j.run(s.cfg)
+		s.mutex.Lock()
+		s.queues[device] = s.queues[device][1:]
+		s.forget(j)
+		s.mutex.Unlock()
+	}
+}
+
+// forget removes oldest finished jobs, if more than s.keep jobs
+// have finished.
+func (s *server) forget(j *job) {
+	s.finished = append(s.finished, j.ID)
+	for len(s.finished) > s.keep {
+		delete(s.jobs, s.finished[0])
+		s.finished = s.finished[1:]
+	}
+}
+
+// findJob returns job with ID from path of request, if it may be read
+// by user u.
+func (s *server) findJob(w http.ResponseWriter, r *http.Request, u *user) *job {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	s.mutex.Lock()
+	j := s.jobs[id]
+	s.mutex.Unlock()
+	if err != nil || j == nil {
+		httpError(w, http.StatusNotFound, errors.New("Unknown job"))
+		return nil
+	}
+	if j.User != u.name && !u.mayRun("all-jobs") {
+		httpError(w, http.StatusForbidden,
+			fmt.Errorf("User %q must not read job %d", u.name, id))
+		return nil
+	}
+	return j
+}
+
+func (s *server) getJob(w http.ResponseWriter, r *http.Request, u *user) {
+	if j := s.findJob(w, r, u); j != nil {
+		writeJSON(w, http.StatusOK, j.info())
+	}
+}
+
+func (s *server) getJobLog(w http.ResponseWriter, r *http.Request, u *user) {
+	if j := s.findJob(w, r, u); j != nil {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		j.streamLog(r.Context(), w)
+	}
+}
+
+func (s *server) getStatus(w http.ResponseWriter, r *http.Request, u *user) {
+	device := r.PathValue("device")
+	if err := checkDevice(device); err != nil {
+		httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, status.Read(s.cfg, device))
+}
+
+func (s *server) getHistory(w http.ResponseWriter, r *http.Request, u *user) {
+	device := r.PathValue("device")
+	if err := checkDevice(device); err != nil {
+		httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	data, err := os.ReadFile(path.Join(s.cfg.BaseDir, "history", device))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+	lines := []string{}
+	if len(data) != 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	writeJSON(w, http.StatusOK, lines)
+}
+
+// checkDevice prevents access to files outside of status and
+// history directory.
+func checkDevice(name string) error {
+	if name == "" || name[0] == '.' || strings.Contains(name, "/") {
+		return fmt.Errorf("Invalid device name %q", name)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func httpError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
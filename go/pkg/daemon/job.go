@@ -0,0 +1,157 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/doapprove"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
+)
+
+var jobActions = []string{"compare", "approve", "check-access"}
+
+const (
+	queued   = "queued"
+	running  = "running"
+	finished = "finished"
+)
+
+type jobRequest struct {
+	Action  string   `json:"action"`
+	Device  string   `json:"device"`
+	Only    []string `json:"only,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	Resume  bool     `json:"resume,omitempty"`
+	Force   bool     `json:"force,omitempty"`
+}
+
+// jobInfo is returned as result of job.
+type jobInfo struct {
+	ID   int    `json:"id"`
+	User string `json:"user"`
+	jobRequest
+	State string `json:"state"`
+	// Exit status of do-approve, only set if finished.
+	Status *int `json:"status,omitempty"`
+	// Messages of do-approve.
+	Output string `json:"output"`
+}
+
+type job struct {
+	jobInfo
+	// Groups of user, used in authorization file.
+	groups []string
+	mutex  sync.Mutex
+	cond   *sync.Cond
+	// Messages of device log, copied while job is running.
+	log    bytes.Buffer
+	output bytes.Buffer
+}
+
+func (j *job) info() jobInfo {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	result := j.jobInfo
+	result.Output = j.output.String()
+	return result
+}
+
+func (j *job) run(cfg *program.Config) {
+	// Record arguments in history like those of do-approve.
+	var args []string
+	if j.Only != nil {
+		args = append(args, "--only="+strings.Join(j.Only, ","))
+	}
+	if j.Exclude != nil {
+		args = append(args, "--exclude="+strings.Join(j.Exclude, ","))
+	}
+	if j.Resume {
+		args = append(args, "--resume")
+	}
+	if j.Force {
+		args = append(args, "--force")
+	}
+	args = append(args, j.Action, j.Device)
+	stat := doapprove.Run(cfg, &doapprove.Job{
+		Action:   j.Action,
+		Device:   j.Device,
+		Only:     j.Only,
+		Exclude:  j.Exclude,
+		Resume:   j.Resume,
+		Force:    j.Force,
+		User:     j.User,
+		Groups:   j.groups,
+		Args:     args,
+		Stdout:   writerFunc(j.writeOutput),
+		Stderr:   writerFunc(j.writeOutput),
+		Progress: writerFunc(j.writeLog),
+		Locked:   func() { j.setState(running) },
+	})
+	j.mutex.Lock()
+	j.Status = &stat
+	j.mutex.Unlock()
+	j.setState(finished)
+}
+
+func (j *job) setState(s string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.State = s
+	j.cond.Broadcast()
+}
+
+func (j *job) writeOutput(p []byte) (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.output.Write(p)
+}
+
+func (j *job) writeLog(p []byte) (int, error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	defer j.cond.Broadcast()
+	return j.log.Write(p)
+}
+
+// streamLog writes messages of device log to w, while job is
+// running. Returns after job has finished or if ctx is done, because
+// client has closed connection.
+func (j *job) streamLog(ctx context.Context, w http.ResponseWriter) {
+	flusher, _ := w.(http.Flusher)
+	pos := 0
+	// Wake up cond.Wait below.
+	stop := context.AfterFunc(ctx, func() {
+		j.mutex.Lock()
+		defer j.mutex.Unlock()
+		j.cond.Broadcast()
+	})
+	defer stop()
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		if data := j.log.Bytes()[pos:]; len(data) != 0 {
+			pos += len(data)
+			j.mutex.Unlock()
+			w.Write(data)
+			if flusher != nil {
+				flusher.Flush()
+			}
+			j.mutex.Lock()
+			continue
+		}
+		if j.State == finished {
+			return
+		}
+		j.cond.Wait()
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+type user struct {
+	name    string
+	token   string
+	actions []string
+	groups  []string
+}
+
+// Format of file "api-users" in basedir
+//   - multiple lines
+//   - empty lines and lines starting with '#' are ignored
+//   - three or four fields, separated by whitespace:
+//     user token actions [groups]
+//   - actions is a comma separated list of compare, approve, check-access,
+//     force and all-jobs
+//   - groups is a comma separated list of groups of user. These are
+//     used instead of Unix groups in file "authorization".
+//   - Jobs can be read by user who started it and by users with all-jobs.
+//   - Status and history of devices can be read by each user.
+//
+// A request is authenticated by header "Authorization: Bearer TOKEN".
+func readUsers(basedir string) ([]user, error) {
+	file := path.Join(basedir, "api-users")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("Can't %v", err)
+	}
+	var result []user
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 3 && len(parts) != 4 {
+			return nil, fmt.Errorf("Expected 3 or 4 fields in line %d of %s",
+				i+1, file)
+		}
+		actions := strings.Split(parts[2], ",")
+		for _, a := range actions {
+			if !slices.Contains(jobActions, a) &&
+				a != "force" && a != "all-jobs" {
+				return nil, fmt.Errorf("Unknown action %q in line %d of %s",
+					a, i+1, file)
+			}
+		}
+		var groups []string
+		if len(parts) == 4 {
+			groups = strings.Split(parts[3], ",")
+		}
+		result = append(result, user{parts[0], parts[1], actions, groups})
+	}
+	return result, nil
+}
+
+// findUser returns user with given token or nil.
+func findUser(l []user, token string) *user {
+	for i, u := range l {
+		if subtle.ConstantTimeCompare([]byte(u.token), []byte(token)) == 1 {
+			return &l[i]
+		}
+	}
+	return nil
+}
+
+func (u *user) mayRun(action string) bool {
+	return slices.Contains(u.actions, action)
+}
@@ -0,0 +1,106 @@
+package device
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/codefiles"
+	"golang.org/x/term"
+)
+
+// selectChanges shows changes grouped by kind of changed objects and
+// asks operator for confirmation. Operator may deselect single groups
+// of changes, which are then left unapplied.
+// Returns names of deselected groups.
+func (s *state) selectChanges(fname string) ([]string, error) {
+	if s.in == nil || s.out == nil {
+		return nil, errors.New("Interactive approve needs input of operator")
+	}
+	groups := s.ChangeGroups()
+	var collect strings.Builder
+	for _, g := range groups {
+		fmt.Fprintf(&collect, "### Changes of %s\n%s", g.Name, g.Changes)
+	}
+	showPaged(collect.String(), s.out)
+	in := bufio.NewReader(s.in)
+	ask := func(prompt, valid string) (byte, error) {
+		return askOperator(in, s.out, isTerminal(s.in), prompt, valid)
+	}
+	answer, err := ask(fmt.Sprintf(
+		"Apply changes to %s? [y]es, [n]o, [s]elect: ",
+		codefiles.GetHostname(fname)), "yns")
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	switch answer {
+	case 'n':
+		return nil, errors.New("Approve cancelled by operator")
+	case 's':
+		for _, g := range groups {
+			answer, err := ask(
+				fmt.Sprintf("Apply changes of %s? [y]es, [n]o: ", g.Name), "yn")
+			if err != nil {
+				return nil, err
+			}
+			if answer == 'n' {
+				s.DeselectChanges(g.Name)
+				result = append(result, g.Name)
+			}
+		}
+	}
+	return result, nil
+}
+
+// askOperator shows prompt until operator answers with one of given
+// characters. End of input cancels approve.
+func askOperator(
+	in *bufio.Reader, out io.Writer, echoed bool, prompt, valid string,
+) (byte, error) {
+	for {
+		fmt.Fprint(out, prompt)
+		line, err := in.ReadString('\n')
+		a := strings.TrimSpace(line)
+		if err != nil || !echoed {
+			// Show answer, if it wasn't echoed by terminal.
+			fmt.Fprintln(out, a)
+		}
+		a = strings.ToLower(a)
+		if a != "" && strings.Contains(valid, a[:1]) {
+			return a[0], nil
+		}
+		if err != nil {
+			return 0, errors.New("Approve cancelled by operator")
+		}
+	}
+}
+
+// showPaged prints text to out using pager from environment variable
+// PAGER or "less", if out is a terminal.
+func showPaged(text string, out io.Writer) {
+	if isTerminal(out) {
+		pager := os.Getenv("PAGER")
+		if pager == "" {
+			pager = "less -FX"
+		}
+		cmd := exec.Command("sh", "-c", pager)
+		cmd.Stdin = strings.NewReader(text)
+		cmd.Stdout = out
+		cmd.Stderr = os.Stderr
+		if cmd.Run() == nil {
+			return
+		}
+	}
+	fmt.Fprint(out, text)
+}
+
+// isTerminal tells if f is a file connected to a terminal.
+func isTerminal(f any) bool {
+	fh, ok := f.(*os.File)
+	return ok && term.IsTerminal(int(fh.Fd()))
+}
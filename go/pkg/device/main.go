@@ -1,10 +1,14 @@
 package device
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/asa"
@@ -13,51 +17,76 @@ import (
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/codefiles"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/ios"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/journal"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/linux"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/nsx"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/nxos"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/panos"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
 type RealDevice interface {
+	CheckAccess(fname string, c *program.Config, l *os.File) ([]string, error)
 	LoadDevice(fname string, c *program.Config, l1, l2 *os.File) error
 	LoadNetspoc(data []byte, fName string) error
+	DumpNetspoc() any
+	ExportRules(device bool) *ir.Config
 	MoveNetspoc2DeviceConfig()
+	Protect(l program.ProtectList) error
 	GetChanges() error
 	GetErrUnmanaged() []error
-	ApplyCommands(*os.File) error
+	ApplyCommands(*os.File, *journal.Journal) error
 	HasChanges() bool
 	ShowChanges() string
+	ChangeGroups() []program.ChangeGroup
+	DeselectChanges(name string)
 	CloseConnection()
+	SetLogger(*errlog.Logger)
 }
 
-func getRealDevice(fname string) RealDevice {
-	var result RealDevice
-	info, _ := codefiles.LoadInfoFile(fname)
+func getRealDevice(fname string) (RealDevice, error) {
+	info, _, err := codefiles.LoadInfoFile(fname)
+	if err != nil {
+		return nil, err
+	}
 	switch info.Model {
 	case "ASA":
-		result = cisco.Setup(&asa.State{})
+		return cisco.Setup(&asa.State{}), nil
 	case "IOS":
-		result = cisco.Setup(&ios.State{})
+		return cisco.Setup(&ios.State{}), nil
+	case "NX-OS":
+		return cisco.Setup(&nxos.State{}), nil
 	case "Checkpoint":
-		result = &checkpoint.State{}
+		return &checkpoint.State{}, nil
 	case "Linux":
-		result = &linux.State{}
+		return &linux.State{}, nil
 	case "NSX":
-		result = &nsx.State{}
+		return &nsx.State{}, nil
 	case "PAN-OS":
-		result = &panos.State{}
-	default:
-		errlog.Abort("Unexpected model %q in file %s.info\n",
-			info.Model, fname)
+		return &panos.State{}, nil
 	}
-	return result
+	return nil, fmt.Errorf("Unexpected model %q in file %s.info",
+		info.Model, fname)
 }
 
 type state struct {
 	RealDevice
 	config   *program.Config
 	logFname string
+	log      *errlog.Logger
+	// Dialog with operator in interactive approve.
+	in  io.Reader
+	out io.Writer
+}
+
+func newState(fname string, log *errlog.Logger) (*state, error) {
+	r, err := getRealDevice(fname)
+	if err != nil {
+		return nil, err
+	}
+	r.SetLogger(log)
+	return &state{RealDevice: r, log: log}, nil
 }
 
 func ApproveOrCompare(
@@ -68,56 +97,259 @@ func ApproveOrCompare(
 	logFile string,
 	quiet bool,
 ) int {
-	return errlog.HandleAbort(func() int {
-		errlog.Quiet = quiet
-		errlog.SetStderrLog(logFile)
-		s := &state{RealDevice: getRealDevice(fname)}
-		s.config = cfg
-		if logDir != "" {
-			s.logFname = path.Join(logDir, path.Base(fname))
-		}
-		defer s.CloseConnection()
-		var err error
+	return run(fname, cfg, logDir, logFile, quiet, func(s *Session) error {
 		if isCompare {
-			err = s.compare(fname)
-		} else {
-			err = s.approve(fname)
-		}
-		if err != nil {
-			errlog.Abort("%v", err)
+			_, err := s.Compare()
+			return err
 		}
-		return 0
+		return s.Approve()
 	})
 }
 
+// CheckAccess only logs into device without reading its configuration.
+// Checked properties are printed with prefix "access:".
+func CheckAccess(
+	fname string,
+	cfg *program.Config,
+	logDir string,
+	logFile string,
+	quiet bool,
+) int {
+	return run(fname, cfg, logDir, logFile, quiet, func(s *Session) error {
+		return s.CheckAccess()
+	})
+}
+
+func run(
+	fname string,
+	cfg *program.Config,
+	logDir string,
+	logFile string,
+	quiet bool,
+	f func(*Session) error,
+) int {
+	log, err := errlog.OpenLogger(logFile, quiet)
+	if err != nil {
+		return exitStatus(nil, err)
+	}
+	defer log.Close()
+	s, err := NewSession(fname, cfg, logDir, log)
+	if err != nil {
+		return exitStatus(log, err)
+	}
+	defer s.Close()
+	s.SetOperator(os.Stdin, os.Stdout)
+	return exitStatus(log, f(s))
+}
+
+// exitStatus shows err and returns its class as exit status.
+func exitStatus(log *errlog.Logger, err error) int {
+	if err == nil {
+		return 0
+	}
+	log.Error(err)
+	return int(errlog.ClassOf(err))
+}
+
+// runFiles calls f with state for model of modelFile. No device is
+// accessed.
+func runFiles(modelFile string, quiet bool, f func(*state) error) int {
+	log := errlog.NewLogger(os.Stderr, quiet)
+	s, err := newState(modelFile, log)
+	if err == nil {
+		err = f(s)
+	}
+	return exitStatus(log, err)
+}
+
 func CompareFiles(fname1, fname2 string, quiet bool) int {
-	return errlog.HandleAbort(func() int {
-		errlog.Quiet = quiet
-		errlog.SetStderrLog("")
-		s := &state{RealDevice: getRealDevice(fname2)}
+	return runFiles(fname2, quiet, func(s *state) error {
 		if err := s.loadSpoc(fname1); err != nil {
-			errlog.Abort("%v", err)
+			return err
 		}
 		s.MoveNetspoc2DeviceConfig()
 		if err := s.loadSpoc(fname2); err != nil {
-			errlog.Abort("%v", err)
+			return err
 		}
 		if err := s.GetChanges(); err != nil {
-			errlog.Abort("%v", err)
+			return err
 		}
 		s.showCompareInfo()
 		fmt.Print(s.ShowChanges())
-		return 0
+		return nil
 	})
 }
 
-func (s *state) compare(fname string) error {
-	err := s.compareDevice(fname)
+// Trace evaluates flow against rules read from device and from
+// Netspoc and prints first matching rule of each side.
+func Trace(
+	fname string,
+	cfg *program.Config,
+	logDir string,
+	logFile string,
+	quiet bool,
+	f *ir.Flow,
+) int {
+	return run(fname, cfg, logDir, logFile, quiet, func(s *Session) error {
+		if err := s.loadSpoc(fname); err != nil {
+			return err
+		}
+		if err := s.loadDevice(fname); err != nil {
+			return err
+		}
+		s.printTrace(f)
+		return nil
+	})
+}
+
+// TraceFiles is like Trace, but reads configuration of device from
+// fname1 and configuration from Netspoc from fname2.
+func TraceFiles(fname1, fname2 string, quiet bool, f *ir.Flow) int {
+	return runFiles(fname2, quiet, func(s *state) error {
+		if err := s.loadSpoc(fname1); err != nil {
+			return err
+		}
+		s.MoveNetspoc2DeviceConfig()
+		if err := s.loadSpoc(fname2); err != nil {
+			return err
+		}
+		s.printTrace(f)
+		return nil
+	})
+}
+
+func (s *state) printTrace(f *ir.Flow) {
+	var actions [2][]ir.Action
+	for i, side := range []string{"device", "netspoc"} {
+		l := s.ExportRules(i == 0).Trace(f)
+		if l == nil {
+			fmt.Printf("%s: no rules apply\n", side)
+		}
+		for _, res := range l {
+			name := res.RuleSet.Name
+			for _, r := range res.Path {
+				name += " -> " + r.Target
+			}
+			for _, u := range res.Uncertain {
+				fmt.Printf("%s: %s: rule %d may match: %s\n",
+					side, u.RuleSet, u.Rule.Position, u.Rule.Orig)
+			}
+			switch {
+			case res.Rule != nil:
+				fmt.Printf("%s: %s: rule %d %s: %s\n",
+					side, name, res.Rule.Position, res.Action, res.Rule.Orig)
+			case res.Action != "":
+				fmt.Printf("%s: %s: default %s\n", side, name, res.Action)
+			default:
+				fmt.Printf("%s: %s: no matching rule\n", side, name)
+			}
+			actions[i] = append(actions[i], res.Action)
+		}
+	}
+	if slices.Equal(actions[0], actions[1]) {
+		fmt.Println("result: unchanged")
+	} else {
+		fmt.Println("result: changed")
+	}
+}
+
+// DumpConfig prints parsed and normalized configuration of fname as
+// JSON. fname is read like a file from Netspoc together with its
+// ipv6 and raw files. The model is taken from info file of modelFile.
+func DumpConfig(fname, modelFile string) int {
+	return dumpJSON(fname, modelFile, func(s *state) any {
+		return s.DumpNetspoc()
+	})
+}
+
+// DumpRules prints rules of fname in vendor neutral representation
+// as JSON. Files are read like in DumpConfig.
+func DumpRules(fname, modelFile string) int {
+	return dumpJSON(fname, modelFile, func(s *state) any {
+		return s.ExportRules(false)
+	})
+}
+
+func dumpJSON(fname, modelFile string, f func(*state) any) int {
+	return runFiles(modelFile, false, func(s *state) error {
+		if err := s.loadSpoc(fname); err != nil {
+			return err
+		}
+		out, err := json.MarshalIndent(f(s), "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	})
+}
+
+// LintRaw checks raw files without accessing any device.
+// Each argument is a code file from Netspoc or a directory with code
+// files. A raw file is read together with its code file, like in
+// compare and approve. If a directory is given, each raw file in this
+// directory is checked. Returns 1 if some file has errors.
+func LintRaw(args []string, quiet bool) int {
+	log := errlog.NewLogger(os.Stderr, quiet)
+	result := 0
+	for _, arg := range args {
+		l, err := rawCodeFiles(arg)
+		if err != nil {
+			log.Error(fmt.Errorf("Can't %v", err))
+			result = 1
+		}
+		for _, fname := range l {
+			log.Info("lint: %s.raw", fname)
+			if lintRaw(fname, log) != 0 {
+				result = 1
+			}
+		}
+	}
+	return result
+}
+
+// rawCodeFiles returns code files with raw file in directory p or
+// returns p itself, if it is a file. Extension ".raw" is removed.
+func rawCodeFiles(p string) ([]string, error) {
+	p = strings.TrimSuffix(p, ".raw")
+	fi, err := os.Stat(p)
 	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{p}, nil
+	}
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, e := range entries {
+		if base, found := strings.CutSuffix(e.Name(), ".raw"); found {
+			result = append(result, path.Join(p, base))
+		}
+	}
+	return result, nil
+}
+
+func lintRaw(fname string, log *errlog.Logger) int {
+	s, err := newState(fname, log)
+	if err == nil {
+		err = s.loadSpoc(fname)
+	}
+	return exitStatus(log, err)
+}
+
+func (s *state) compare(fname string) error {
+	if err := s.loadSpoc(fname); err != nil {
+		return err
+	}
+	s.analyzeRules()
+	if err := s.compareDevice(fname); err != nil {
 		return err
 	}
 	for _, w := range s.GetErrUnmanaged() {
-		errlog.Warning("%v", w)
+		s.log.Warning("%v", w)
 	}
 	s.showCompareInfo()
 	if s.logFname != "" && s.HasChanges() {
@@ -132,26 +364,151 @@ func (s *state) compare(fname string) error {
 }
 
 func (s *state) approve(fname string) error {
-	err := s.compareDevice(fname)
+	jName := s.journalName(fname)
+	prev, finished, err := journal.Read(jName)
 	if err != nil {
 		return err
 	}
+	if s.config.Resume {
+		if prev == nil || finished {
+			return fmt.Errorf("No interrupted approve found in %s", jName)
+		}
+	} else if prev != nil && !finished {
+		if !s.config.Force {
+			return fmt.Errorf("Previous approve was interrupted, see %s\n"+
+				"Use option --resume to continue"+
+				" or option --force to start new approve", jName)
+		}
+		s.log.Warning("Ignoring interrupted approve in %s", jName)
+	}
+	if err := s.loadSpoc(fname); err != nil {
+		return err
+	}
+	if err := s.compareDevice(fname); err != nil {
+		return err
+	}
 	if l := s.GetErrUnmanaged(); l != nil {
-		return l[0]
+		return errlog.Classify(errlog.Unmanaged, l[0])
+	}
+	if s.config.Resume {
+		s.reconcile(prev)
 	}
-	return s.applyCommands()
+	skipped := s.deselectCategories()
+	if s.config.Interactive && s.HasChanges() {
+		l, err := s.selectChanges(fname)
+		if err != nil {
+			return err
+		}
+		skipped = append(skipped, l...)
+	}
+	if skipped != nil {
+		s.log.Info("approve: partial, left unapplied: %s",
+			strings.Join(skipped, ", "))
+	}
+	return s.applyCommands(jName)
 }
 
-func (s *state) compareDevice(fname string) error {
-	if err := s.loadSpoc(fname); err != nil {
+// journalName returns name of file in basedir, where commands sent
+// to device during approve are recorded.
+func (s *state) journalName(fname string) string {
+	return path.Join(s.config.BaseDir, "journal", codefiles.GetHostname(fname))
+}
+
+// reconcile shows result of commands of interrupted approve.
+// Configuration of device has been read again and changes have been
+// calculated from current configuration. Hence commands that have
+// been acknowledged by device are not sent again. Commands with
+// unknown result are sent again, if still needed.
+func (s *state) reconcile(l []journal.Entry) {
+	count := 0
+	for _, e := range l {
+		switch e.State {
+		case journal.Acknowledged:
+			count++
+		case journal.Failed:
+			msg := e.Cmd
+			if e.Error != "" {
+				msg += "\n" + e.Error
+			}
+			s.log.Warning("Command of interrupted approve failed: %s", msg)
+		default:
+			s.log.Warning("Command of interrupted approve has unknown result: %s",
+				e.Cmd)
+		}
+	}
+	s.log.Info("resume: %d of %d commands of interrupted approve"+
+		" were acknowledged", count, len(l))
+}
+
+func (s *state) checkAccess(fname string) error {
+	logLogin, err := s.getLogFH(".login")
+	if err != nil {
+		return err
+	}
+	defer closeLogFH(logLogin)
+	l, err := s.CheckAccess(fname, s.config, logLogin)
+	if err != nil {
 		return err
 	}
+	report := func(msg string) { s.log.PrintWithMarker("access: ", "%s", msg) }
+	report("login succeeded")
+	for _, msg := range l {
+		report(msg)
+	}
+	for _, w := range s.GetErrUnmanaged() {
+		s.log.Warning("%v", w)
+	}
+	return nil
+}
+
+func (s *state) compareDevice(fname string) error {
 	if err := s.loadDevice(fname); err != nil {
 		return err
 	}
+	if err := s.protect(fname); err != nil {
+		return err
+	}
 	return s.GetChanges()
 }
 
+// deselectCategories leaves changes unapplied, whose category isn't
+// selected by command line options --only and --exclude.
+// Returns names of deselected categories.
+func (s *state) deselectCategories() []string {
+	var result []string
+	for _, g := range s.ChangeGroups() {
+		if !s.config.IsSelected(g.Category) {
+			s.DeselectChanges(g.Name)
+			if !slices.Contains(result, g.Category) {
+				result = append(result, g.Category)
+			}
+		}
+	}
+	return result
+}
+
+// protect leaves those parts of device configuration unchanged,
+// that are listed for this device in protect file.
+func (s *state) protect(fname string) error {
+	l, err := s.config.GetProtected(codefiles.GetHostname(fname))
+	if err != nil {
+		return err
+	}
+	return s.Protect(l)
+}
+
+// analyzeRules shows shadowed and redundant rules of merged
+// configuration from Netspoc and rules from raw file, that override
+// rules from Netspoc.
+func (s *state) analyzeRules() {
+	for _, f := range s.ExportRules(false).Analyze() {
+		s.log.Warning("%v", f)
+	}
+}
+
+// loadDevice logs into device and reads its configuration.
+// Errors without class are classified as errors in device
+// configuration.
 func (s *state) loadDevice(fname string) error {
 	logConfig, err := s.getLogFH(".config")
 	if err != nil {
@@ -163,27 +520,44 @@ func (s *state) loadDevice(fname string) error {
 		return err
 	}
 	defer closeLogFH(logLogin)
-	return s.LoadDevice(fname, s.config, logLogin, logConfig)
+	return errlog.Classify(errlog.DeviceConfig,
+		s.LoadDevice(fname, s.config, logLogin, logConfig))
 }
 
-func (s *state) applyCommands() error {
+func (s *state) applyCommands(jName string) error {
 	logFH, err := s.getLogFH(".change")
 	if err != nil {
 		return err
 	}
 	defer closeLogFH(logFH)
+	j, err := journal.Create(jName)
+	if err != nil {
+		return err
+	}
+	defer j.Close()
 	if !s.HasChanges() {
 		errlog.DoLog(logFH, "No changes applied")
-		return nil
+		return j.Finish()
 	}
-	return s.ApplyCommands(logFH)
+	// Device may already be changed partially. Hence a device that
+	// stops responding isn't reported as unreachable, because an
+	// unreachable device is retried automatically.
+	err = s.ApplyCommands(logFH, j)
+	if errlog.ClassOf(err) != errlog.CommitFailed {
+		err = errlog.Reclassify(errlog.ApplyFailed, err)
+	}
+	if err != nil {
+		j.Abort(err)
+		return err
+	}
+	return j.Finish()
 }
 
 func (s *state) showCompareInfo() {
 	if !s.HasChanges() {
-		errlog.Info("comp: device unchanged")
+		s.log.Info("comp: device unchanged")
 	} else {
-		errlog.Info("comp: *** device changed ***")
+		s.log.Info("comp: *** device changed ***")
 	}
 }
 
@@ -201,16 +575,27 @@ func (s *state) loadSpoc(v4Path string) error {
 func (s *state) loadSpocFile(fname string) error {
 	data, err := os.ReadFile(fname)
 	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return fmt.Errorf("Can't %v", err)
+		return errlog.Classify(errlog.NetspocFile, fmt.Errorf("Can't %v", err))
 	}
 	if err := s.LoadNetspoc(data, fname); err != nil {
-		return fmt.Errorf("While reading file %s: %v", path.Base(fname), err)
+		return errlog.Classify(errlog.NetspocFile,
+			fmt.Errorf("While reading file %s: %v", path.Base(fname), err))
 	}
 	return nil
 }
 
 // Set lock for exclusive approval.
 func SetLock(fname string, cfg *program.Config) (*os.File, error) {
+	return setLock(fname, cfg, syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// WaitLock waits until lock for exclusive approval is available and
+// sets it.
+func WaitLock(fname string, cfg *program.Config) (*os.File, error) {
+	return setLock(fname, cfg, syscall.LOCK_EX)
+}
+
+func setLock(fname string, cfg *program.Config, how int) (*os.File, error) {
 	lockDir := path.Join(cfg.BaseDir, "lock")
 	os.Mkdir(lockDir, 0755)
 	lockFile := path.Join(lockDir, path.Base(fname))
@@ -218,7 +603,7 @@ func SetLock(fname string, cfg *program.Config) (*os.File, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = syscall.Flock(int(fh.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	err = syscall.Flock(int(fh.Fd()), how)
 	if err != nil {
 		err = fmt.Errorf("Approve in progress for %s", fname)
 	}
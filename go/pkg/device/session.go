@@ -0,0 +1,67 @@
+package device
+
+import (
+	"io"
+	"path"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
+)
+
+// Session handles a single device, described by a code file from
+// Netspoc. Methods of Session never panic, but return errors.
+// Messages are written to logger of session. Hence several sessions
+// may run concurrently in one process.
+type Session struct {
+	*state
+	fname string
+}
+
+// NewSession prepares session for device of code file fname.
+// If logDir isn't empty, communication with device is logged to
+// files in this directory.
+func NewSession(
+	fname string,
+	cfg *program.Config,
+	logDir string,
+	log *errlog.Logger,
+) (*Session, error) {
+	s, err := newState(fname, log)
+	if err != nil {
+		return nil, err
+	}
+	s.config = cfg
+	if logDir != "" {
+		s.logFname = path.Join(logDir, path.Base(fname))
+	}
+	return &Session{state: s, fname: fname}, nil
+}
+
+// SetOperator sets input and output for dialog with operator,
+// which confirms changes in interactive approve.
+func (s *Session) SetOperator(in io.Reader, out io.Writer) {
+	s.in = in
+	s.out = out
+}
+
+// Approve applies changes from Netspoc to device.
+func (s *Session) Approve() error {
+	return s.approve(s.fname)
+}
+
+// Compare compares device with Netspoc and returns true, if device
+// would be changed by approve.
+func (s *Session) Compare() (bool, error) {
+	err := s.compare(s.fname)
+	return err == nil && s.HasChanges(), err
+}
+
+// CheckAccess only logs into device without reading its configuration.
+func (s *Session) CheckAccess() error {
+	return s.checkAccess(s.fname)
+}
+
+// Close closes connection to device.
+func (s *Session) Close() {
+	s.CloseConnection()
+}
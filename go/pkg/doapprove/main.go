@@ -2,13 +2,17 @@ package doapprove
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"os/user"
 	"path"
 	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/device"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/mytime"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/status"
@@ -20,11 +24,19 @@ func Main() int {
 	// Setup custom usage function.
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr,
-			"Usage: %s [options] approve|compare DEVICE\n%s",
+			"Usage: %s [options] approve|compare|check-access DEVICE\n%s",
 			os.Args[0], fs.FlagUsages())
 	}
 	brief := fs.BoolP("brief", "b", false,
 		"Suppress message about unreachable device")
+	only := fs.StringSlice("only", nil,
+		"Approve only changes of comma separated `CATEGORIES`")
+	exclude := fs.StringSlice("exclude", nil,
+		"Leave changes of comma separated `CATEGORIES` unapplied")
+	resume := fs.Bool("resume", false,
+		"Continue interrupted approve, show commands from journal")
+	force := fs.Bool("force", false,
+		"Approve, even if previous approve was interrupted")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		if err == pflag.ErrHelp {
 			return 1
@@ -40,58 +52,148 @@ func Main() int {
 	}
 	action := args[0]
 	devName := args[1]
-
+	if action != "approve" &&
+		(*only != nil || *exclude != nil || *resume || *force) ||
+		*resume && *force {
+		fs.Usage()
+		return 1
+	}
 	// Load config file 'netspoc-approve'.
 	cfg, err := program.LoadConfig()
 	if err != nil {
-		return abort("%v", err)
+		return abort(os.Stderr, "%v", err)
+	}
+	switch action {
+	case "compare", "approve", "check-access":
+	default:
+		fs.Usage()
+		return 1
+	}
+	return Run(cfg, &Job{
+		Action:  action,
+		Device:  devName,
+		Only:    *only,
+		Exclude: *exclude,
+		Resume:  *resume,
+		Force:   *force,
+		Brief:   *brief,
+		Args:    os.Args[1:],
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	})
+}
+
+// Job describes a single compare, approve or check-access of device.
+type Job struct {
+	Action  string
+	Device  string
+	Only    []string
+	Exclude []string
+	Resume  bool
+	Force   bool
+	Brief   bool
+	// Authenticated user and its groups, if job isn't started by
+	// command line.
+	User   string
+	Groups []string
+	// Arguments of job, recorded in history.
+	Args []string
+	// Results are written to Stdout and Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+	// If set, messages of device log are copied to Progress while job
+	// is running.
+	Progress io.Writer
+	// If set, job waits while device is locked by other process and
+	// Locked is called after lock has been acquired.
+	// Otherwise job fails if device is locked.
+	Locked func()
+}
+
+// Run runs job on device of current policy, like do-approve.
+// Returns exit status.
+func Run(c *program.Config, j *Job) int {
+	cfg := *c
+	cfg.Only = j.Only
+	cfg.Exclude = j.Exclude
+	cfg.Resume = j.Resume
+	cfg.Force = j.Force
+	if err := cfg.CheckCategories(); err != nil {
+		return abort(j.Stderr, "%v", err)
 	}
 	// Get directory of current policy.
 	policies := path.Join(cfg.BaseDir, "policies")
 	dir, err := filepath.EvalSymlinks(path.Join(policies, "current"))
 	if err != nil {
-		return abort("Can't get 'current' policy directory: %v", err)
+		return abort(j.Stderr, "Can't get 'current' policy directory: %v", err)
 	}
 	policy := filepath.Base(dir)
 
+	devName := j.Device
 	codeFile := path.Join(dir, "code", devName)
 	code6File := path.Join(dir, "code/ipv6", devName)
 	if !(fileExists(codeFile) || fileExists(code6File)) {
-		return abort("unknown device %q", devName)
+		return abort(j.Stderr, "unknown device %q", devName)
 	}
 
 	// Get arguments and run approve / compare.
 	logDir := path.Join(dir, "log")
 	logFile := path.Join(logDir, devName)
-	isCompare := action == "compare"
-	switch action {
+	isCompare := j.Action == "compare"
+	isAccess := j.Action == "check-access"
+	switch j.Action {
 	case "compare":
 		logFile += ".compare"
 	case "approve":
 		logFile += ".drc"
+	case "check-access":
+		logFile += ".access"
 	default:
-		fs.Usage()
-		return 1
+		return abort(j.Stderr, "unknown action %q", j.Action)
+	}
+	// Option --force needs permission of its own.
+	authAction := j.Action
+	if j.Force {
+		authAction = "force"
 	}
-	lockFH, err := device.SetLock(devName, cfg)
+	userName, groups := j.userName(), j.Groups
+	if j.User == "" {
+		groups = program.UnixGroups(userName)
+	}
+	err = cfg.Authorize(userName, groups, devName, authAction)
+	if err != nil {
+		// Record denied attempt.
+		if hLog, err2 := openHistoryLog(&cfg, devName); err2 == nil {
+			j.logStart(hLog)
+			logHistory(hLog, "DENIED:", err)
+			logHistory(hLog, "END:", "DENIED")
+			hLog.Close()
+		}
+		return abort(j.Stderr, "%v", err)
+	}
+	setLock := device.SetLock
+	if j.Locked != nil {
+		setLock = device.WaitLock
+	}
+	lockFH, err := setLock(devName, &cfg)
 	if lockFH != nil {
 		defer lockFH.Close()
 	}
 	if err != nil {
-		return abort("%v", err)
+		return abort(j.Stderr, "%v", err)
 	}
-	hLog, err := openHistoryLog(cfg, devName)
-	if err != nil {
-		return abort("can't %v", err)
+	if j.Locked != nil {
+		j.Locked()
 	}
-	logHistory(hLog, "START:", strings.Join(os.Args[1:], " "))
-	if u := os.Getenv("SUDO_USER"); u != "" {
-		logHistory(hLog, "SUDO_USER:", u)
+	hLog, err := openHistoryLog(&cfg, devName)
+	if err != nil {
+		return abort(j.Stderr, "can't %v", err)
 	}
+	defer hLog.Close()
+	j.logStart(hLog)
 	logHistory(hLog, "POLICY:", policy)
-	var warnings, errors, changed, failed bool
-	stat := device.ApproveOrCompare(
-		isCompare, codeFile, cfg, logDir, logFile, false)
+	var warnings, errors, changed, partial, failed bool
+	stat, runErr := runDevice(&cfg, j, codeFile, logDir, logFile)
 	if stat != 0 {
 		failed = true
 		errors = true
@@ -100,7 +202,17 @@ func Main() int {
 	// Check result and print errors messages.
 	data, err := os.ReadFile(logFile)
 	if err != nil {
-		return abort("can't %v", err)
+		return abort(j.Stderr, "can't %v", err)
+	}
+	// Unreachable device is the relevant result of check-access.
+	// Otherwise suppress only message about unreachable device with
+	// option --brief.
+	unreachable := make(map[string]bool)
+	if j.Brief && !isAccess && errlog.ClassOf(runErr) == errlog.Unreachable {
+		msg := strings.TrimSuffix(runErr.Error(), "\n")
+		for _, l := range strings.Split(msg, "\n") {
+			unreachable["ERROR>>> "+l] = true
+		}
 	}
 	lines := strings.Split(string(data), "\n")
 	for _, ln := range lines {
@@ -110,43 +222,117 @@ func Main() int {
 			warnings = true
 		} else if strings.HasPrefix(ln, "comp: ***") {
 			changed = true
+		} else if strings.HasPrefix(ln, "approve: partial") {
+			partial = true
+		} else if isAccess && strings.HasPrefix(ln, "access:") {
+			if j.Brief {
+				continue
+			}
 		} else {
 			continue
 		}
-		if *brief {
-			isTimeout :=
-				strings.HasPrefix(ln, "ERROR>>> while waiting for login prompt") &&
-					strings.Contains(ln, "timer expired")
-			if !isTimeout {
-				fmt.Printf("%s:%s\n", devName, ln)
+		if j.Brief {
+			if !unreachable[ln] {
+				fmt.Fprintf(j.Stdout, "%s:%s\n", devName, ln)
 			}
 		} else {
-			fmt.Println(ln)
+			fmt.Fprintln(j.Stdout, ln)
 		}
 		logHistory(hLog, "RES:", ln)
 	}
 
 	// Update status file.
 	if isCompare {
-		status.SetCompare(cfg, devName, policy, changed || errors)
-	} else {
-		status.SetApprove(cfg, devName, policy, failed)
+		err = status.SetCompare(
+			&cfg, devName, policy, changed || errors, errlog.Class(stat))
+	} else if !isAccess {
+		err = status.SetApprove(
+			&cfg, devName, policy, errlog.Class(stat), partial)
+	}
+	if err != nil {
+		return abort(j.Stderr, "can't %v", err)
 	}
 
 	okMsg := "OK"
 	if failed {
 		okMsg = "FAILED"
 	}
-	if !*brief && (failed || warnings || errors || changed) {
-		fmt.Fprintf(os.Stderr, "%s, details in %s\n", okMsg, logFile)
+	if !j.Brief && (failed || warnings || errors || changed || partial) {
+		fmt.Fprintf(j.Stderr, "%s, details in %s\n", okMsg, logFile)
 	}
 
 	logHistory(hLog, "END:", okMsg)
 
-	if failed {
-		return 1
-	} else {
-		return 0
+	return stat
+}
+
+// runDevice runs job on device and writes messages to logFile.
+// Returns exit status and error written to logFile.
+func runDevice(
+	cfg *program.Config, j *Job, codeFile, logDir, logFile string,
+) (int, error) {
+	log, err := errlog.OpenLogger(logFile, false)
+	if err != nil {
+		return abort(j.Stderr, "%v", err), nil
+	}
+	defer log.Close()
+	if j.Progress != nil {
+		log.AddWriter(j.Progress)
+	}
+	s, err := device.NewSession(codeFile, cfg, logDir, log)
+	if err == nil {
+		defer s.Close()
+		switch j.Action {
+		case "compare":
+			_, err = s.Compare()
+		case "approve":
+			err = s.Approve()
+		case "check-access":
+			err = s.CheckAccess()
+		}
+	}
+	if err != nil {
+		log.Error(err)
+		return int(errlog.ClassOf(err)), err
+	}
+	return 0, nil
+}
+
+// userName returns name of user, who started job.
+func (j *Job) userName() string {
+	if j.User != "" {
+		return j.User
+	}
+	if u := sudoUser(); u != "" {
+		return u
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// sudoUser returns value of SUDO_USER, if program has been started
+// by sudo. We only trust SUDO_USER if program runs as root or as other
+// user than the calling user. Otherwise SUDO_USER could have been
+// set by calling user to impersonate someone else.
+func sudoUser() string {
+	u := os.Getenv("SUDO_USER")
+	if u == "" || os.Geteuid() == 0 {
+		return u
+	}
+	if id := os.Getenv("SUDO_UID"); id != "" && id != strconv.Itoa(os.Getuid()) {
+		return u
+	}
+	return ""
+}
+
+func (j *Job) logStart(hLog *os.File) {
+	logHistory(hLog, "START:", strings.Join(j.Args, " "))
+	if j.User != "" {
+		logHistory(hLog, "USER:", j.User)
+	} else if u := sudoUser(); u != "" {
+		logHistory(hLog, "SUDO_USER:", u)
 	}
 }
 
@@ -171,7 +357,7 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-func abort(format string, args ...any) int {
-	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+func abort(w io.Writer, format string, args ...any) int {
+	fmt.Fprintf(w, "Error: "+format+"\n", args...)
 	return 1
 }
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/device"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 	"github.com/spf13/pflag"
+	"golang.org/x/term"
 )
 
 var version = "devel"
@@ -20,16 +23,45 @@ func Main() int {
 		prog := path.Base(os.Args[0])
 		fmt.Fprintf(os.Stderr,
 			"Usage: %s [options] FILE1\n"+
-				"     : %s [-q] FILE1 FILE2\n", prog, prog)
+				"     : %s [-q] [--trace FLOW] FILE1 FILE2\n"+
+				"     : %s --dump|--rules FILE1 [FILE2]\n"+
+				"     : %s [-q] --lint FILE|DIR ...\n", prog, prog, prog, prog)
 		fs.PrintDefaults()
 	}
 
 	// Command line flags
 	isCompare := fs.BoolP("compare", "C", false, "Compare only")
+	checkAccess := fs.BoolP("check-access", "A", false,
+		"Only check login to device, don't read its configuration")
 	logDir := fs.StringP("logdir", "L", "", "Path for saving session logs")
 	logFile := fs.StringP("LOGFILE", "", "", "Path to redirect STDERR")
 	user := fs.StringP("user", "u", "", "Username for login to remote device")
 	quiet := fs.BoolP("quiet", "q", false, "No info messages")
+	interactive := fs.BoolP("interactive", "i",
+		term.IsTerminal(int(os.Stdin.Fd())),
+		"Show changes and ask for confirmation before approve,\n"+
+			"default if STDIN is a terminal")
+	only := fs.StringSlice("only", nil,
+		"Approve only changes of comma separated `CATEGORIES`,\n"+
+			"from "+strings.Join(program.ChangeCategories, ", "))
+	exclude := fs.StringSlice("exclude", nil,
+		"Leave changes of comma separated `CATEGORIES` unapplied")
+	resume := fs.Bool("resume", false,
+		"Continue interrupted approve, show commands from journal")
+	force := fs.Bool("force", false,
+		"Approve, even if previous approve was interrupted")
+	dump := fs.BoolP("dump", "D", false,
+		"Print parsed config of FILE1 as JSON,\n"+
+			"take model from info file of FILE2 if given")
+	rules := fs.BoolP("rules", "R", false,
+		"Print rules of FILE1 in vendor neutral format as JSON,\n"+
+			"take model from info file of FILE2 if given")
+	trace := fs.StringP("trace", "T", "",
+		"Show first rule matching `FLOW` on device and from Netspoc,\n"+
+			"FLOW is given as \"SRC DST PROTO[:PORT] [IN[:OUT]]\"")
+	lint := fs.BoolP("lint", "l", false,
+		"Check raw file of FILE or raw files of all devices in DIR,\n"+
+			"don't access any device")
 	showVer := fs.BoolP("version", "v", false, "Show version")
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		if err == pflag.ErrHelp {
@@ -46,6 +78,39 @@ func Main() int {
 
 	// Argument processing
 	args := fs.Args()
+	var flow *ir.Flow
+	if *trace != "" {
+		f, err := ir.ParseFlow(*trace)
+		if err != nil {
+			return abort("%v", err)
+		}
+		flow = f
+	}
+	if *lint {
+		n := fs.NFlag()
+		if fs.Changed("quiet") {
+			n--
+		}
+		if n > 1 || len(args) == 0 {
+			fs.Usage()
+			return 1
+		}
+		return device.LintRaw(args, *quiet)
+	}
+	if *dump || *rules {
+		n := fs.NFlag()
+		if fs.Changed("quiet") {
+			n--
+		}
+		if n > 1 || len(args) == 0 || len(args) > 2 {
+			fs.Usage()
+			return 1
+		}
+		if *rules {
+			return device.DumpRules(args[0], args[len(args)-1])
+		}
+		return device.DumpConfig(args[0], args[len(args)-1])
+	}
 	switch len(args) {
 	case 0:
 		fallthrough
@@ -53,26 +118,58 @@ func Main() int {
 		fs.Usage()
 		return 1
 	case 1:
+		if flow != nil && (*isCompare || *checkAccess) {
+			fs.Usage()
+			return 1
+		}
+		if (*only != nil || *exclude != nil || *resume || *force) &&
+			(*isCompare || *checkAccess || flow != nil) ||
+			*resume && *force {
+			fs.Usage()
+			return 1
+		}
 		cfg, err := program.LoadConfig()
 		if err != nil {
 			return abort("%v", err)
 		}
 		cfg.User = *user
+		cfg.Interactive = *interactive
+		cfg.Only = *only
+		cfg.Exclude = *exclude
+		cfg.Resume = *resume
+		cfg.Force = *force
+		if err := cfg.CheckCategories(); err != nil {
+			return abort("%v", err)
+		}
 		fname := args[0]
+		if *checkAccess {
+			return device.CheckAccess(fname, cfg, *logDir, *logFile, *quiet)
+		}
 		lockFH, err := device.SetLock(fname, cfg)
 		if err != nil {
 			return abort("%v", err)
 		}
 		defer lockFH.Close()
+		if flow != nil {
+			return device.Trace(fname, cfg, *logDir, *logFile, *quiet, flow)
+		}
 		return device.ApproveOrCompare(
 			*isCompare, fname, cfg, *logDir, *logFile, *quiet)
 	case 2:
-		q := fs.Changed("quiet")
 		n := fs.NFlag()
-		if q && n > 1 || !q && n > 0 {
+		if fs.Changed("quiet") {
+			n--
+		}
+		if flow != nil {
+			n--
+		}
+		if n > 0 {
 			fs.Usage()
 			return 1
 		}
+		if flow != nil {
+			return device.TraceFiles(args[0], args[1], *quiet, flow)
+		}
 		return device.CompareFiles(args[0], args[1], *quiet)
 	}
 }
@@ -0,0 +1,90 @@
+package errlog
+
+import (
+	"errors"
+)
+
+// Class of error. Its value is used as exit status of commands
+// 'drc' and 'do-approve' and its name is recorded in status file.
+//
+//	1 other: any other error, e.g. invalid command line
+//	2 unreachable: device doesn't respond
+//	3 authentication: login or enable mode failed
+//	4 device-name: device has wrong name
+//	5 unmanaged: device isn't marked as managed by Netspoc
+//	6 netspoc-file: error in file from Netspoc or in raw file
+//	7 device-config: error in configuration read from device
+//	8 apply: device rejected change
+//	9 commit: saving, committing, publishing or installing failed
+type Class int
+
+const (
+	Other Class = iota + 1
+	Unreachable
+	AuthFailed
+	WrongName
+	Unmanaged
+	NetspocFile
+	DeviceConfig
+	ApplyFailed
+	CommitFailed
+)
+
+var className = map[Class]string{
+	Other:        "other",
+	Unreachable:  "unreachable",
+	AuthFailed:   "authentication",
+	WrongName:    "device-name",
+	Unmanaged:    "unmanaged",
+	NetspocFile:  "netspoc-file",
+	DeviceConfig: "device-config",
+	ApplyFailed:  "apply",
+	CommitFailed: "commit",
+}
+
+func (c Class) String() string {
+	return className[c]
+}
+
+// ClassError is an error together with its class.
+type ClassError struct {
+	Class Class
+	Err   error
+}
+
+func (e *ClassError) Error() string { return e.Err.Error() }
+func (e *ClassError) Unwrap() error { return e.Err }
+
+// Classify adds class to err. An error that already has a class is
+// returned unchanged.
+func Classify(c Class, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ce *ClassError
+	if errors.As(err, &ce) {
+		return err
+	}
+	return &ClassError{c, err}
+}
+
+// Reclassify sets class of err to c, replacing an existing class.
+func Reclassify(c Class, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ce *ClassError
+	if errors.As(err, &ce) {
+		err = ce.Err
+	}
+	return &ClassError{c, err}
+}
+
+// ClassOf returns class of err or Other if err has no class.
+func ClassOf(err error) Class {
+	var ce *ClassError
+	if errors.As(err, &ce) {
+		return ce.Class
+	}
+	return Other
+}
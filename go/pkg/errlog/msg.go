@@ -2,6 +2,7 @@ package errlog
 
 import (
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path"
@@ -10,46 +11,80 @@ import (
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/mytime"
 )
 
-var Quiet bool
+// Logger writes messages of a single session with a device.
+// A nil Logger writes to STDERR.
+type Logger struct {
+	w     io.Writer
+	fh    *os.File
+	quiet bool
+}
+
+// NewLogger returns Logger writing to w.
+// Info messages are suppressed, if quiet is set.
+func NewLogger(w io.Writer, quiet bool) *Logger {
+	return &Logger{w: w, quiet: quiet}
+}
 
-func Info(format string, args ...any) {
-	if !Quiet {
-		fmt.Fprintf(stderrLog, format+"\n", args...)
+// OpenLogger returns Logger writing to file fname or to STDERR
+// if fname is empty. An existing file is renamed.
+func OpenLogger(fname string, quiet bool) (*Logger, error) {
+	if fname == "" {
+		return NewLogger(os.Stderr, quiet), nil
+	}
+	MoveLogFile(fname)
+	fh, err := CreateWithPath(fname)
+	if err != nil {
+		return nil, fmt.Errorf("Can't %v", err)
 	}
+	return &Logger{w: fh, fh: fh, quiet: quiet}, nil
 }
 
-func Warning(format string, args ...any) {
-	PrintWithMarker("WARNING>>> ", format, args...)
+// AddWriter lets l write messages to w in addition.
+func (l *Logger) AddWriter(w io.Writer) {
+	l.w = io.MultiWriter(l.w, w)
 }
 
-func DoLog(fh *os.File, s string) {
-	if fh != nil {
-		if strings.HasPrefix(s, "http") || strings.HasPrefix(s, "DATA: ") {
-			s, _ = url.QueryUnescape(s)
-		}
-		fmt.Fprintln(fh, s)
+func (l *Logger) Close() {
+	if l != nil && l.fh != nil {
+		l.fh.Close()
 	}
 }
 
-var stderrLog *os.File
-
-func SetStderrLog(fname string) {
-	stderrLog = os.Stderr
-	if fname != "" {
-		MoveLogFile(fname)
-		fh, err := CreateWithPath(fname)
-		if err != nil {
-			Abort("Can't %v", err)
-		}
-		stderrLog = fh
+func (l *Logger) Info(format string, args ...any) {
+	if l == nil || !l.quiet {
+		fmt.Fprintf(l.writer(), format+"\n", args...)
 	}
 }
 
-func PrintWithMarker(m string, format string, args ...any) {
+func (l *Logger) Warning(format string, args ...any) {
+	l.PrintWithMarker("WARNING>>> ", format, args...)
+}
+
+func (l *Logger) Error(err error) {
+	l.PrintWithMarker("ERROR>>> ", "%v", err)
+}
+
+func (l *Logger) PrintWithMarker(m string, format string, args ...any) {
 	out := fmt.Sprintf(format, args...)
 	out = strings.TrimSuffix(out, "\n")
 	out = strings.ReplaceAll(out, "\n", "\n"+m)
-	fmt.Fprintln(stderrLog, m+out)
+	fmt.Fprintln(l.writer(), m+out)
+}
+
+func (l *Logger) writer() io.Writer {
+	if l == nil {
+		return os.Stderr
+	}
+	return l.w
+}
+
+func DoLog(fh *os.File, s string) {
+	if fh != nil {
+		if strings.HasPrefix(s, "http") || strings.HasPrefix(s, "DATA: ") {
+			s, _ = url.QueryUnescape(s)
+		}
+		fmt.Fprintln(fh, s)
+	}
 }
 
 // Rename existing logfile.
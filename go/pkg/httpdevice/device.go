@@ -35,6 +35,7 @@ func GetHTTPClient(cfg *program.Config, ip string) (*http.Client, string) {
 func TryReachableHTTPLogin(
 	fname string,
 	cfg *program.Config,
+	log *errlog.Logger,
 	login func(name, ip, user, pass string) error,
 ) error {
 
@@ -42,6 +43,7 @@ func TryReachableHTTPLogin(
 	if err != nil {
 		return err
 	}
+	class := errlog.Unreachable
 	for i, name := range nameList {
 		ip := ipList[i]
 		user, pass, err := cfg.GetUserPass(name)
@@ -49,17 +51,34 @@ func TryReachableHTTPLogin(
 			return err
 		}
 		if err := login(name, ip, user, pass); err != nil {
-			errlog.Warning("%v", err)
+			log.Warning("%v", err)
+			if errlog.ClassOf(err) == errlog.AuthFailed {
+				class = errlog.AuthFailed
+			}
 			continue
 		}
 		return nil
 	}
-	return fmt.Errorf(
-		"Devices unreachable: %s", strings.Join(nameList, ", "))
+	return errlog.Classify(class, fmt.Errorf(
+		"Devices unreachable: %s", strings.Join(nameList, ", ")))
+}
+
+// LoginStatusError returns error for unexpected HTTP status code
+// of login request. Status 401 and 403 denote failed authentication.
+func LoginStatusError(code int) error {
+	err := fmt.Errorf("status code: %d", code)
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errlog.Classify(errlog.AuthFailed, err)
+	}
+	return err
 }
 
 func getHostnameIPList(path string) ([]string, []string, error) {
-	info, checked := codefiles.LoadInfoFile(path)
+	info, checked, err := codefiles.LoadInfoFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
 	nameList := info.NameList
 	ipList := info.IPList
 	if len(nameList) == 0 {
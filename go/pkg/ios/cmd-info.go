@@ -31,12 +31,52 @@ interface *
  shutdown
  ip access-group $ip_access-list_extended in
  ip access-group $ip_access-list_extended out
+ ipv6 traffic-filter $ipv6_access-list in
+ ipv6 traffic-filter $ipv6_access-list out
  ip inspect *
  # 'vrf forwarding' is used if IPv6 is enabled.
  vrf forwarding *
  ip vrf forwarding *
  crypto map $crypto_map
+ zone-member security $zone_security
+ tunnel source *
+ tunnel destination *
+ tunnel mode *
+ tunnel protection ipsec profile $crypto_ipsec_profile
 
+[ANCHOR,FIXED_NAME]
+# Zone-based firewall.
+# * holds source and destination zone. It doesn't reference
+# $zone_security, because built-in zone "self" isn't shown in config.
+zone-pair_security $NAME *
+ description *
+ service-policy type inspect $policy-map_type_inspect
+
+[FIXED_NAME]
+zone_security $NAME
+ description *
+
+# Action of class, given as one or more sub-subcommands, is joined to
+# command "class ..." during parsing.
+policy-map_type_inspect $NAME
+ class type inspect $class-map_type_inspect
+ class class-default
+
+class-map_type_inspect match-any $NAME
+ match access-group name $ip_access-list_extended
+ match protocol *
+ match class-map $class-map_type_inspect
+class-map_type_inspect match-all $NAME
+ match access-group name $ip_access-list_extended
+ match protocol *
+ match class-map $class-map_type_inspect
+
+object-group network $NAME
+ *
+object-group service $NAME
+ *
+
+# * may reference up to three $object-group, will be resolved later.
 ip_access-list_extended $NAME
  remark *
  permit *
@@ -45,10 +85,46 @@ ip_access-list_extended $NAME
  $SEQ permit *
  $SEQ deny *
 
+# Sequence number is shown at end of line as "sequence N" on device.
+ipv6_access-list $NAME
+ remark *
+ permit *
+ deny *
+ sequence $SEQ remark *
+ sequence $SEQ permit *
+ sequence $SEQ deny *
+
+# * of "set transform-set" references one or more
+# $crypto_ipsec_transform-set, will be resolved later.
 crypto_map $NAME $SEQ ipsec-isakmp
+ match address $ip_access-list_extended
  set ip access-group $ip_access-list_extended in
  set ip access-group $ip_access-list_extended out
  set peer *
-# match address $ip_access-list_extended # Currently configured manually
+ set transform-set *
+ set isakmp-profile $crypto_isakmp_profile
+ set ikev2-profile $crypto_ikev2_profile
+ set pfs *
+ set security-association lifetime *
+ set reverse-route
 crypto_map $NAME $SEQ gdoi
+
+[FIXED_NAME]
+# Profile references keyring with pre-shared key.
+# Hence it is transferred manually and only referenced by name.
+# Subcommands are ignored.
+crypto_isakmp_profile $NAME
+crypto_ikev2_profile $NAME
+
+[SIMPLE_OBJ]
+crypto_ipsec_transform-set $NAME *
+ mode *
+# * of "set transform-set" references one or more
+# $crypto_ipsec_transform-set, will be resolved later.
+crypto_ipsec_profile $NAME
+ set transform-set *
+ set ikev2-profile $crypto_ikev2_profile
+ set isakmp-profile $crypto_isakmp_profile
+ set pfs *
+ set security-association lifetime *
 `
@@ -2,6 +2,7 @@ package ios
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
@@ -10,39 +11,62 @@ import (
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/console"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
 type State struct {
 	reloadActive bool
+	log          *errlog.Logger
 }
 
-func (s *State) SetTerminal(conn *console.Conn) {
-	conn.SendCmd("term len 0")
-	conn.SendCmd("term width 512")
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
+
+func (s *State) SetTerminal(conn *console.Conn) error {
+	if err := conn.SendCmd("term len 0"); err != nil {
+		return err
+	}
+	return conn.SendCmd("term width 512")
 }
 
-func (s *State) CheckDeviceName(name string, conn *console.Conn) {
+func (s *State) CheckDeviceName(name, _ string, conn *console.Conn) error {
 	// Force new prompt by issuing empty command.
 	// Output is: \r\n\s*NAME#\s?
-	out := strings.TrimSpace(conn.IssueCmd("", `#[ ]?`))
-	out = strings.TrimSuffix(out, "#")
+	out, err := conn.IssueCmd("", `#[ ]?`)
+	if err != nil {
+		return err
+	}
+	out = strings.TrimSuffix(strings.TrimSpace(out), "#")
 	if name != out {
-		errlog.Abort("Wrong device name: %q, expected: %q", out, name)
+		return errlog.Classify(errlog.WrongName,
+			fmt.Errorf("Wrong device name: %q, expected: %q", out, name))
 	}
+	return nil
 }
 
-func (s *State) PrepareDevice(conn *console.Conn) {
-	conn.SendCmd("configure terminal")
-	// Don't slow down the system by logging to console.
-	conn.SendCmd("no logging console")
-	// Enable logging synchronous to get a fresh prompt after
-	// a reload banner is shown.
-	conn.SendCmd("line vty 0 15")
-	conn.SendCmd("logging synchronous level all")
-	// Needed for default route to work as expected.
-	conn.SendCmd("ip subnet-zero")
-	conn.SendCmd("ip classless")
-	conn.SendCmd("end")
+// HA state isn't checked.
+func (s *State) CheckFailover(conn *console.Conn) (string, error) {
+	return "", nil
+}
+
+func (s *State) PrepareDevice(conn *console.Conn) error {
+	for _, cmd := range []string{
+		"configure terminal",
+		// Don't slow down the system by logging to console.
+		"no logging console",
+		// Enable logging synchronous to get a fresh prompt after
+		// a reload banner is shown.
+		"line vty 0 15",
+		"logging synchronous level all",
+		// Needed for default route to work as expected.
+		"ip subnet-zero",
+		"ip classless",
+		"end",
+	} {
+		if err := conn.SendCmd(cmd); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Output of "write mem":
@@ -62,19 +86,24 @@ func (s *State) PrepareDevice(conn *console.Conn) {
 // startup-config file open failed (Device or resource busy)
 // In this case we retry the command up to three times.
 
-func (s *State) WriteMem(conn *console.Conn) {
+func (s *State) WriteMem(conn *console.Conn) error {
 	retries := 2
 	sleepTime := 3 * time.Second
 	if os.Getenv("SIMULATE_ROUTER") != "" {
 		sleepTime = 10 * time.Millisecond
 	}
 	for {
-		out := conn.IssueCmd("write memory", `#[ ]?|\[confirm\]`)
+		out, err := conn.IssueCmd("write memory", `#[ ]?|\[confirm\]`)
+		if err != nil {
+			return err
+		}
 		if strings.Contains(out, "Overwrite the previous NVRAM configuration") {
-			out = conn.GetCmdOutput("")
+			if out, err = conn.GetCmdOutput(""); err != nil {
+				return err
+			}
 		}
 		if strings.Contains(out, "[OK]") {
-			return
+			return nil
 		}
 		if strings.Contains(out, "startup-config file open failed") {
 			if retries > 0 {
@@ -82,9 +111,10 @@ func (s *State) WriteMem(conn *console.Conn) {
 				time.Sleep(sleepTime)
 				continue
 			}
-			errlog.Abort("write mem: startup-config open failed - giving up")
+			return errors.New(
+				"write mem: startup-config open failed - giving up")
 		}
-		errlog.Abort("write mem: unexpected result: %s", out)
+		return fmt.Errorf("write mem: unexpected result: %s", out)
 	}
 }
 
@@ -97,7 +127,7 @@ func (s *State) IsValidOutput(cmd, out string) bool {
 			continue
 		}
 		if strings.HasPrefix(line, "WARNING:") {
-			errlog.Warning("Got unexpected output from '%s':\n%s", cmd, line)
+			s.log.Warning("Got unexpected output from '%s':\n%s", cmd, line)
 			continue
 		}
 		return false
@@ -107,42 +137,62 @@ func (s *State) IsValidOutput(cmd, out string) bool {
 
 const reloadMinutes = 2
 
-func (s *State) ScheduleReload(conn *console.Conn) {
-	s.sendReloadCmd(false, conn)
+func (s *State) ScheduleReload(conn *console.Conn, _ *program.Config,
+) error {
+	return s.sendReloadCmd(false, conn)
 }
 
-func (s *State) ExtendReload(conn *console.Conn) {
-	s.sendReloadCmd(true, conn)
+func (s *State) ExtendReload(conn *console.Conn) error {
+	return s.sendReloadCmd(true, conn)
 }
 
-func (s *State) sendReloadCmd(withDo bool, conn *console.Conn) {
+func (s *State) sendReloadCmd(withDo bool, conn *console.Conn) error {
 	cmd := fmt.Sprintf("reload in %d", reloadMinutes)
 	if withDo {
 		cmd = "do " + cmd
 	}
-	out := conn.IssueCmd(cmd, `\[yes\/no\]:\ |\[confirm\]`)
+	out, err := conn.IssueCmd(cmd, `\[yes\/no\]:\ |\[confirm\]`)
+	if err != nil {
+		return err
+	}
 	// System configuration has been modified. Save? [yes/no]:
 	if strings.Contains(out, "[yes/no]") {
 		// Leave our changes unsaved, to be sure that a reload
 		// gets last good configuration.
-		conn.IssueCmd("n", `\[confirm\]`)
+		if _, err := conn.IssueCmd("n", `\[confirm\]`); err != nil {
+			return err
+		}
 	}
 	// Confirm the reload with empty command, wait for the standard prompt.
 	s.reloadActive = true
-	conn.SendCmd("")
+	return conn.SendCmd("")
 }
 
-func (s *State) CancelReload(conn *console.Conn) {
+func (s *State) CancelReload(conn *console.Conn) error {
 	// Don't wait for standard prompt, but for banner message, which is
 	// sent asynchronously.
-	conn.IssueCmd("reload cancel", `--- SHUTDOWN ABORTED ---`)
+	_, err := conn.IssueCmd("reload cancel", `--- SHUTDOWN ABORTED ---`)
+	if err != nil {
+		return err
+	}
 	// Because of 'logging synchronous' we are sure to get another prompt.
-	conn.WaitShort(`[#] ?$`)
+	if _, err := conn.WaitShort(`[#] ?$`); err != nil {
+		return err
+	}
 	// Synchronize expect buffers with empty command.
-	conn.SendCmd("")
+	if err := conn.SendCmd(""); err != nil {
+		return err
+	}
 	s.reloadActive = false
+	return nil
 }
 
+// Changes are left unsaved in running config, if some command fails.
+func (s *State) Rollback(conn *console.Conn) {}
+
+// Scheduled reload restores configuration, if connection is lost.
+func (s *State) ReconnectOnLoss() bool { return false }
+
 /*
 Remove banner message from command output and
 check if a renewal of running reload process is needed.
@@ -163,7 +213,7 @@ This message is shown some time before the actual reload takes place:
 var bannerRe = regexp.MustCompile(`\n\n\n\x07[*]{3}\n[*]{3}([^\n]+)\n[*]{3}\n`)
 
 func (s *State) StripReloadBanner(out string, conn *console.Conn,
-) (string, bool) {
+) (string, bool, error) {
 	if s.reloadActive {
 		// Find message inside banner.
 		if l := bannerRe.FindStringSubmatchIndex(out); l != nil {
@@ -175,22 +225,27 @@ func (s *State) StripReloadBanner(out string, conn *console.Conn,
 				// Because of 'logging synchronous' we are sure to get another prompt
 				// if the banner is the only output before current prompt.
 				// Read next prompt.
-				errlog.Info("Found banner before output, expecting another prompt")
-				out = conn.WaitShort(`[#] ?$`)
-				out = conn.StripStdPrompt(out)
+				s.log.Info("Found banner before output, expecting another prompt")
+				var err error
+				if out, err = conn.WaitShort(`[#] ?$`); err != nil {
+					return "", false, err
+				}
+				if out, err = conn.StripStdPrompt(out); err != nil {
+					return "", false, err
+				}
 			} else if prefix != "" && strings.TrimSpace(postfix) == "" {
 				// Try to read another prompt if banner is shown directly
 				// behind current output.
-				errlog.Info("Found banner after output, checking another prompt")
+				s.log.Info("Found banner after output, checking another prompt")
 				if conn.TryPrompt() {
-					errlog.Info("- Found prompt")
+					s.log.Info("- Found prompt")
 				}
 			}
 			matched, _ := regexp.MatchString(`SHUTDOWN in 0?0:01:00`, msg)
-			return out, matched
+			return out, matched, nil
 		}
 	}
-	return out, false
+	return out, false, nil
 }
 
 // Remove definitions of banner lines from IOS config.
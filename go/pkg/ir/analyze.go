@@ -0,0 +1,270 @@
+package ir
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+)
+
+// Finding describes a problem found by Analyze.
+type Finding struct {
+	Kind    FindingKind
+	RuleSet string
+	Rule    *Rule
+	// Earlier rule that causes the problem.
+	By *Rule
+}
+
+type FindingKind int
+
+const (
+	// Rule never matches, because an earlier rule with different
+	// action matches all its packets.
+	Shadowed FindingKind = iota
+	// Rule is not needed, because an earlier rule with same action
+	// matches all its packets.
+	Redundant
+	// Rule from raw file decides on some packets of a later rule
+	// from Netspoc with different action.
+	Overrides
+)
+
+func (f *Finding) String() string {
+	var msg string
+	by := "rule"
+	if f.By.Raw {
+		by = "raw rule"
+	}
+	switch f.Kind {
+	case Shadowed:
+		msg = fmt.Sprintf("Rule %d of %s is shadowed by %s %d",
+			f.Rule.Position, f.RuleSet, by, f.By.Position)
+	case Redundant:
+		msg = fmt.Sprintf("Rule %d of %s is redundant to %s %d",
+			f.Rule.Position, f.RuleSet, by, f.By.Position)
+	case Overrides:
+		msg = fmt.Sprintf("Raw rule %d of %s overrides rule %d from Netspoc",
+			f.By.Position, f.RuleSet, f.Rule.Position)
+	}
+	return fmt.Sprintf("%s\n %d: %s\n %d: %s", msg,
+		f.By.Position, f.By.Orig, f.Rule.Position, f.Rule.Orig)
+}
+
+// Analyze checks each rule set for rules that are shadowed by or
+// redundant to a single earlier rule and for rules from raw file
+// that override later rules from Netspoc.
+// Rules with elements that could not be converted are not analyzed.
+// Overriding of rules that match any packet is not reported, because
+// these rules only implement the default action of a rule set.
+func (c *Config) Analyze() []*Finding {
+	var result []*Finding
+	for _, rs := range c.RuleSets {
+		var prev []*Rule
+		for _, r := range rs.Rules {
+			if !r.isDecision() || r.hasUnknown() {
+				continue
+			}
+			var f *Finding
+			for _, p := range prev {
+				if p.covers(r) {
+					kind := Redundant
+					if p.Action != r.Action {
+						kind = Shadowed
+					}
+					f = &Finding{Kind: kind, RuleSet: rs.Name, Rule: r, By: p}
+					break
+				}
+			}
+			if f == nil && !r.Raw && !r.isCatchAll() {
+				for _, p := range prev {
+					if p.Raw && p.Action != r.Action && p.overlaps(r) {
+						f = &Finding{
+							Kind: Overrides, RuleSet: rs.Name, Rule: r, By: p}
+						break
+					}
+				}
+			}
+			if f != nil {
+				result = append(result, f)
+			}
+			prev = append(prev, r)
+		}
+	}
+	return result
+}
+
+func (r *Rule) isDecision() bool {
+	return r.Action == Permit || r.Action == Deny
+}
+
+func (r *Rule) hasUnknown() bool {
+	return r.Src.Negate || r.Dst.Negate ||
+		len(r.Src.Unknown) != 0 || len(r.Dst.Unknown) != 0 ||
+		slices.ContainsFunc(r.Services, func(s *Service) bool {
+			return s.Unknown != ""
+		})
+}
+
+func (r *Rule) isCatchAll() bool {
+	isAny := func(a *AddrSet) bool {
+		return slices.ContainsFunc(a.Prefixes, func(p netip.Prefix) bool {
+			return p.Bits() == 0
+		})
+	}
+	return isAny(r.Src) && isAny(r.Dst) && r.anyService() &&
+		len(r.From) == 0 && len(r.To) == 0
+}
+
+func (r *Rule) anyService() bool {
+	return len(r.Services) == 0 ||
+		slices.ContainsFunc(r.Services, func(s *Service) bool {
+			return s.Proto == IP
+		})
+}
+
+// covers returns true if r matches all packets of r2.
+func (r *Rule) covers(r2 *Rule) bool {
+	if !r.Src.covers(r2.Src) || !r.Dst.covers(r2.Dst) ||
+		!coversZones(r.From, r2.From) || !coversZones(r.To, r2.To) {
+		return false
+	}
+	if r.anyService() {
+		return true
+	}
+	if r2.anyService() {
+		return false
+	}
+	for _, s2 := range r2.Services {
+		if !slices.ContainsFunc(r.Services, func(s *Service) bool {
+			return s.covers(s2)
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *AddrSet) covers(b *AddrSet) bool {
+	for _, q := range b.Prefixes {
+		if !slices.ContainsFunc(a.Prefixes, func(p netip.Prefix) bool {
+			return p.Bits() <= q.Bits() && p.Contains(q.Addr())
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+func coversZones(l1, l2 []string) bool {
+	if len(l1) == 0 {
+		return true
+	}
+	if len(l2) == 0 {
+		return false
+	}
+	for _, z := range l2 {
+		if !slices.Contains(l1, z) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) covers(s2 *Service) bool {
+	if s.Proto == IP {
+		return true
+	}
+	if s.Proto != s2.Proto {
+		return false
+	}
+	if !coversPorts(s.SrcPorts, s2.SrcPorts) ||
+		!coversPorts(s.DstPorts, s2.DstPorts) {
+		return false
+	}
+	coversNum := func(n1, n2 *int) bool {
+		return n1 == nil || n2 != nil && *n1 == *n2
+	}
+	return coversNum(s.Type, s2.Type) && coversNum(s.Code, s2.Code)
+}
+
+func coversPorts(l1, l2 []PortRange) bool {
+	if len(l1) == 0 {
+		return true
+	}
+	if len(l2) == 0 {
+		return isAllPorts(l1)
+	}
+	for _, r2 := range l2 {
+		if !slices.ContainsFunc(l1, func(r PortRange) bool {
+			return r.Low <= r2.Low && r2.High <= r.High
+		}) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps returns true if some packet is matched by r and r2.
+func (r *Rule) overlaps(r2 *Rule) bool {
+	if !r.Src.overlaps(r2.Src) || !r.Dst.overlaps(r2.Dst) ||
+		!overlapsZones(r.From, r2.From) || !overlapsZones(r.To, r2.To) {
+		return false
+	}
+	if r.anyService() || r2.anyService() {
+		return true
+	}
+	for _, s := range r.Services {
+		for _, s2 := range r2.Services {
+			if s.overlaps(s2) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *AddrSet) overlaps(b *AddrSet) bool {
+	for _, p := range a.Prefixes {
+		for _, q := range b.Prefixes {
+			if p.Overlaps(q) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func overlapsZones(l1, l2 []string) bool {
+	return len(l1) == 0 || len(l2) == 0 ||
+		slices.ContainsFunc(l1, func(z string) bool {
+			return slices.Contains(l2, z)
+		})
+}
+
+func (s *Service) overlaps(s2 *Service) bool {
+	if s.Proto != s2.Proto {
+		return false
+	}
+	if !overlapsPorts(s.SrcPorts, s2.SrcPorts) ||
+		!overlapsPorts(s.DstPorts, s2.DstPorts) {
+		return false
+	}
+	overlapsNum := func(n1, n2 *int) bool {
+		return n1 == nil || n2 == nil || *n1 == *n2
+	}
+	return overlapsNum(s.Type, s2.Type) && overlapsNum(s.Code, s2.Code)
+}
+
+func overlapsPorts(l1, l2 []PortRange) bool {
+	if len(l1) == 0 || len(l2) == 0 {
+		return true
+	}
+	for _, r := range l1 {
+		for _, r2 := range l2 {
+			if r.Low <= r2.High && r2.Low <= r.High {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,235 @@
+// Package ir provides a vendor neutral representation of rules,
+// managed by Netspoc-Approve.
+//
+// Each driver converts its ACLs, rulebases, policies or iptables
+// chains to this representation, both for the configuration read
+// from device and for the configuration generated by Netspoc.
+package ir
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	RuleSets []*RuleSet `json:"rule_sets"`
+}
+
+// RuleSet is an ordered list of rules, e.g. an ACL, the rulebase of
+// a gateway or vsys, a security policy or an iptables chain.
+type RuleSet struct {
+	Name string `json:"name"`
+	// Interfaces, zones or gateways where this rule set is applied.
+	// Rule set without bindings is only used by reference,
+	// e.g. user defined iptables chain.
+	Bindings []*Binding `json:"bindings,omitempty"`
+	Rules    []*Rule    `json:"rules"`
+	// Action if no rule matches. Empty value means, that evaluation
+	// continues in calling rule set.
+	Default Action `json:"default,omitempty"`
+}
+
+type Binding struct {
+	// Name of interface, zone or gateway.
+	// Empty name denotes binding, that is applied everywhere.
+	Name string `json:"name,omitempty"`
+	// "in" or "out" or empty if not applicable.
+	Direction string `json:"direction,omitempty"`
+}
+
+type Action string
+
+const (
+	Permit Action = "permit"
+	Deny   Action = "deny"
+	// Continue evaluation in rule set given in Rule.Target.
+	Jump Action = "jump"
+	// Continue evaluation in calling rule set.
+	Return Action = "return"
+)
+
+type Rule struct {
+	// Name of rule if available on device.
+	Name string `json:"name,omitempty"`
+	// Position of rule in its rule set, starting at 1.
+	Position int    `json:"position"`
+	Action   Action `json:"action"`
+	// Name of rule set for action Jump.
+	Target string   `json:"target,omitempty"`
+	Src    *AddrSet `json:"src"`
+	Dst    *AddrSet `json:"dst"`
+	// Rule matches any service if Services is empty.
+	Services []*Service `json:"services,omitempty"`
+	// Interfaces or zones, where packet enters resp. leaves the device.
+	// Empty value matches any interface or zone.
+	// NSX: From holds the scope of the rule.
+	From []string `json:"from,omitempty"`
+	To   []string `json:"to,omitempty"`
+	Log  bool     `json:"log,omitempty"`
+	// Rule was read from raw file.
+	Raw bool `json:"raw,omitempty"`
+	// Original representation of rule on device,
+	// in vendor specific syntax.
+	Orig string `json:"orig"`
+}
+
+// AddrSet is a set of IPv4 and IPv6 addresses.
+type AddrSet struct {
+	Prefixes []netip.Prefix `json:"prefixes,omitempty"`
+	// Set matches all addresses not in Prefixes.
+	Negate bool `json:"negate,omitempty"`
+	// Elements that could not be converted to prefixes,
+	// e.g. references to unknown objects or address ranges
+	// on Cisco with non contiguous wildcard mask.
+	Unknown []string `json:"unknown,omitempty"`
+}
+
+var (
+	Any4 = netip.MustParsePrefix("0.0.0.0/0")
+	Any6 = netip.MustParsePrefix("::/0")
+)
+
+// AnyAddr returns set of all IPv4 and IPv6 addresses.
+func AnyAddr() *AddrSet {
+	return &AddrSet{Prefixes: []netip.Prefix{Any4, Any6}}
+}
+
+// Add adds address, given as IP address or prefix.
+// Unparsable value is added to list of unknown elements.
+func (a *AddrSet) Add(s string) {
+	if p, err := ParsePrefix(s); err == nil {
+		a.Prefixes = append(a.Prefixes, p)
+	} else {
+		a.Unknown = append(a.Unknown, s)
+	}
+}
+
+// ParsePrefix parses IP address or prefix in CIDR notation.
+// IP address is converted to host prefix.
+func ParsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	ip, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(ip, ip.BitLen()), nil
+}
+
+// Names of protocols used in Service.Proto.
+// Other protocols are given by their number.
+const (
+	IP     = "ip"
+	TCP    = "tcp"
+	UDP    = "udp"
+	ICMP   = "icmp"
+	ICMPv6 = "icmpv6"
+)
+
+type Service struct {
+	Proto string `json:"proto,omitempty"`
+	// Port ranges of TCP and UDP. Empty value matches all ports.
+	SrcPorts []PortRange `json:"src_ports,omitempty"`
+	DstPorts []PortRange `json:"dst_ports,omitempty"`
+	// Type and code of ICMP and ICMPv6. Nil value matches all.
+	Type *int `json:"type,omitempty"`
+	Code *int `json:"code,omitempty"`
+	// Service that could not be converted,
+	// e.g. reference to application on PAN-OS.
+	Unknown string `json:"unknown,omitempty"`
+}
+
+type PortRange struct {
+	Low  int `json:"low"`
+	High int `json:"high"`
+}
+
+// ProtoName converts protocol number or name to value of Service.Proto.
+func ProtoName(s string) string {
+	switch strings.ToLower(s) {
+	case "1", "icmp", "icmpv4":
+		return ICMP
+	case "6", "tcp":
+		return TCP
+	case "17", "udp":
+		return UDP
+	case "58", "icmp6", "icmpv6", "ipv6-icmp":
+		return ICMPv6
+	case "", "ip", "all", "any":
+		return IP
+	}
+	return s
+}
+
+// ParsePorts parses port or port range, given as "N", "N-M", "N:M",
+// "<N", ">N" or comma separated list of these.
+func ParsePorts(s string) ([]PortRange, error) {
+	var result []PortRange
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		num := func(s string) (int, error) {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 || n > 65535 {
+				return 0, fmt.Errorf("Invalid port %q", s)
+			}
+			return n, nil
+		}
+		var r PortRange
+		var err error
+		if l, h, found := strings.Cut(p, "-"); found {
+			r.Low, err = num(l)
+			if err == nil {
+				r.High, err = num(h)
+			}
+		} else if l, h, found := strings.Cut(p, ":"); found {
+			r.Low, err = num(l)
+			if err == nil {
+				r.High, err = num(h)
+			}
+		} else if rest, found := strings.CutPrefix(p, ">"); found {
+			r.Low, err = num(rest)
+			r.Low++
+			r.High = 65535
+		} else if rest, found := strings.CutPrefix(p, "<"); found {
+			r.High, err = num(rest)
+			r.High--
+		} else {
+			r.Low, err = num(p)
+			r.High = r.Low
+		}
+		if err != nil {
+			return nil, err
+		}
+		if r.Low > r.High {
+			return nil, fmt.Errorf("Invalid port range %q", p)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// ServiceFromPorts returns service for TCP or UDP.
+// Unparsable port is stored as unknown service.
+func ServiceFromPorts(proto, src, dst string) *Service {
+	srv := &Service{Proto: ProtoName(proto)}
+	conv := func(s string) []PortRange {
+		if s == "" {
+			return nil
+		}
+		r, err := ParsePorts(s)
+		if err != nil {
+			srv.Unknown = proto + " " + s
+		}
+		return r
+	}
+	srv.SrcPorts = conv(src)
+	srv.DstPorts = conv(dst)
+	return srv
+}
+
+// IntPtr is used to fill Service.Type and Service.Code.
+func IntPtr(i int) *int { return &i }
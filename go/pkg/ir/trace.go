@@ -0,0 +1,281 @@
+package ir
+
+import (
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Flow describes packets that are evaluated against rules.
+type Flow struct {
+	Src, Dst netip.Addr
+	Proto    string
+	// Destination port of TCP and UDP or type of ICMP.
+	// Value -1 means: not given.
+	Port int
+	// Code of ICMP. Value -1 means: not given.
+	Code int
+	// Interface, zone or gateway, where packet enters resp. leaves
+	// the device. Empty if not given.
+	In, Out string
+}
+
+// ParseFlow parses flow given as "SRC DST PROTO[:PORT] [IN[:OUT]]".
+// Fields may be separated by space or comma.
+// PORT of ICMP is given as TYPE[/CODE].
+func ParseFlow(s string) (*Flow, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(fields) < 3 || len(fields) > 4 {
+		return nil, fmt.Errorf(
+			"Expected flow as \"SRC DST PROTO[:PORT] [IN[:OUT]]\", got %q", s)
+	}
+	f := &Flow{Port: -1, Code: -1}
+	var err error
+	if f.Src, err = netip.ParseAddr(fields[0]); err != nil {
+		return nil, fmt.Errorf("Invalid source address in flow: %v", err)
+	}
+	if f.Dst, err = netip.ParseAddr(fields[1]); err != nil {
+		return nil, fmt.Errorf("Invalid destination address in flow: %v", err)
+	}
+	if f.Src.Is4() != f.Dst.Is4() {
+		return nil, fmt.Errorf("Must not mix IPv4 and IPv6 addresses in flow")
+	}
+	proto, port, _ := strings.Cut(fields[2], ":")
+	f.Proto = ProtoName(proto)
+	if port != "" {
+		p, c, _ := strings.Cut(port, "/")
+		isICMP := f.Proto == ICMP || f.Proto == ICMPv6
+		num := func(s string, max int) (int, error) {
+			n, err := strconv.Atoi(s)
+			if err != nil || n < 0 || n > max {
+				return 0, fmt.Errorf("Invalid port %q in flow", port)
+			}
+			return n, nil
+		}
+		switch {
+		case f.Proto == TCP || f.Proto == UDP:
+			if c != "" {
+				return nil, fmt.Errorf("Invalid port %q in flow", port)
+			}
+			f.Port, err = num(p, 65535)
+		case isICMP:
+			f.Port, err = num(p, 255)
+			if err == nil && c != "" {
+				f.Code, err = num(c, 255)
+			}
+		default:
+			err = fmt.Errorf("Must not use port with protocol %q in flow", proto)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) == 4 {
+		f.In, f.Out, _ = strings.Cut(fields[3], ":")
+	}
+	return f, nil
+}
+
+// TraceResult describes evaluation of a flow against a single rule set.
+type TraceResult struct {
+	RuleSet *RuleSet
+	// Rules with action Jump that lead to matching rule.
+	Path []*Rule
+	// First matching rule or nil if default action was applied.
+	Rule *Rule
+	// Resulting action or empty if no rule matches and
+	// rule set has no default action.
+	Action Action
+	// Rules that have been skipped before the matching rule, because
+	// they have elements that could not be converted and hence may
+	// match as well.
+	Uncertain []UncertainRule
+}
+
+type UncertainRule struct {
+	RuleSet string
+	Rule    *Rule
+}
+
+// Trace evaluates flow against each rule set that is bound to
+// interface, zone or gateway of flow. Rule sets are evaluated in
+// order of their names.
+func (c *Config) Trace(f *Flow) []*TraceResult {
+	lookup := make(map[string]*RuleSet)
+	for _, rs := range c.RuleSets {
+		lookup[rs.Name] = rs
+	}
+	var result []*TraceResult
+	for _, rs := range c.RuleSets {
+		if !slices.ContainsFunc(rs.Bindings, func(b *Binding) bool {
+			return b.appliesTo(f)
+		}) {
+			continue
+		}
+		res := &TraceResult{RuleSet: rs}
+		if !res.eval(rs, f, lookup, 0) {
+			res.Action = rs.Default
+		}
+		result = append(result, res)
+	}
+	slices.SortStableFunc(result, func(a, b *TraceResult) int {
+		return strings.Compare(a.RuleSet.Name, b.RuleSet.Name)
+	})
+	return result
+}
+
+func (b *Binding) appliesTo(f *Flow) bool {
+	if b.Name == "" {
+		return true
+	}
+	if b.Direction == "out" {
+		return b.Name == f.Out
+	}
+	return b.Name == f.In
+}
+
+// Maximum depth of nested jumps.
+const maxDepth = 20
+
+// eval evaluates flow against rules of rs.
+// Returns true, if some rule decides on flow.
+func (res *TraceResult) eval(
+	rs *RuleSet, f *Flow, lookup map[string]*RuleSet, depth int) bool {
+
+	for _, r := range rs.Rules {
+		switch r.match(f) {
+		case no:
+			continue
+		case maybe:
+			res.Uncertain = append(res.Uncertain, UncertainRule{rs.Name, r})
+			continue
+		}
+		switch r.Action {
+		case Jump:
+			if t := lookup[r.Target]; t != nil && depth < maxDepth {
+				res.Path = append(res.Path, r)
+				if res.eval(t, f, lookup, depth+1) {
+					return true
+				}
+				res.Path = res.Path[:len(res.Path)-1]
+			}
+		case Return:
+			return false
+		default:
+			res.Rule = r
+			res.Action = r.Action
+			return true
+		}
+	}
+	return false
+}
+
+// Result of matching, ordered from weakest to strongest.
+type tri int
+
+const (
+	no tri = iota
+	maybe
+	yes
+)
+
+func (r *Rule) match(f *Flow) tri {
+	result := min(
+		r.Src.match(f.Src),
+		r.Dst.match(f.Dst),
+		matchZone(r.From, f.In),
+		matchZone(r.To, f.Out),
+	)
+	if len(r.Services) != 0 {
+		m := no
+		for _, s := range r.Services {
+			m = max(m, s.match(f))
+		}
+		result = min(result, m)
+	}
+	return result
+}
+
+func (a *AddrSet) match(ip netip.Addr) tri {
+	result := no
+	if slices.ContainsFunc(a.Prefixes, func(p netip.Prefix) bool {
+		return p.Contains(ip)
+	}) {
+		result = yes
+	} else if len(a.Unknown) != 0 {
+		result = maybe
+	}
+	if a.Negate {
+		switch result {
+		case yes:
+			result = no
+		case no:
+			result = yes
+		}
+	}
+	return result
+}
+
+func matchZone(l []string, name string) tri {
+	switch {
+	case len(l) == 0:
+		return yes
+	case name == "":
+		return maybe
+	case slices.Contains(l, name):
+		return yes
+	}
+	return no
+}
+
+func (s *Service) match(f *Flow) tri {
+	if s.Unknown != "" {
+		return maybe
+	}
+	if s.Proto == IP {
+		return yes
+	}
+	if s.Proto != f.Proto {
+		return no
+	}
+	result := yes
+	matchNum := func(n int, ok func(int) bool) {
+		if n == -1 {
+			result = min(result, maybe)
+		} else if !ok(n) {
+			result = no
+		}
+	}
+	switch s.Proto {
+	case TCP, UDP:
+		// Source port of flow is unknown.
+		if len(s.SrcPorts) != 0 && !isAllPorts(s.SrcPorts) {
+			result = maybe
+		}
+		if len(s.DstPorts) != 0 {
+			matchNum(f.Port, func(n int) bool {
+				return slices.ContainsFunc(s.DstPorts, func(r PortRange) bool {
+					return r.Low <= n && n <= r.High
+				})
+			})
+		}
+	case ICMP, ICMPv6:
+		if s.Type != nil {
+			matchNum(f.Port, func(n int) bool { return n == *s.Type })
+		}
+		if s.Code != nil {
+			matchNum(f.Code, func(n int) bool { return n == *s.Code })
+		}
+	}
+	return result
+}
+
+func isAllPorts(l []PortRange) bool {
+	return slices.ContainsFunc(l, func(r PortRange) bool {
+		return r.Low <= 1 && r.High == 65535
+	})
+}
@@ -0,0 +1,141 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+)
+
+// Journal records each command or API call sent to device during
+// approve. A line is written before and after each command is sent.
+// Hence the journal of an interrupted approve shows, which commands
+// were acknowledged by device and which have an unknown result.
+//
+// Each line of journal file is a JSON object. Lines of the same
+// command have equal value of "n".
+type Journal struct {
+	fh    *os.File
+	count int
+}
+
+// Entry describes state of a single command of journal.
+type Entry struct {
+	N     int    `json:"n,omitempty"`
+	State string `json:"state"`
+	Cmd   string `json:"cmd,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	Sent         = "sent"
+	Acknowledged = "acknowledged"
+	Failed       = "failed"
+	Finished     = "finished"
+	Aborted      = "aborted"
+)
+
+// Create creates an empty journal, replacing previous journal.
+func Create(fname string) (*Journal, error) {
+	fh, err := errlog.CreateWithPath(fname)
+	if err != nil {
+		return nil, fmt.Errorf("Can't %v", err)
+	}
+	return &Journal{fh: fh}, nil
+}
+
+func (j *Journal) Close() {
+	if j != nil {
+		j.fh.Close()
+	}
+}
+
+// Send records cmd, executes f, which sends cmd to device and
+// records result of f. If f returns an error, cmd is recorded as
+// failed together with the error message and the error is returned.
+func (j *Journal) Send(cmd string, f func() error) error {
+	if j == nil {
+		return f()
+	}
+	j.count++
+	n := j.count
+	if err := j.write(Entry{N: n, State: Sent, Cmd: cmd}); err != nil {
+		return err
+	}
+	if err := f(); err != nil {
+		j.write(Entry{N: n, State: Failed, Error: err.Error()})
+		return err
+	}
+	return j.write(Entry{N: n, State: Acknowledged})
+}
+
+// Finish marks journal as completed.
+func (j *Journal) Finish() error {
+	if j == nil {
+		return nil
+	}
+	return j.write(Entry{State: Finished})
+}
+
+// Abort marks journal as completed after approve has failed.
+// Only a journal without final state, left by a process that died
+// during approve, blocks later approves.
+func (j *Journal) Abort(err error) error {
+	if j == nil {
+		return nil
+	}
+	return j.write(Entry{State: Aborted, Error: err.Error()})
+}
+
+func (j *Journal) write(e Entry) error {
+	data, _ := json.Marshal(e)
+	if _, err := fmt.Fprintln(j.fh, string(data)); err != nil {
+		return fmt.Errorf("Can't write journal: %v", err)
+	}
+	return j.fh.Sync()
+}
+
+// Read reads journal from file fname and returns last state of each
+// command in order of sending. Second result tells if journal was
+// finished or aborted. Result is nil, if file doesn't exist.
+func Read(fname string) ([]Entry, bool, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("Can't %v", err)
+	}
+	var result []Entry
+	finished := false
+	for i, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			return nil, false,
+				fmt.Errorf("Invalid line %d in journal %s: %v", i+1, fname, err)
+		}
+		switch {
+		case e.State == Finished || e.State == Aborted:
+			finished = true
+		case e.N == len(result)+1 && e.State == Sent:
+			result = append(result, e)
+		case e.N >= 1 && e.N <= len(result):
+			result[e.N-1].State = e.State
+			result[e.N-1].Error = e.Error
+		default:
+			return nil, false,
+				fmt.Errorf("Invalid line %d in journal %s", i+1, fname)
+		}
+	}
+	if result == nil {
+		result = []Entry{}
+	}
+	return result, finished, nil
+}
@@ -1,45 +1,88 @@
 package linux
 
 import (
+	"fmt"
 	"slices"
-
-	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 )
 
 func (s *State) LoadNetspoc(data []byte, fName string) error {
-	cfg := s.parseConfig(data, fName)
+	cfg, err := s.parseConfig(data, fName)
+	if err != nil {
+		return err
+	}
 	if s.spocCfg == nil {
 		s.spocCfg = cfg
-	} else {
-		s.mergeSpoc(cfg)
+		return nil
 	}
-	return nil
+	return s.mergeSpoc(cfg)
 }
 
 func (s *State) MoveNetspoc2DeviceConfig() {
 	s.deviceCfg, s.spocCfg = s.spocCfg, nil
 }
 
-func (s *State) mergeSpoc(b *config) {
+type dumpChain struct {
+	Policy string      `json:"policy"`
+	Rules  []*dumpRule `json:"rules,omitempty"`
+}
+
+type dumpRule struct {
+	Orig   string            `json:"orig"`
+	Pairs  map[string]string `json:"pairs"`
+	Append bool              `json:"append,omitempty"`
+}
+
+// DumpNetspoc returns parsed configuration from Netspoc
+// in a form suitable for output as JSON.
+func (s *State) DumpNetspoc() any {
+	type dumpConfig struct {
+		Routes   []string                         `json:"routes,omitempty"`
+		IPTables map[string]map[string]*dumpChain `json:"iptables"`
+	}
+	result := &dumpConfig{IPTables: make(map[string]map[string]*dumpChain)}
+	cf := s.spocCfg
+	if cf == nil {
+		return result
+	}
+	for _, r := range cf.routes {
+		result.Routes = append(result.Routes, r.orig)
+	}
+	for tName, chains := range cf.iptables {
+		m := make(map[string]*dumpChain)
+		for cName, ch := range chains {
+			d := &dumpChain{Policy: ch.policy}
+			for _, ru := range ch.rules {
+				d.Rules = append(d.Rules,
+					&dumpRule{Orig: ru.orig, Pairs: ru.pairs, Append: ru.append})
+			}
+			m[cName] = d
+		}
+		result.IPTables[tName] = m
+	}
+	return result
+}
+
+func (s *State) mergeSpoc(b *config) error {
 	a := s.spocCfg
 	a.routes = append(a.routes, b.routes...)
 	for tName, bChains := range b.iptables {
 		aChains := a.iptables[tName]
 		if aChains == nil {
-			errlog.Info("Adding all chains of table %q", tName)
+			s.log.Info("Adding all chains of table %q", tName)
 			a.iptables[tName] = bChains
 			continue
 		}
 		for cName, bChain := range bChains {
 			aChain := aChains[cName]
 			if aChain == nil {
-				errlog.Info("Adding chain %q of table %q", cName, tName)
+				s.log.Info("Adding chain %q of table %q", cName, tName)
 				aChains[cName] = bChain
 				continue
 			}
 			switch aChain.policy {
 			case "-", "":
-				errlog.Abort("Must not redefine chain %q of table %q from rawdata",
+				return fmt.Errorf(
+					"Must not redefine chain %q of table %q from rawdata",
 					cName, tName)
 			}
 			for _, ru := range bChain.rules {
@@ -59,4 +102,5 @@ func (s *State) mergeSpoc(b *config) {
 			}
 		}
 	}
+	return nil
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/codefiles"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/console"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/journal"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
@@ -29,8 +30,11 @@ type State struct {
 	ip           string
 	user         string
 	errUnmanaged []error
+	log          *errlog.Logger
 }
 
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
+
 type change struct {
 	routes    []string
 	iptables  string
@@ -45,77 +49,136 @@ type config struct {
 func (s *State) LoadDevice(
 	spocFile string, cfg *program.Config, logLogin, logConfig *os.File,
 ) error {
-	user, pass, err := cfg.GetUserPass(codefiles.GetHostname(spocFile))
+	_, err := s.CheckAccess(spocFile, cfg, logLogin)
 	if err != nil {
 		return err
 	}
-	s.conn, err = console.GetSSHConn(spocFile, user, cfg, logLogin)
+	s.conn.SetLogFH(logConfig)
+
+	tb, err := s.getDeviceIPTables()
 	if err != nil {
 		return err
 	}
+	s.deviceCfg = &config{iptables: tb}
+	if len(s.spocCfg.routes) > 0 {
+		s.deviceCfg.routes, err = s.getDeviceRoutes()
+	}
+	return err
+}
+
+// Login to device, check device name and banner.
+// Return description of checked properties.
+func (s *State) CheckAccess(
+	spocFile string, cfg *program.Config, logLogin *os.File,
+) ([]string, error) {
+
+	user, pass, err := cfg.GetUserPass(codefiles.GetHostname(spocFile))
+	if err != nil {
+		return nil, err
+	}
+	s.conn, err = console.GetSSHConn(spocFile, user, cfg, logLogin)
+	if err != nil {
+		return nil, err
+	}
 	hostName := codefiles.GetHostname(spocFile)
-	s.loginEnable(pass, cfg)
-	s.logVersion()
-	s.checkDeviceName(hostName)
-	s.checkBanner(cfg)
-	s.conn.SetLogFH(logConfig)
+	if err := s.loginEnable(pass); err != nil {
+		return nil, err
+	}
+	if err := s.logVersion(); err != nil {
+		return nil, err
+	}
+	if err := s.checkDeviceName(hostName); err != nil {
+		return nil, err
+	}
 	s.ip, _, _ = codefiles.GetIPPDP(spocFile)
 	s.user = user
-
-	s.deviceCfg = &config{iptables: s.getDeviceIPTables()}
-	if len(s.spocCfg.routes) > 0 {
-		s.deviceCfg.routes = s.getDeviceRoutes()
+	result := []string{"device name: " + hostName}
+	if cfg.CheckBanner != nil {
+		if err := s.checkBanner(cfg); err != nil {
+			return nil, err
+		}
+		banner := "found"
+		if s.errUnmanaged != nil {
+			banner = "missing"
+		}
+		result = append(result, "banner: "+banner)
 	}
-	return err
+	return result, nil
 }
 
-func (s *State) loginEnable(pass string, cfg *program.Config) {
+func (s *State) loginEnable(pass string) error {
 	conn := s.conn
 	stdPrompt := `\r\n\S*\s?[%>$#]\s?(?:\x27\S*)?`
 	passPrompt := stdPrompt + `|(?i)password:`
-	out := conn.WaitLogin(passPrompt + `|\(yes/no.*\)\?`)
+	out, err := conn.WaitLogin(passPrompt + `|\(yes/no.*\)\?`)
+	if err != nil {
+		return err
+	}
 	if strings.HasSuffix(out, "?") {
-		out = conn.IssueCmd("yes", passPrompt)
+		if out, err = conn.IssueCmd("yes", passPrompt); err != nil {
+			return err
+		}
 	}
 	if strings.HasSuffix(out, "word:") {
-		out = conn.IssueCmd(pass, passPrompt)
+		if out, err = conn.IssueCmd(pass, passPrompt); err != nil {
+			return err
+		}
 	}
 	if strings.HasSuffix(out, "word:") {
-		errlog.Abort("Authentication failed")
+		return errlog.Classify(errlog.AuthFailed,
+			errors.New("Authentication failed"))
 	}
 
 	// Force prompt to simple, known value.
 	// Don't use '#', because it is used as comment character
 	// in output of iptables-save.
-	conn.IssueCmd("PS1=router#", stdPrompt)
+	if _, err := conn.IssueCmd("PS1=router#", stdPrompt); err != nil {
+		return err
+	}
 	rx := regexp.MustCompile(`\nrouter#`)
 	conn.SetStdPrompt(rx)
+	return nil
 }
 
-func (s *State) logVersion() {
-	s.conn.GetCmdOutput("uname -r")
-	s.conn.GetCmdOutput("uname -m")
+func (s *State) logVersion() error {
+	if _, err := s.conn.GetCmdOutput("uname -r"); err != nil {
+		return err
+	}
+	_, err := s.conn.GetCmdOutput("uname -m")
+	return err
 }
 
-func (s *State) checkDeviceName(name string) {
-	out := s.conn.GetCmdOutput("hostname -s")
+func (s *State) checkDeviceName(name string) error {
+	out, err := s.conn.GetCmdOutput("hostname -s")
+	if err != nil {
+		return err
+	}
 	out = strings.TrimSuffix(out, "\n")
 	if name != out {
-		errlog.Abort("Wrong device name: %q, expected: %q", out, name)
+		return errlog.Classify(errlog.WrongName,
+			fmt.Errorf("Wrong device name: %q, expected: %q", out, name))
 	}
+	return nil
 }
 
-func (s *State) checkBanner(cfg *program.Config) {
+func (s *State) checkBanner(cfg *program.Config) error {
 	re := cfg.CheckBanner.String()
-	lines := s.conn.GetCmdOutput("grep '" + re + "' /etc/issue")
+	lines, err := s.conn.GetCmdOutput("grep '" + re + "' /etc/issue")
+	if err != nil {
+		return err
+	}
 	if len(lines) == 0 {
 		s.errUnmanaged =
 			[]error{errors.New("Missing banner at NetSPoC managed device")}
 	}
+	return nil
 }
 
-func (s *State) getDeviceRoutes() []route {
-	out := s.conn.GetCmdOutput("ip route show")
+func (s *State) getDeviceRoutes() ([]route, error) {
+	out, err := s.conn.GetCmdOutput("ip route show")
+	if err != nil {
+		return nil, err
+	}
 	lines := strings.Split(out, "\n")
 	if s := len(lines); s > 0 && lines[s-1] == "" {
 		lines = lines[:s-1]
@@ -123,12 +186,15 @@ func (s *State) getDeviceRoutes() []route {
 	for i, l := range lines {
 		lines[i] = "ip route add " + l
 	}
-	return parseRoutes(lines)
+	return parseRoutes(lines, nil)
 }
 
-func (s *State) getDeviceIPTables() tables {
-	out := s.conn.GetCmdOutput("iptables-save")
-	return s.parseIPTables(strings.Split(string(out), "\n"))
+func (s *State) getDeviceIPTables() (tables, error) {
+	out, err := s.conn.GetCmdOutput("iptables-save")
+	if err != nil {
+		return nil, err
+	}
+	return s.parseIPTables(strings.Split(string(out), "\n"), nil)
 }
 
 func (s *State) GetChanges() error {
@@ -141,11 +207,20 @@ func (s *State) HasChanges() bool {
 }
 
 func (s *State) ShowChanges() string {
+	return s.showRoutes() + s.showIPTables()
+}
+
+func (s *State) showRoutes() string {
 	var collect strings.Builder
 	for _, line := range s.change.routes {
 		line = strings.Replace(line, "\n", "\\N ", 1)
 		fmt.Fprintln(&collect, line)
 	}
+	return collect.String()
+}
+
+func (s *State) showIPTables() string {
+	var collect strings.Builder
 	if chg := s.change.iptables; chg != "" {
 		fmt.Fprintln(&collect, chg)
 		fmt.Fprintln(&collect, "#!/sbin/iptables-restore")
@@ -157,80 +232,140 @@ func (s *State) ShowChanges() string {
 	return collect.String()
 }
 
-func (s *State) ApplyCommands(logFh *os.File) error {
+func (s *State) ChangeGroups() []program.ChangeGroup {
+	var result []program.ChangeGroup
+	if len(s.change.routes) != 0 {
+		result = append(result,
+			program.ChangeGroup{
+				Name: "routes", Category: "routes", Changes: s.showRoutes()})
+	}
+	if s.change.iptables != "" {
+		result = append(result,
+			program.ChangeGroup{
+				Name: "iptables", Category: "iptables", Changes: s.showIPTables()})
+	}
+	return result
+}
+
+func (s *State) DeselectChanges(name string) {
+	switch name {
+	case "routes":
+		s.change.routes = nil
+	case "iptables":
+		s.change.iptables = ""
+	}
+}
+
+func (s *State) ApplyCommands(logFh *os.File, j *journal.Journal) error {
 	s.conn.SetLogFH(logFh)
 	ch := s.change
 	cf := ch.newConfig
+	send := func(c string) error {
+		return j.Send(c, func() error { return s.cmd(c) })
+	}
 	// Change active routes on device.
 	for _, c := range ch.routes {
-		s.cmd(c)
+		if err := send(c); err != nil {
+			return err
+		}
 	}
 	// Copy new iptables config to temporary file on device.
 	// Execute this file to activate new iptables configuration.
 	if ch.iptables != "" {
 		tmpFile := deviceIPTablesFile + ".new"
-		s.writeStartupIPTables(cf.iptables, tmpFile)
-		s.cmd("chmod a+x " + tmpFile)
-		errlog.Info("Changing iptables running config")
-		s.cmd(tmpFile)
-		s.cmd("mv -f " + tmpFile + " " + deviceIPTablesFile)
+		err := j.Send("write "+tmpFile, func() error {
+			return s.writeStartupIPTables(cf.iptables, tmpFile)
+		})
+		if err != nil {
+			return err
+		}
+		if err := send("chmod a+x " + tmpFile); err != nil {
+			return err
+		}
+		s.log.Info("Changing iptables running config")
+		if err := send(tmpFile); err != nil {
+			return err
+		}
+		if err := send("mv -f " + tmpFile + " " + deviceIPTablesFile); err != nil {
+			return err
+		}
 	}
 
 	// Write startup routing config to device if routes have changed.
 	if len(ch.routes) != 0 {
-		s.writeStartupRouting(cf.routes, deviceRoutingFile)
+		err := j.Send("write "+deviceRoutingFile, func() error {
+			return s.writeStartupRouting(cf.routes, deviceRoutingFile)
+		})
+		return errlog.Reclassify(errlog.CommitFailed, err)
 	}
 	return nil
 }
 
 // Send 1 or 2 commands in one data packet to device.
 // No output expected from commands.
-func (s *State) cmd(c string) {
+func (s *State) cmd(c string) error {
 	c1, c2, _ := strings.Cut(c, "\n")
 	s.conn.Send(c)
-	check := func(ci string) {
-		out := s.conn.GetOutput()
-		out = s.conn.StripEcho(ci, out)
+	check := func(ci string) error {
+		out, err := s.conn.GetOutput()
+		if err != nil {
+			return err
+		}
+		if out, err = s.conn.StripEcho(ci, out); err != nil {
+			return err
+		}
 		if out != "" {
-			errlog.Abort("Got unexpected output from '%s':\n%s", ci, out)
+			return fmt.Errorf("Got unexpected output from '%s':\n%s", ci, out)
 		}
+		return nil
+	}
+	if err := check(c1); err != nil {
+		return err
 	}
-	check(c1)
 	if c2 != "" {
-		check(c2)
+		if err := check(c2); err != nil {
+			return err
+		}
 	}
-	if s.conn.GetCmdOutput("echo $?") != "0\n" {
-		errlog.Abort("%s failed (exit status)",
+	out, err := s.conn.GetCmdOutput("echo $?")
+	if err != nil {
+		return err
+	}
+	if out != "0\n" {
+		return fmt.Errorf("%s failed (exit status)",
 			strings.Replace(c, "\n", "\\N ", 1))
 	}
+	return nil
 }
 
-func (s *State) writeStartupRouting(routes []route, dst string) {
-	file := createTemp("routes")
-	defer os.Remove(file.Name())
+func (s *State) writeStartupRouting(routes []route, dst string) error {
 	lines := []string{"#!/bin/sh", "# Generated by NetSPoC"}
 	for _, r := range routes {
 		lines = append(lines, r.orig)
 	}
-	s.writeStartup(file, lines, dst)
+	return s.writeStartup("routes", lines, dst)
 }
 
-func (s *State) writeStartupIPTables(tb tables, dst string) {
-	file := createTemp("iptables")
-	defer os.Remove(file.Name())
-	path := s.findIPTablesRestoreCmd()
+func (s *State) writeStartupIPTables(tb tables, dst string) error {
+	path, err := s.findIPTablesRestoreCmd()
+	if err != nil {
+		return err
+	}
 	tLines := getIPTablesConfig(tb)
 	lines := append([]string{"#!" + path, "# Generated by NetSPoC"}, tLines...)
-	s.writeStartup(file, lines, dst)
+	return s.writeStartup("iptables", lines, dst)
 }
 
-func (s *State) findIPTablesRestoreCmd() string {
-	out := s.conn.GetCmdOutput("which iptables-restore")
+func (s *State) findIPTablesRestoreCmd() (string, error) {
+	out, err := s.conn.GetCmdOutput("which iptables-restore")
+	if err != nil {
+		return "", err
+	}
 	cmd := strings.TrimSpace(out)
 	if !strings.HasSuffix(cmd, "iptables-restore") {
-		errlog.Abort("Can't find path of 'iptables-restore'")
+		return "", errors.New("Can't find path of 'iptables-restore'")
 	}
-	return cmd
+	return cmd, nil
 }
 
 func getIPTablesConfig(tb tables) []string {
@@ -254,33 +389,32 @@ func getIPTablesConfig(tb tables) []string {
 	return result
 }
 
-func createTemp(name string) *os.File {
+// writeStartup writes lines to temporary file and copies it to dst
+// on device.
+func (s *State) writeStartup(name string, lines []string, dst string) error {
 	file, err := os.CreateTemp("", name)
 	if err != nil {
-		errlog.Abort("can't %v", err)
+		return fmt.Errorf("can't %v", err)
 	}
-	return file
-}
-
-func (s *State) writeStartup(file *os.File, lines []string, dst string) {
+	defer os.Remove(file.Name())
 	for _, entry := range lines {
 		fmt.Fprintln(file, entry)
 	}
 	file.Close()
-	s.putScp(file.Name(), dst)
+	return s.putScp(file.Name(), dst)
 }
 
-func (s *State) putScp(src, dst string) {
+func (s *State) putScp(src, dst string) error {
 	remote := s.user + "@" + s.ip + ":"
 	cmd := exec.Command("scp", "-q", src, remote+dst)
-	errlog.Info("Executing %s", cmd)
+	s.log.Info("Executing %s", cmd)
 	if os.Getenv("SIMULATE_ROUTER") != "" {
-		return
+		return nil
 	}
-	err := cmd.Run()
-	if err != nil {
-		errlog.Abort("%s failed: %v", cmd, err)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %v", cmd, err)
 	}
+	return nil
 }
 
 func (s *State) CloseConnection()         {}
@@ -4,10 +4,58 @@ import (
 	"cmp"
 	"fmt"
 	"maps"
+	"net/netip"
 	"slices"
 	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
+// Protect copies chains and routes from device to configuration from
+// Netspoc, that match entries of protect file. Hence they are left
+// unchanged on device. Netspoc must not generate protected entries.
+func (s *State) Protect(l program.ProtectList) error {
+	a, b := s.deviceCfg, s.spocCfg
+	if b.iptables == nil {
+		b.iptables = make(tables)
+	}
+	for _, tName := range slices.Sorted(maps.Keys(a.iptables)) {
+		aChains := a.iptables[tName]
+		for _, cName := range slices.Sorted(maps.Keys(aChains)) {
+			if !l.Match("chain", cName) {
+				continue
+			}
+			bChains := b.iptables[tName]
+			if bChains == nil {
+				bChains = make(chains)
+				b.iptables[tName] = bChains
+			}
+			if bChains[cName] != nil {
+				return fmt.Errorf("Must not change protected chain %s:%s",
+					tName, cName)
+			}
+			bChains[cName] = aChains[cName]
+		}
+	}
+	for _, r := range b.routes {
+		if l.MatchRoute(r.dst.prefixOf()) {
+			return fmt.Errorf("Must not change protected route: %s", r.orig)
+		}
+	}
+	for _, r := range a.routes {
+		if l.MatchRoute(r.dst.prefixOf()) {
+			b.routes = append(b.routes, r)
+		}
+	}
+	return nil
+}
+
+func (d dst) prefixOf() netip.Prefix {
+	ip, _ := netip.ParseAddr(d.ip)
+	p, _ := ip.Prefix(d.prefix)
+	return p
+}
+
 func diffConfig(a, b *config) change {
 	return change{
 		newConfig: b,
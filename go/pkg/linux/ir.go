@@ -0,0 +1,164 @@
+package linux
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
+)
+
+// ExportRules converts chains of table "filter" to vendor neutral
+// representation. Rules with targets other than ACCEPT, DROP, REJECT,
+// RETURN or a user defined chain are left out, because they don't
+// decide on packets. Rules that only match packets of established
+// connections are left out as well.
+func (s *State) ExportRules(device bool) *ir.Config {
+	cf := s.spocCfg
+	if device {
+		cf = s.deviceCfg
+	}
+	result := &ir.Config{}
+	if cf == nil {
+		return result
+	}
+	chains := cf.iptables["filter"]
+	for name, ch := range chains {
+		rs := &ir.RuleSet{Name: name}
+		switch ch.policy {
+		case "ACCEPT":
+			rs.Default = ir.Permit
+		case "DROP":
+			rs.Default = ir.Deny
+		}
+		switch name {
+		case "INPUT", "FORWARD":
+			rs.Bindings = []*ir.Binding{{}}
+		}
+		for i, ru := range ch.rules {
+			if r := convertRule(ru, chains); r != nil {
+				r.Position = i + 1
+				rs.Rules = append(rs.Rules, r)
+			}
+		}
+		result.RuleSets = append(result.RuleSets, rs)
+	}
+	sort.Slice(result.RuleSets, func(i, j int) bool {
+		return result.RuleSets[i].Name < result.RuleSets[j].Name
+	})
+	return result
+}
+
+func convertRule(ru rule, chains chains) *ir.Rule {
+	p := ru.pairs
+	r := &ir.Rule{Raw: ru.raw, Orig: ru.orig}
+	switch t := p["-j"]; t {
+	case "ACCEPT":
+		r.Action = ir.Permit
+	case "DROP", "REJECT":
+		r.Action = ir.Deny
+	case "RETURN":
+		r.Action = ir.Return
+	default:
+		if chains[t] == nil {
+			return nil
+		}
+		r.Action = ir.Jump
+		r.Target = t
+	}
+	for _, k := range []string{"--state", "--ctstate"} {
+		if v, found := p[k]; found && !strings.Contains(v, "NEW") {
+			return nil
+		}
+	}
+	r.Src = convertAddr(p["-s"])
+	r.Dst = convertAddr(p["-d"])
+	if v := p["-i"]; v != "" {
+		r.From = []string{v}
+	}
+	if v := p["-o"]; v != "" {
+		r.To = []string{v}
+	}
+	if srv := convertService(p); srv.Proto != ir.IP || srv.Unknown != "" {
+		r.Services = []*ir.Service{srv}
+	}
+	return r
+}
+
+func convertAddr(v string) *ir.AddrSet {
+	if v == "" {
+		return &ir.AddrSet{Prefixes: []netip.Prefix{ir.Any4}}
+	}
+	a := &ir.AddrSet{}
+	if rest, found := strings.CutPrefix(v, "!"); found {
+		a.Negate = true
+		v = rest
+	}
+	for _, s := range strings.Split(v, ",") {
+		a.Add(s)
+	}
+	return a
+}
+
+func convertService(p map[string]string) *ir.Service {
+	proto := p["-p"]
+	if strings.HasPrefix(proto, "!") {
+		return &ir.Service{Unknown: "-p " + proto}
+	}
+	srv := &ir.Service{Proto: ir.ProtoName(proto)}
+	var unknown []string
+	ports := func(k1, k2 string) []ir.PortRange {
+		v, k := p[k1], k1
+		if v == "" {
+			v, k = p[k2], k2
+		}
+		if v == "" {
+			return nil
+		}
+		// Port was normalized to "N", "N:M" or "N:",
+		// leading zeros were removed.
+		s := v
+		if strings.HasPrefix(s, ":") {
+			s = "0" + s
+		}
+		if strings.HasSuffix(s, ":") {
+			s += "65535"
+		}
+		l, err := ir.ParsePorts(s)
+		if err != nil {
+			unknown = append(unknown, k+" "+v)
+		}
+		return l
+	}
+	switch srv.Proto {
+	case ir.TCP, ir.UDP:
+		srv.SrcPorts = ports("--sport", "--sports")
+		srv.DstPorts = ports("--dport", "--dports")
+	case ir.ICMP, ir.ICMPv6:
+		v := p["--icmp-type"]
+		if v == "" {
+			v = p["--icmpv6-type"]
+		}
+		if v != "" {
+			t, c, _ := strings.Cut(v, "/")
+			n1, err1 := strconv.Atoi(t)
+			if err1 != nil {
+				unknown = append(unknown, "--icmp-type "+v)
+				break
+			}
+			srv.Type = ir.IntPtr(n1)
+			if c != "" {
+				if n2, err2 := strconv.Atoi(c); err2 == nil {
+					srv.Code = ir.IntPtr(n2)
+				} else {
+					unknown = append(unknown, "--icmp-type "+v)
+				}
+			}
+		}
+	}
+	if unknown != nil {
+		srv.Unknown = strings.Join(unknown, " ")
+	}
+	return srv
+}
@@ -1,31 +1,55 @@
 package linux
 
 import (
+	"errors"
+	"fmt"
+	"path"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
-
-	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 )
 
-func (s *State) parseConfig(data []byte, fName string) *config {
+func (s *State) parseConfig(data []byte, fName string) (*config, error) {
+	isRaw := path.Ext(fName) == ".raw"
 	var rLines, tLines []string
-	for _, line := range strings.Split(string(data), "\n") {
+	// Numbers of lines in raw file, shown in error messages.
+	var rNo, tNo []int
+	for i, line := range strings.Split(string(data), "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" || line[0] == '#' {
 			continue
 		}
 		if strings.HasPrefix(line, "ip route") {
 			rLines = append(rLines, line)
+			if isRaw {
+				rNo = append(rNo, i+1)
+			}
 		} else {
 			tLines = append(tLines, line)
+			if isRaw {
+				tNo = append(tNo, i+1)
+			}
+		}
+	}
+	tb, err := s.parseIPTables(tLines, tNo)
+	if err != nil {
+		return nil, err
+	}
+	if isRaw {
+		for _, chains := range tb {
+			for _, ch := range chains {
+				for i := range ch.rules {
+					ch.rules[i].raw = true
+				}
+			}
 		}
 	}
-	return &config{
-		routes:   parseRoutes(rLines),
-		iptables: s.parseIPTables(tLines),
+	routes, err := parseRoutes(rLines, rNo)
+	if err != nil {
+		return nil, err
 	}
+	return &config{routes: routes, iptables: tb}, nil
 }
 
 type route struct {
@@ -42,12 +66,22 @@ type dst struct {
 	prefix int
 }
 
-func parseRoutes(lines []string) []route {
+// lineError returns error in i-th line of lines.
+// Number of line is added to message, if lineNo is given.
+func lineError(lineNo []int, i int, format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if lineNo != nil {
+		msg += fmt.Sprintf(" in line %d", lineNo[i])
+	}
+	return errors.New(msg)
+}
+
+func parseRoutes(lines []string, lineNo []int) ([]route, error) {
 	var result []route
-	for _, line := range lines {
+	for i, line := range lines {
 		rest, found := strings.CutPrefix(line, "ip route add ")
 		if !found {
-			errlog.Abort("Unexpected route: %s", line)
+			return nil, lineError(lineNo, i, "Unexpected route: %s", line)
 		}
 		// Ignore entries with 'scope link'.
 		if strings.Contains(rest, " scope link") {
@@ -59,11 +93,11 @@ func parseRoutes(lines []string) []route {
 		}
 		words := strings.Fields(rest)
 		if !(len(words) >= 3 && words[1] == "via") {
-			errlog.Abort("Unexpected route: %s", line)
+			return nil, lineError(lineNo, i, "Unexpected route: %s", line)
 		}
 		// Ignore attribute 'dev', if 'via' is provided.
 		if len(words) > 3 && !(len(words) == 5 && words[3] == "dev") {
-			errlog.Abort("Unexpected route: %s", line)
+			return nil, lineError(lineNo, i, "Unexpected route: %s", line)
 		}
 		ip := words[0]
 		prefix := 32
@@ -81,7 +115,7 @@ func parseRoutes(lines []string) []route {
 				orig: line,
 			})
 	}
-	return result
+	return result, nil
 }
 
 type tables map[string]chains
@@ -94,13 +128,17 @@ type rule struct {
 	orig   string
 	pairs  map[string]string
 	append bool
+	raw    bool // Rule was read from raw file
 }
 
-func (s *State) parseIPTables(lines []string) tables {
+func (s *State) parseIPTables(lines []string, lineNo []int) (tables, error) {
 	tb := make(tables)
 	var cMap chains
 	appendRule := false
-	for _, line := range lines {
+	for i, line := range lines {
+		abort := func(format string, args ...any) (tables, error) {
+			return nil, lineError(lineNo, i, format, args...)
+		}
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
@@ -116,7 +154,7 @@ func (s *State) parseIPTables(lines []string) tables {
 			// :INPUT ACCEPT [68024:74200042]
 			// :e0_in - [0:0]
 			if cMap == nil {
-				errlog.Abort("Found chain policy outside of table: %q", line)
+				return abort("Found chain policy outside of table: %q", line)
 			}
 			words := strings.Fields(line[1:])
 			if len(words) >= 2 {
@@ -132,19 +170,19 @@ func (s *State) parseIPTables(lines []string) tables {
 			// '!' may occur before or after the key,
 			// but only after key, if at least one argument.
 			if cMap == nil {
-				errlog.Abort("Found rule outside of table: %q", line)
+				return abort("Found rule outside of table: %q", line)
 			}
 			words := strings.Fields(line)
 			if words[0] != "-A" {
-				errlog.Abort("Unsupported command %q", words[0])
+				return abort("Unsupported command %q", words[0])
 			}
 			if len(words) < 2 {
-				errlog.Abort("Incomplete command %q", line)
+				return abort("Incomplete command %q", line)
 			}
 			name := words[1]
 			ch := cMap[name]
 			if ch == nil {
-				errlog.Abort("Must define policy before adding rules of chain %q",
+				return abort("Must define policy before adding rules of chain %q",
 					name)
 			}
 			words = words[2:]
@@ -156,7 +194,7 @@ func (s *State) parseIPTables(lines []string) tables {
 					negate = "!"
 					words = words[1:]
 					if len(words) == 0 {
-						errlog.Abort("Unexpected trailing '!' in line\n %s", line)
+						return abort("Unexpected trailing '!' in line\n %s", line)
 					}
 				}
 				key := words[0]
@@ -192,11 +230,11 @@ func (s *State) parseIPTables(lines []string) tables {
 			case "COMMIT":
 				// ignore
 			default:
-				errlog.Abort("Unknown command: %q", line)
+				return abort("Unknown command: %q", line)
 			}
 		}
 	}
-	return tb
+	return tb, nil
 }
 
 // Normalize values of iptables rules.
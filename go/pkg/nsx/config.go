@@ -1,5 +1,11 @@
 package nsx
 
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
 func (s *State) LoadNetspoc(data []byte, fName string) error {
 	cfg, err := s.parseConfig(data, fName)
 	if err != nil {
@@ -8,7 +14,7 @@ func (s *State) LoadNetspoc(data []byte, fName string) error {
 	if s.spocCfg == nil {
 		s.spocCfg = cfg
 	} else {
-		s.mergeSpoc(cfg)
+		return s.mergeSpoc(cfg)
 	}
 	return nil
 }
@@ -17,7 +23,13 @@ func (s *State) MoveNetspoc2DeviceConfig() {
 	s.deviceCfg, s.spocCfg = s.spocCfg, nil
 }
 
-func (s *State) mergeSpoc(n2 *nsxConfig) {
+// DumpNetspoc returns parsed configuration from Netspoc
+// in a form suitable for output as JSON.
+func (s *State) DumpNetspoc() any {
+	return s.spocCfg
+}
+
+func (s *State) mergeSpoc(n2 *nsxConfig) error {
 	n1 := s.spocCfg
 	n1.Groups = append(n1.Groups, n2.Groups...)
 	n1.Services = append(n1.Services, n2.Services...)
@@ -26,10 +38,126 @@ POLICY:
 	for _, p2 := range n2.Policies {
 		for _, p1 := range n1.Policies {
 			if p2.Id == p1.Id {
-				p1.Rules = append(p1.Rules, p2.Rules...)
+				rules, err := mergeRules(p1.Rules, p2.Rules, p1.Id)
+				if err != nil {
+					return err
+				}
+				p1.Rules = rules
 				continue POLICY
 			}
 		}
+		if _, err := mergeRules(nil, p2.Rules, p2.Id); err != nil {
+			return err
+		}
 		n1.Policies = append(n1.Policies, p2)
 	}
+	return nil
+}
+
+// mergeRules adds rules from raw to rules from Netspoc.
+// Rules are ordered by their sequence number.
+// Rules with attribute "replace" or "delete" replace resp. delete
+// rule with same id from Netspoc.
+// Rules with attribute "before" or "after" are inserted before resp.
+// after rule with given id, "prepend" and "append" insert before
+// first resp. after last rule. Given rule may be from Netspoc or
+// may have been inserted from raw before.
+// Afterwards sequence numbers are adjusted to the order of rules.
+func mergeRules(l1, l2 []*nsxRule, policy string) ([]*nsxRule, error) {
+	slices.SortStableFunc(l1, func(a, b *nsxRule) int {
+		return cmp.Compare(a.SequenceNumber, b.SequenceNumber)
+	})
+	find := func(id, attr string) (int, error) {
+		i := slices.IndexFunc(l1, func(r *nsxRule) bool { return r.Id == id })
+		if i == -1 {
+			return i, fmt.Errorf("Unknown rule %q in %q of policy %q",
+				id, attr, policy)
+		}
+		return i, nil
+	}
+	// Rules inserted at position.
+	inserted := make(map[*nsxRule]bool)
+	// Rules inserted with "after" are placed behind rules inserted
+	// with "after" of same rule before.
+	skipAfter := func(i int, id string) int {
+		for i < len(l1) && inserted[l1[i]] && l1[i].After == id {
+			i++
+		}
+		return i
+	}
+	nPrepend := 0
+	for _, r := range l2 {
+		var i int
+		var err error
+		switch {
+		case r.Replace:
+			if i, err = find(r.Id, "replace"); err == nil {
+				l1[i] = r
+			}
+		case r.Delete:
+			if i, err = find(r.Id, "delete"); err == nil {
+				l1 = slices.Delete(l1, i, i+1)
+			}
+		case r.Before != "":
+			if i, err = find(r.Before, "before"); err == nil {
+				r.SequenceNumber = max(0, l1[i].SequenceNumber-1)
+				l1 = slices.Insert(l1, i, r)
+				inserted[r] = true
+			}
+		case r.After != "":
+			if i, err = find(r.After, "after"); err == nil {
+				r.SequenceNumber = l1[i].SequenceNumber + 1
+				l1 = slices.Insert(l1, skipAfter(i+1, r.After), r)
+				inserted[r] = true
+			}
+		case r.Prepend:
+			r.SequenceNumber = 0
+			l1 = slices.Insert(l1, nPrepend, r)
+			nPrepend++
+			inserted[r] = true
+		case r.Append:
+			if n := len(l1); n > 0 {
+				r.SequenceNumber = l1[n-1].SequenceNumber + 1
+			}
+			l1 = append(l1, r)
+			inserted[r] = true
+		default:
+			// Insert behind rules with same sequence number.
+			i = slices.IndexFunc(l1, func(a *nsxRule) bool {
+				return a.SequenceNumber > r.SequenceNumber
+			})
+			if i == -1 {
+				i = len(l1)
+			}
+			l1 = slices.Insert(l1, i, r)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	renumber(l1, inserted)
+	for _, r := range l2 {
+		r.Replace, r.Before, r.After = false, "", ""
+		r.Prepend, r.Append = false, false
+	}
+	return l1, nil
+}
+
+// renumber assigns increasing sequence numbers to rules in given
+// order. Other rules than inserted ones keep their number if
+// possible. Adjacent rules of these with equal number keep being
+// equal.
+func renumber(l []*nsxRule, inserted map[*nsxRule]bool) {
+	prev, prevOrig := 0, 0
+	for i, r := range l {
+		orig := r.SequenceNumber
+		switch {
+		case i == 0:
+		case !inserted[r] && !inserted[l[i-1]] && orig == prevOrig:
+			r.SequenceNumber = prev
+		case orig <= prev:
+			r.SequenceNumber = prev + 1
+		}
+		prev, prevOrig = r.SequenceNumber, orig
+	}
 }
@@ -14,6 +14,7 @@ import (
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/httpdevice"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/journal"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
@@ -24,7 +25,11 @@ type State struct {
 	deviceCfg *nsxConfig
 	spocCfg   *nsxConfig
 	changes   []change
+	log       *errlog.Logger
 }
+
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
+
 type change struct {
 	method   string
 	url      string
@@ -34,34 +39,9 @@ type change struct {
 func (s *State) LoadDevice(
 	spocFile string, cfg *program.Config, logLogin, logConfig *os.File) error {
 
-	err := httpdevice.TryReachableHTTPLogin(spocFile, cfg,
-		func(name, ip, user, pass string) error {
-			s.client, s.prefix = httpdevice.GetHTTPClient(cfg, ip)
-			jar, _ := cookiejar.New(nil)
-			s.client.Jar = jar
-
-			uri := s.prefix + "/api/session/create"
-			errlog.DoLog(logLogin, "POST "+uri)
-			v := url.Values{}
-			v.Set("j_username", user)
-			v.Set("j_password", "xxx")
-			errlog.DoLog(logLogin, v.Encode())
-			v.Set("j_password", pass)
-			resp, err := s.client.PostForm(uri, v)
-			if err != nil {
-				return err
-			}
-			errlog.DoLog(logLogin, resp.Status)
-			if resp.StatusCode != http.StatusOK {
-				return fmt.Errorf("status code: %d", resp.StatusCode)
-			}
-			s.token = resp.Header.Get("x-xsrf-token")
-			return nil
-		})
-	if err != nil {
+	if _, err := s.CheckAccess(spocFile, cfg, logLogin); err != nil {
 		return err
 	}
-
 	path := "/policy/api/v1/infra/domains/default/gateway-policies"
 	data, err := s.sendRequest("GET", path, nil)
 	if err != nil {
@@ -117,6 +97,40 @@ func (s *State) LoadDevice(
 	return nil
 }
 
+// Login to device and create session.
+// Only policies named "Netspoc..." are managed and hence device name
+// and management state aren't checked.
+func (s *State) CheckAccess(
+	spocFile string, cfg *program.Config, logLogin *os.File,
+) ([]string, error) {
+
+	err := httpdevice.TryReachableHTTPLogin(spocFile, cfg, s.log,
+		func(name, ip, user, pass string) error {
+			s.client, s.prefix = httpdevice.GetHTTPClient(cfg, ip)
+			jar, _ := cookiejar.New(nil)
+			s.client.Jar = jar
+
+			uri := s.prefix + "/api/session/create"
+			errlog.DoLog(logLogin, "POST "+uri)
+			v := url.Values{}
+			v.Set("j_username", user)
+			v.Set("j_password", "xxx")
+			errlog.DoLog(logLogin, v.Encode())
+			v.Set("j_password", pass)
+			resp, err := s.client.PostForm(uri, v)
+			if err != nil {
+				return err
+			}
+			errlog.DoLog(logLogin, resp.Status)
+			if resp.StatusCode != http.StatusOK {
+				return httpdevice.LoginStatusError(resp.StatusCode)
+			}
+			s.token = resp.Header.Get("x-xsrf-token")
+			return nil
+		})
+	return nil, err
+}
+
 func (s *State) getRawJSON(path string) ([]json.RawMessage, error) {
 	var data []json.RawMessage
 	var cursor string
@@ -189,19 +203,46 @@ func (s *State) ShowChanges() string {
 	return collect.String()
 }
 
-func (s *State) ApplyCommands(logFh *os.File) error {
+// ChangeGroups returns all changes as single group, because changed
+// rules depend on changed groups and services.
+func (s *State) ChangeGroups() []program.ChangeGroup {
+	if len(s.changes) == 0 {
+		return nil
+	}
+	return []program.ChangeGroup{
+		{Name: "rules", Category: "rules", Changes: s.ShowChanges()}}
+}
+
+func (s *State) DeselectChanges(name string) {
+	if name == "rules" {
+		s.changes = nil
+	}
+}
+
+func (s *State) ApplyCommands(logFh *os.File, j *journal.Journal) error {
 	for _, c := range s.changes {
-		errlog.DoLog(logFh, fmt.Sprintf("URI: %s %s", c.method, c.url))
+		cmd := c.method + " " + c.url
 		if c.postData != nil {
-			errlog.DoLog(logFh, "DATA: "+string(c.postData))
+			cmd += " " + string(c.postData)
 		}
-		resp, err := s.sendRequest(c.method, c.url, bytes.NewReader(c.postData))
+		err := j.Send(cmd, func() error {
+			errlog.DoLog(logFh, fmt.Sprintf("URI: %s %s", c.method, c.url))
+			if c.postData != nil {
+				errlog.DoLog(logFh, "DATA: "+string(c.postData))
+			}
+			resp, err := s.sendRequest(
+				c.method, c.url, bytes.NewReader(c.postData))
+			if err != nil {
+				return err
+			}
+			if len(resp) != 0 {
+				errlog.DoLog(logFh, "RESP: "+string(resp))
+			}
+			return nil
+		})
 		if err != nil {
 			return err
 		}
-		if len(resp) != 0 {
-			errlog.DoLog(logFh, "RESP: "+string(resp))
-		}
 	}
 	return nil
 }
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 	"github.com/pkg/diff/myers"
 )
 
@@ -103,6 +104,66 @@ func diffConfig(a, b *nsxConfig) []change {
 	return changes
 }
 
+// Protect marks rules on device, that match entries of protect file.
+// Objects matching protect file and objects referenced by protected
+// rules are marked as needed. Hence all these are left unchanged on
+// device. Netspoc must not generate protected rules and objects.
+func (s *State) Protect(l program.ProtectList) error {
+	a, b := s.deviceCfg, s.spocCfg
+	for _, p := range b.Policies {
+		for _, r := range p.Rules {
+			if l.Match("rule", r.Id) {
+				return fmt.Errorf("Must not change protected rule %s of %s",
+					r.Id, p.Id)
+			}
+		}
+	}
+	for _, g := range b.Groups {
+		if l.Match("object", g.Id) {
+			return fmt.Errorf("Must not change protected group %s", g.Id)
+		}
+	}
+	for _, sv := range b.Services {
+		if l.Match("object", sv.Id) {
+			return fmt.Errorf("Must not change protected service %s", sv.Id)
+		}
+	}
+	groups := groupMap(a.Groups)
+	services := serviceMap(a.Services)
+	markNeeded := func(l []string) {
+		for _, n := range l {
+			if g := getGroup(n, groups); g != nil {
+				g.needed = true
+			} else if id, found := strings.CutPrefix(n, "/infra/services/"); found {
+				if sv := services[id]; sv != nil {
+					sv.needed = true
+				}
+			}
+		}
+	}
+	for _, g := range a.Groups {
+		if l.Match("object", g.Id) {
+			g.needed = true
+		}
+	}
+	for _, sv := range a.Services {
+		if l.Match("object", sv.Id) {
+			sv.needed = true
+		}
+	}
+	for _, p := range a.Policies {
+		for _, r := range p.Rules {
+			if l.Match("rule", r.Id) {
+				r.protected = true
+				markNeeded(r.SourceGroups)
+				markNeeded(r.DestinationGroups)
+				markNeeded(r.Services)
+			}
+		}
+	}
+	return nil
+}
+
 func sortGroups(groups []*nsxGroup) {
 	for _, group := range groups {
 		sort.Strings(group.Expression[0].IPAddresses)
@@ -111,7 +172,13 @@ func sortGroups(groups []*nsxGroup) {
 
 func diffPolicies(a, b *nsxPolicy, ab *rulesPair) []change {
 	if b == nil {
-		return deletePolicy(a)
+		if !slices.ContainsFunc(a.Rules, func(r *nsxRule) bool {
+			return r.protected
+		}) {
+			return deletePolicy(a)
+		}
+		// Leave policy with protected rules on device.
+		b = &nsxPolicy{Id: a.Id}
 	}
 	var chgs []change
 	createPolicy := func() {
@@ -130,6 +197,10 @@ func diffPolicies(a, b *nsxPolicy, ab *rulesPair) []change {
 	}
 	ab.policy = a
 	genUniqRuleNames(a.Rules, b.Rules)
+	// Protected rules are left unchanged on device.
+	a.Rules = slices.DeleteFunc(a.Rules, func(r *nsxRule) bool {
+		return r.protected
+	})
 	sortRules(a.Rules, ab.a.groups)
 	sortRules(b.Rules, ab.b.groups)
 	ab.a.rules = a.Rules
@@ -0,0 +1,164 @@
+package nsx
+
+import (
+	"net/netip"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
+)
+
+// ExportRules converts rules of each policy to vendor neutral
+// representation. Referenced groups and services are expanded.
+func (s *State) ExportRules(device bool) *ir.Config {
+	cf := s.spocCfg
+	if device {
+		cf = s.deviceCfg
+	}
+	result := &ir.Config{}
+	if cf == nil {
+		return result
+	}
+	groups := make(map[string]*nsxGroup)
+	for _, g := range cf.Groups {
+		groups[g.Id] = g
+	}
+	services := make(map[string]*nsxService)
+	for _, sv := range cf.Services {
+		services[sv.Id] = sv
+	}
+	// Rules generated by Netspoc have names r1, r2, ...
+	re := regexp.MustCompile(`^r\d`)
+	for _, p := range cf.Policies {
+		rs := &ir.RuleSet{
+			Name: p.Id,
+			// Scope is checked in each rule.
+			Bindings: []*ir.Binding{{}},
+		}
+		for i, r := range p.Rules {
+			if r.Disabled {
+				continue
+			}
+			ru := &ir.Rule{
+				Name:     r.Id,
+				Position: i + 1,
+				Src: convertGroups(
+					r.SourceGroups, r.SourcesExcluded, r.IPProtocol, groups),
+				Dst: convertGroups(
+					r.DestinationGroups, r.DestinationsExcluded, r.IPProtocol, groups),
+				Services: convertServices(r.Services, services),
+				Log:      r.Logged,
+				Raw:      !re.MatchString(r.Id),
+				Orig:     r.Id,
+			}
+			if r.Action == "ALLOW" {
+				ru.Action = ir.Permit
+			} else {
+				ru.Action = ir.Deny
+			}
+			if len(r.ServiceEntries) != 0 {
+				ru.Services = append(ru.Services,
+					&ir.Service{Unknown: "service_entries"})
+			}
+			for _, sc := range r.Scope {
+				if sc != "ANY" {
+					ru.From = append(ru.From, sc)
+				}
+			}
+			rs.Rules = append(rs.Rules, ru)
+		}
+		result.RuleSets = append(result.RuleSets, rs)
+	}
+	return result
+}
+
+func isAnyList(l []string) bool {
+	return len(l) == 0 || len(l) == 1 && l[0] == "ANY"
+}
+
+func convertGroups(
+	l []string, negate bool, ipProto string, groups map[string]*nsxGroup,
+) *ir.AddrSet {
+
+	if isAnyList(l) {
+		a := ir.AnyAddr()
+		switch ipProto {
+		case "IPV4":
+			a.Prefixes = []netip.Prefix{ir.Any4}
+		case "IPV6":
+			a.Prefixes = []netip.Prefix{ir.Any6}
+		}
+		a.Negate = negate
+		return a
+	}
+	a := &ir.AddrSet{Negate: negate}
+	for _, p := range l {
+		g := groups[path.Base(p)]
+		if g == nil {
+			// Address may be given literally in rule.
+			a.Add(p)
+			continue
+		}
+		for _, e := range g.Expression {
+			for _, ip := range e.IPAddresses {
+				a.Add(ip)
+			}
+		}
+	}
+	return a
+}
+
+func convertServices(
+	l []string, services map[string]*nsxService) []*ir.Service {
+
+	if isAnyList(l) {
+		return nil
+	}
+	var result []*ir.Service
+	for _, p := range l {
+		sv := services[path.Base(p)]
+		if sv == nil {
+			result = append(result, &ir.Service{Unknown: p})
+			continue
+		}
+		for _, e := range sv.ServiceEntries {
+			var srv *ir.Service
+			switch e.ResourceType {
+			case "L4PortSetServiceEntry":
+				srv = &ir.Service{Proto: ir.ProtoName(e.L4Protocol)}
+				var err error
+				for _, v := range e.SourcePorts {
+					var r []ir.PortRange
+					if r, err = ir.ParsePorts(v); err != nil {
+						break
+					}
+					srv.SrcPorts = append(srv.SrcPorts, r...)
+				}
+				for _, v := range e.DestinationPorts {
+					var r []ir.PortRange
+					if r, err = ir.ParsePorts(v); err != nil {
+						break
+					}
+					srv.DstPorts = append(srv.DstPorts, r...)
+				}
+				if err != nil {
+					srv.Unknown = sv.Id
+				}
+			case "ICMPTypeServiceEntry":
+				srv = &ir.Service{
+					Proto: ir.ProtoName(e.ICMPProtocol),
+					Type:  e.ICMPType,
+					Code:  e.ICMPCode,
+				}
+			case "IPProtocolServiceEntry":
+				srv = &ir.Service{Proto: ir.ProtoName(
+					strconv.Itoa(e.ProtocolNumber))}
+			default:
+				srv = &ir.Service{Unknown: sv.Id}
+			}
+			result = append(result, srv)
+		}
+	}
+	return result
+}
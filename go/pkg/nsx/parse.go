@@ -7,6 +7,8 @@ import (
 	"path"
 	"regexp"
 	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/codefiles"
 )
 
 type nsxPolicy struct {
@@ -32,6 +34,15 @@ type nsxRule struct {
 	Direction            string          `json:"direction"`
 	IPProtocol           string          `json:"ip_protocol,omitempty"`
 	Revision             int             `json:"_revision,omitempty"`
+	// Attributes from raw file, that control merging with rules
+	// from Netspoc.
+	Replace   bool   `json:"replace,omitempty"`
+	Delete    bool   `json:"delete,omitempty"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+	Prepend   bool   `json:"prepend,omitempty"`
+	Append    bool   `json:"append,omitempty"`
+	protected bool
 }
 
 type nsxGroup struct {
@@ -113,11 +124,16 @@ func (s *State) parseConfig(data []byte, fName string) (*nsxConfig, error) {
 	if len(data) == 0 {
 		return config, nil
 	}
-	err := json.Unmarshal(removeHeader(data), config)
+	isRaw := path.Ext(fName) == ".raw"
+	body := removeHeader(data)
+	err := json.Unmarshal(body, config)
 	if err != nil {
+		if isRaw {
+			err = codefiles.AddJSONLine(data, len(data)-len(body), err)
+		}
 		return nil, err
 	}
-	if path.Ext(fName) == ".raw" {
+	if isRaw {
 		if err := checkRaw(config); err != nil {
 			return nil, err
 		}
@@ -130,6 +146,13 @@ func checkRaw(c *nsxConfig) error {
 	re := regexp.MustCompile(`^r\d`)
 	for _, p := range c.Policies {
 		for _, r := range p.Rules {
+			if err := checkMarkers(r); err != nil {
+				return err
+			}
+			// Rule from Netspoc is replaced or deleted by name.
+			if r.Replace || r.Delete {
+				continue
+			}
 			if re.MatchString(r.Id) {
 				return fmt.Errorf(
 					"Must not use rule name starting with 'r<NUM>': %s",
@@ -160,9 +183,29 @@ func checkRaw(c *nsxConfig) error {
 	return nil
 }
 
+func checkMarkers(r *nsxRule) error {
+	count := 0
+	for _, set := range []bool{r.Replace, r.Delete, r.Before != "", r.After != "",
+		r.Prepend, r.Append} {
+		if set {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf(
+			`Must use only one of "replace", "delete", "before", "after",`+
+				` "prepend", "append" in rule %s`,
+			r.Id)
+	}
+	return nil
+}
+
 func checkConfigValidity(c *nsxConfig) error {
 	for _, p := range c.Policies {
 		for _, r := range p.Rules {
+			if r.Delete {
+				continue
+			}
 			if len(r.SourceGroups) != 1 || len(r.DestinationGroups) != 1 || len(r.Services) != 1 {
 				return fmt.Errorf(
 					"Expecting exactly one element in source/destination/service of rule %s", r.Id)
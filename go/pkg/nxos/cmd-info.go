@@ -0,0 +1,34 @@
+package nxos
+
+func (s *State) GetCmdInfo() string { return cmdInfo }
+
+// Description of commands that will be parsed.
+// See package ios for description of syntax.
+var cmdInfo = `
+[ANCHOR]
+ip_route *
+interface *
+ ip address *
+ shutdown
+ vrf member *
+ ip access-group $ip_access-list in
+ ip access-group $ip_access-list out
+vrf_context *
+ ip route *
+ ipv6 route *
+
+# Entries are shown with sequence number on device.
+# "addrgroup NAME" in * references $object-group, will be resolved later.
+ip_access-list $NAME
+ $SEQ remark *
+ $SEQ permit *
+ $SEQ deny *
+ remark *
+ permit *
+ deny *
+ !statistics *
+
+object-group ip address $NAME
+ $SEQ *
+ *
+`
@@ -0,0 +1,132 @@
+package nxos
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/console"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
+)
+
+type State struct {
+	log *errlog.Logger
+	// Leave checkpoint on device for manual rollback.
+	keepCheckpoint bool
+}
+
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
+
+func (s *State) SetTerminal(conn *console.Conn) error {
+	if err := conn.SendCmd("terminal length 0"); err != nil {
+		return err
+	}
+	return conn.SendCmd("terminal width 511")
+}
+
+func (s *State) CheckDeviceName(name, _ string, conn *console.Conn) error {
+	// Force new prompt by issuing empty command.
+	// Output is: \r\n\s*NAME#\s?
+	out, err := conn.IssueCmd("", `#[ ]?`)
+	if err != nil {
+		return err
+	}
+	out = strings.TrimSuffix(strings.TrimSpace(out), "#")
+	if name != out {
+		return errlog.Classify(errlog.WrongName,
+			fmt.Errorf("Wrong device name: %q, expected: %q", out, name))
+	}
+	return nil
+}
+
+// HA state isn't checked.
+func (s *State) CheckFailover(conn *console.Conn) (string, error) {
+	return "", nil
+}
+
+func (s *State) PrepareDevice(conn *console.Conn) error { return nil }
+func (s *State) RemoveBanner(data []byte) []byte        { return data }
+func (s *State) StripReloadBanner(out string, conn *console.Conn,
+) (string, bool, error) {
+	return out, false, nil
+}
+
+// NX-OS has no "reload in". Instead a checkpoint of running config
+// is created before changes are applied. If some command fails, this
+// checkpoint is restored. If connection to device is lost, the
+// checkpoint is left on device and can be restored manually.
+const checkpoint = "netspoc-approve"
+
+func (s *State) ScheduleReload(conn *console.Conn, _ *program.Config,
+) error {
+	// Remove checkpoint of previous interrupted approve.
+	if _, err := conn.GetCmdOutput("no checkpoint " + checkpoint); err != nil {
+		return err
+	}
+	out, err := conn.GetCmdOutput("checkpoint " + checkpoint)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, "Done") {
+		return fmt.Errorf("Creating checkpoint failed:\n%s", out)
+	}
+	return nil
+}
+
+func (s *State) ExtendReload(conn *console.Conn) error { return nil }
+
+func (s *State) CancelReload(conn *console.Conn) error {
+	if s.keepCheckpoint {
+		return nil
+	}
+	_, err := conn.GetCmdOutput("no checkpoint " + checkpoint)
+	return err
+}
+
+func (s *State) ReconnectOnLoss() bool { return false }
+
+func (s *State) Rollback(conn *console.Conn) {
+	out, err := conn.GetCmdOutput(
+		"rollback running-config checkpoint " + checkpoint)
+	if err != nil {
+		s.log.Warning("Rollback to checkpoint %s failed: %v", checkpoint, err)
+		s.keepCheckpoint = true
+		return
+	}
+	if strings.Contains(out, "Rollback completed successfully") {
+		s.log.Info("Restored running config from checkpoint %s", checkpoint)
+	} else {
+		s.log.Warning("Rollback to checkpoint %s failed:\n%s", checkpoint, out)
+		s.keepCheckpoint = true
+	}
+}
+
+// Output of "copy running-config startup-config":
+// [########################################] 100%
+// Copy complete, now saving to disk (please wait)...
+// Copy complete.
+func (s *State) WriteMem(conn *console.Conn) error {
+	out, err := conn.GetCmdOutput("copy running-config startup-config")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(out, "Copy complete.") {
+		return fmt.Errorf(
+			"copy running-config startup-config: unexpected result: %s", out)
+	}
+	return nil
+}
+
+func (s *State) IsValidOutput(cmd, out string) bool {
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Warning:") {
+			s.log.Warning("Got unexpected output from '%s':\n%s", cmd, line)
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -2,6 +2,9 @@ package panos
 
 import (
 	"fmt"
+	"slices"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 )
 
 func (s *State) LoadNetspoc(data []byte, fName string) error {
@@ -12,7 +15,7 @@ func (s *State) LoadNetspoc(data []byte, fName string) error {
 	if s.spocCfg == nil {
 		s.spocCfg = cfg
 	} else {
-		s.mergeSpoc(cfg)
+		return s.mergeSpoc(cfg)
 	}
 	return nil
 }
@@ -21,10 +24,16 @@ func (s *State) MoveNetspoc2DeviceConfig() {
 	s.deviceCfg, s.spocCfg = s.spocCfg, nil
 }
 
+// DumpNetspoc returns parsed configuration from Netspoc
+// in a form suitable for output as JSON.
+func (s *State) DumpNetspoc() any {
+	return s.spocCfg
+}
+
 // mergeSpoc merges two configurations read from Netspoc.
-func (s *State) mergeSpoc(p2 *panConfig) {
+func (s *State) mergeSpoc(p2 *panConfig) error {
 	p1 := s.spocCfg
-	processVsysPairs(p1, p2, func(v1, v2 *panVsys) error {
+	return processVsysPairs(p1, p2, func(v1, v2 *panVsys) error {
 		// Create empty vsys in p1 to add complete vsys from p2 below.
 		if v1 == nil {
 			if p1.Devices == nil {
@@ -39,24 +48,75 @@ func (s *State) mergeSpoc(p2 *panConfig) {
 			v1.Addresses = append(v1.Addresses, v2.Addresses...)
 			v1.AddressGroups = append(v1.AddressGroups, v2.AddressGroups...)
 			v1.Services = append(v1.Services, v2.Services...)
-			// Add rules.
-			// Rules are prepended per default.
-			// Rules with attribute <APPEND> are appended.
-			var top []*panRule
-			for _, r := range v2.Rules {
-				if r.Append == nil {
-					top = append(top, r)
-				} else {
-					r.Append = nil
-					v1.Rules = append(v1.Rules, r)
-				}
-			}
-			v1.Rules = append(top, v1.Rules...)
+			return mergeRules(v1, v2)
 		}
 		return nil
 	})
 }
 
+// mergeRules adds rules from raw/IPv6 to rules from Netspoc.
+// Rules are prepended per default.
+// Rules with attribute <APPEND> are appended.
+// Rules with attribute <REPLACE> or <DELETE> replace resp. delete
+// rule with same name from Netspoc.
+// Rules with attribute <BEFORE> or <AFTER> are inserted before
+// resp. after rule from Netspoc with given name.
+func mergeRules(v1, v2 *panVsys) error {
+	rules := v1.Rules
+	find := func(name, attr string) (int, error) {
+		i := slices.IndexFunc(rules, func(r *panRule) bool {
+			return r.Name == name
+		})
+		if i == -1 {
+			return i, fmt.Errorf("Unknown rule %q in <%s> of vsys %q",
+				name, attr, v1.Name)
+		}
+		return i, nil
+	}
+	var top, bottom []*panRule
+	for _, r := range v2.Rules {
+		var i int
+		var err error
+		switch {
+		case r.Replace != nil:
+			if i, err = find(r.Name, "REPLACE"); err == nil {
+				rules[i] = r
+			}
+		case r.Delete != nil:
+			if i, err = find(r.Name, "DELETE"); err == nil {
+				rules = slices.Delete(rules, i, i+1)
+			}
+		case r.Before != "":
+			if i, err = find(r.Before, "BEFORE"); err == nil {
+				rules = slices.Insert(rules, i, r)
+			}
+		case r.After != "":
+			if i, err = find(r.After, "AFTER"); err == nil {
+				// Leave order of multiple rules after same rule.
+				i++
+				for i < len(rules) && rules[i].After == r.After {
+					i++
+				}
+				rules = slices.Insert(rules, i, r)
+			}
+		case r.Append != nil:
+			bottom = append(bottom, r)
+		default:
+			top = append(top, r)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for _, r := range v2.Rules {
+		r.Append, r.Prepend, r.Replace = nil, nil, nil
+		r.Before, r.After = "", ""
+	}
+	rules = append(top, rules...)
+	v1.Rules = append(rules, bottom...)
+	return nil
+}
+
 func processVsysPairs(c1, c2 *panConfig, f func(v1, v2 *panVsys) error) error {
 	getDevVsysMap :=
 		func(c *panConfig) (*panDevice, map[string]*panVsys) {
@@ -99,7 +159,8 @@ func (c *panConfig) getDevName() string {
 func (c *panConfig) checkDeviceName(expected string) error {
 	name := c.getDevName()
 	if name != expected {
-		return fmt.Errorf("Wrong device name %q, expected %q", name, expected)
+		return errlog.Classify(errlog.WrongName,
+			fmt.Errorf("Wrong device name %q, expected %q", name, expected))
 	}
 	return nil
 }
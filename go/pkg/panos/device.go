@@ -9,11 +9,13 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/httpdevice"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/journal"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
 
@@ -26,33 +28,20 @@ type State struct {
 	spocCfg      *panConfig
 	changes      []change
 	errUnmanaged []error
+	log          *errlog.Logger
 }
 
+func (s *State) SetLogger(l *errlog.Logger) { s.log = l }
+
 type change struct {
 	Cmds []string
+	vsys string
 }
 
 func (s *State) LoadDevice(
 	path string, cfg *program.Config, logLogin, logConfig *os.File) error {
 
-	devName := ""
-	err := httpdevice.TryReachableHTTPLogin(path, cfg,
-		func(name, ip, user, pass string) error {
-			client, addr := httpdevice.GetHTTPClient(cfg, ip)
-			s.client = client
-			s.devUser = user
-			key, err := s.getAPIKey(addr, user, pass, logLogin)
-			if err != nil {
-				return err
-			}
-			s.url = fmt.Sprintf("%s/api/", addr)
-			s.apiKey = key
-			if !s.checkHA(logLogin) {
-				return fmt.Errorf("not in active state: %s (%s)", ip, name)
-			}
-			devName = name
-			return nil
-		})
+	devName, _, err := s.login(path, cfg, logLogin)
 	if err != nil {
 		return err
 	}
@@ -75,6 +64,48 @@ func (s *State) LoadDevice(
 	return s.deviceCfg.checkDeviceName(devName)
 }
 
+// Login to device and check HA state.
+// Device name is only known from device configuration and isn't checked.
+func (s *State) CheckAccess(
+	path string, cfg *program.Config, logLogin *os.File) ([]string, error) {
+
+	_, ha, err := s.login(path, cfg, logLogin)
+	if err != nil {
+		return nil, err
+	}
+	return []string{"HA state: " + ha}, nil
+}
+
+// Login to first reachable device in active HA state.
+// Return name of device and its HA state.
+func (s *State) login(
+	path string, cfg *program.Config, logLogin *os.File,
+) (string, string, error) {
+
+	devName := ""
+	haState := ""
+	err := httpdevice.TryReachableHTTPLogin(path, cfg, s.log,
+		func(name, ip, user, pass string) error {
+			client, addr := httpdevice.GetHTTPClient(cfg, ip)
+			s.client = client
+			s.devUser = user
+			key, err := s.getAPIKey(addr, user, pass, logLogin)
+			if err != nil {
+				return err
+			}
+			s.url = fmt.Sprintf("%s/api/", addr)
+			s.apiKey = key
+			ok, ha := s.checkHA(logLogin)
+			if !ok {
+				return fmt.Errorf("not in active state: %s (%s)", ip, name)
+			}
+			devName = name
+			haState = ha
+			return nil
+		})
+	return devName, haState, err
+}
+
 func (s *State) getAPIKey(addr, user, pass string, logFH *os.File,
 ) (string, error) {
 	addr += "/api/"
@@ -92,7 +123,11 @@ func (s *State) getAPIKey(addr, user, pass string, logFH *os.File,
 	errlog.DoLog(logFH, loggedBody)
 	if err != nil {
 		msg := err.Error()
-		return "", fmt.Errorf("API key %s", msg)
+		err = fmt.Errorf("API key %s", msg)
+		if strings.HasPrefix(msg, "status code: 403") {
+			err = errlog.Classify(errlog.AuthFailed, err)
+		}
+		return "", err
 	}
 	return parseAPIKey(body)
 }
@@ -114,16 +149,17 @@ func (s *State) getAPIKey(addr, user, pass string, logFH *os.File,
 // </response>
 */
 // Result is true if HA not enabled or if enabled and active.
-func (s *State) checkHA(logFH *os.File) bool {
+// Second result describes HA state.
+func (s *State) checkHA(logFH *os.File) (bool, string) {
 	cmd :=
 		"type=op&cmd=<show><high-availability><state/></high-availability></show>"
 	body, err := s.httpPrefixPostLog(cmd, logFH)
 	if err != nil {
-		return false
+		return false, ""
 	}
 	_, data, err := parseResponse(body)
 	if err != nil {
-		return false
+		return false, ""
 	}
 	type haState struct {
 		Enabled string `xml:"enabled"`
@@ -133,18 +169,19 @@ func (s *State) checkHA(logFH *os.File) bool {
 	ha := new(haState)
 	err = xml.Unmarshal(data, ha)
 	if err != nil {
-		return false
+		return false, ""
 	}
 	if ha.Enabled != "yes" {
-		return true
+		return true, "not enabled"
 	}
+	descr := ha.State + " (" + ha.Mode + ")"
 	switch ha.Mode {
 	case "Active-Passive":
-		return ha.State == "active"
+		return ha.State == "active", descr
 	case "Active-Active":
-		return ha.State == "active-primary"
+		return ha.State == "active-primary", descr
 	}
-	return false
+	return false, descr
 }
 
 func (s *State) GetChanges() error {
@@ -164,7 +201,7 @@ func (s *State) GetChanges() error {
 		xPath := devPath + "/vsys/entry" + nameAttr(v2.Name)
 		l := diffConfig(v1, v2, xPath)
 		if len(l) != 0 {
-			s.changes = append(s.changes, change{l})
+			s.changes = append(s.changes, change{Cmds: l, vsys: v2.Name})
 		}
 		return nil
 	})
@@ -183,7 +220,7 @@ func (s *State) GetErrUnmanaged() []error {
 	return s.errUnmanaged
 }
 
-func (s *State) ApplyCommands(logFH *os.File) error {
+func (s *State) ApplyCommands(logFH *os.File, j *journal.Journal) error {
 	doCmd := func(cmd string) (string, []byte, error) {
 		body, err := s.httpPrefixPostLog(cmd, logFH)
 		if err != nil {
@@ -244,13 +281,18 @@ func (s *State) ApplyCommands(logFH *os.File) error {
 	}
 	for _, chg := range s.changes {
 		for _, cmd := range chg.Cmds {
-			_, _, err := doCmd(cmd)
+			c, _ := url.QueryUnescape(cmd)
+			err := j.Send(c, func() error {
+				_, _, err := doCmd(cmd)
+				return err
+			})
 			if err != nil {
 				return fmt.Errorf("Command failed with %v", err)
 			}
 		}
-		if err := commit(); err != nil {
-			return fmt.Errorf("Commit failed: %v", err)
+		if err := j.Send("commit", commit); err != nil {
+			return errlog.Classify(errlog.CommitFailed,
+				fmt.Errorf("Commit failed: %v", err))
 		}
 	}
 	return nil
@@ -299,12 +341,36 @@ func (s *State) HasChanges() bool {
 func (s *State) ShowChanges() string {
 	var collect strings.Builder
 	for _, chg := range s.changes {
-		for _, c := range chg.Cmds {
-			c, _ = url.QueryUnescape(c)
-			fmt.Fprintln(&collect, c)
-		}
+		collect.WriteString(chg.show())
+	}
+	return collect.String()
+}
+
+func (chg change) show() string {
+	var collect strings.Builder
+	for _, c := range chg.Cmds {
+		c, _ = url.QueryUnescape(c)
+		fmt.Fprintln(&collect, c)
 	}
 	return collect.String()
 }
 
+// ChangeGroups returns changes of each vsys as separate group,
+// because changes of each vsys are committed separately.
+// All groups have category "rules".
+func (s *State) ChangeGroups() []program.ChangeGroup {
+	var result []program.ChangeGroup
+	for _, chg := range s.changes {
+		result = append(result, program.ChangeGroup{
+			Name: chg.vsys, Category: "rules", Changes: chg.show()})
+	}
+	return result
+}
+
+func (s *State) DeselectChanges(name string) {
+	s.changes = slices.DeleteFunc(s.changes, func(chg change) bool {
+		return chg.vsys == name
+	})
+}
+
 func (s *State) CloseConnection() {}
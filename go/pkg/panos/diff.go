@@ -2,8 +2,10 @@ package panos
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 	"github.com/pkg/diff/myers"
 )
 
@@ -14,12 +16,104 @@ func diffConfig(a, b *panVsys, vsysPath string) []string {
 	ab.markObjects(b.Rules)
 	ab.genUniqRuleNames()
 	ab.genUniqGroupNames()
+	// Protected rules are left unchanged on device.
+	ab.a.rules = slices.DeleteFunc(slices.Clone(ab.a.rules),
+		func(r *panRule) bool { return r.protected })
 	ruleCmds := ab.diffRules(vsysPath)
 	result := append(ab.transferNeededObjects(vsysPath), ruleCmds...)
 	result = append(result, ab.removeUnneededObjects(vsysPath)...)
 	return result
 }
 
+// Protect marks rules on device, that match entries of protect file.
+// Objects matching protect file and objects referenced by protected
+// rules are marked as needed. Hence all these are left unchanged on
+// device. Netspoc must not generate protected rules and objects.
+func (s *State) Protect(l program.ProtectList) error {
+	return processVsysPairs(s.deviceCfg, s.spocCfg,
+		func(v1, v2 *panVsys) error {
+			if v2 != nil {
+				if err := checkProtected(v2, l); err != nil {
+					return err
+				}
+			}
+			if v1 != nil {
+				markProtected(v1, l)
+			}
+			return nil
+		})
+}
+
+func checkProtected(v *panVsys, l program.ProtectList) error {
+	for _, r := range v.Rules {
+		if l.Match("rule", r.Name) {
+			return fmt.Errorf("Must not change protected rule %s in vsys %s",
+				r.Name, v.Name)
+		}
+	}
+	for _, name := range objectNames(v) {
+		if l.Match("object", name) {
+			return fmt.Errorf("Must not change protected object %s in vsys %s",
+				name, v.Name)
+		}
+	}
+	return nil
+}
+
+func markProtected(v *panVsys, l program.ProtectList) {
+	addresses := addressMap(v)
+	groups := groupMap(v)
+	services := serviceMap(v)
+	sGroups := sGroupMap(v)
+	var markNeeded func(l []string)
+	markNeeded = func(l []string) {
+		for _, name := range l {
+			if o := addresses[name]; o != nil {
+				o.needed = true
+			} else if o := groups[name]; o != nil && !o.needed {
+				o.needed = true
+				markNeeded(o.Members)
+			} else if o := services[name]; o != nil {
+				o.needed = true
+			} else if o := sGroups[name]; o != nil && !o.needed {
+				o.needed = true
+				markNeeded(o.Members)
+			}
+		}
+	}
+	for _, name := range objectNames(v) {
+		if l.Match("object", name) {
+			markNeeded([]string{name})
+		}
+	}
+	for _, r := range v.Rules {
+		if l.Match("rule", r.Name) {
+			r.protected = true
+			markNeeded(r.Source)
+			markNeeded(r.Destination)
+			markNeeded(r.Service)
+		}
+	}
+}
+
+// objectNames returns names of all objects and groups of v.
+func objectNames(v *panVsys) []string {
+	var names []string
+	for _, o := range v.Addresses {
+		names = append(names, o.Name)
+	}
+	for _, o := range v.AddressGroups {
+		names = append(names, o.Name)
+	}
+	for _, o := range v.Services {
+		names = append(names, o.Name)
+	}
+	for _, o := range v.ServiceGroups {
+		names = append(names, o.Name)
+	}
+	return names
+}
+
 type vsysInfo struct {
 	vsys      *panVsys
 	rules     []*panRule
@@ -0,0 +1,172 @@
+package panos
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/ir"
+)
+
+// ExportRules converts security rules of each vsys to vendor neutral
+// representation. Referenced address groups and service groups are
+// expanded.
+func (s *State) ExportRules(device bool) *ir.Config {
+	cf := s.spocCfg
+	if device {
+		cf = s.deviceCfg
+	}
+	result := &ir.Config{}
+	if cf == nil || cf.Devices == nil {
+		return result
+	}
+	// Rules generated by Netspoc have names r1, r2, ...
+	re := regexp.MustCompile(`^r\d`)
+	for _, d := range cf.Devices.Entries {
+		for _, v := range d.Vsys {
+			rs := &ir.RuleSet{
+				Name: v.Name,
+				// Zones are checked in each rule.
+				Bindings: []*ir.Binding{{}},
+				Default:  ir.Deny,
+			}
+			for i, r := range v.Rules {
+				var negSrc, negDst, disabled bool
+				for _, a := range r.Unknown {
+					if a.XML == "yes" {
+						switch a.XMLName.Local {
+						case "negate-source":
+							negSrc = true
+						case "negate-destination":
+							negDst = true
+						case "disabled":
+							disabled = true
+						}
+					}
+				}
+				if disabled {
+					continue
+				}
+				ru := &ir.Rule{
+					Name:     r.Name,
+					Position: i + 1,
+					Src:      v.convertAddresses(r.Source, negSrc),
+					Dst:      v.convertAddresses(r.Destination, negDst),
+					Services: v.convertServices(r.Service),
+					From:     convertZones(r.From),
+					To:       convertZones(r.To),
+					Log:      r.LogStart == "yes" || r.LogEnd == "yes",
+					Raw:      !re.MatchString(r.Name),
+					Orig:     r.Name,
+				}
+				if r.Action == "allow" {
+					ru.Action = ir.Permit
+				} else {
+					ru.Action = ir.Deny
+				}
+				if !isAnyList(r.Application) {
+					ru.Services = append(ru.Services, &ir.Service{
+						Unknown: "application " + strings.Join(r.Application, ","),
+					})
+				}
+				rs.Rules = append(rs.Rules, ru)
+			}
+			result.RuleSets = append(result.RuleSets, rs)
+		}
+	}
+	return result
+}
+
+func isAnyList(l []string) bool {
+	return len(l) == 0 || len(l) == 1 && l[0] == "any"
+}
+
+func convertZones(l []string) []string {
+	if isAnyList(l) {
+		return nil
+	}
+	return l
+}
+
+func (v *panVsys) convertAddresses(l []string, negate bool) *ir.AddrSet {
+	if isAnyList(l) {
+		a := ir.AnyAddr()
+		a.Negate = negate
+		return a
+	}
+	addresses := make(map[string]*panAddress)
+	for _, o := range v.Addresses {
+		addresses[o.Name] = o
+	}
+	groups := make(map[string]*panAddressGroup)
+	for _, o := range v.AddressGroups {
+		groups[o.Name] = o
+	}
+	a := &ir.AddrSet{Negate: negate}
+	seen := make(map[string]bool)
+	var add func(string)
+	add = func(n string) {
+		if o := addresses[n]; o != nil && o.IpNetmask != "" {
+			a.Add(o.IpNetmask)
+		} else if g := groups[n]; g != nil && !seen[n] {
+			seen[n] = true
+			for _, m := range g.Members {
+				add(m)
+			}
+		} else {
+			// Address may be given literally in rule.
+			a.Add(n)
+		}
+	}
+	for _, n := range l {
+		add(n)
+	}
+	return a
+}
+
+// Predefined services of PAN-OS.
+var predefinedServices = map[string][2]string{
+	"service-http":  {"tcp", "80,8080"},
+	"service-https": {"tcp", "443"},
+}
+
+func (v *panVsys) convertServices(l []string) []*ir.Service {
+	if isAnyList(l) {
+		return nil
+	}
+	services := make(map[string]*panService)
+	for _, o := range v.Services {
+		services[o.Name] = o
+	}
+	groups := make(map[string]*panServiceGroup)
+	for _, o := range v.ServiceGroups {
+		groups[o.Name] = o
+	}
+	var result []*ir.Service
+	seen := make(map[string]bool)
+	var add func(string)
+	add = func(n string) {
+		if o := services[n]; o != nil {
+			if p := o.Protocol.TCP; p != nil {
+				result = append(result, ir.ServiceFromPorts(ir.TCP, "", p.Port))
+			} else if p := o.Protocol.UDP; p != nil {
+				result = append(result, ir.ServiceFromPorts(ir.UDP, "", p.Port))
+			} else {
+				result = append(result, &ir.Service{Unknown: n})
+			}
+		} else if g := groups[n]; g != nil && !seen[n] {
+			seen[n] = true
+			for _, m := range g.Members {
+				add(m)
+			}
+		} else if p, found := predefinedServices[n]; found {
+			result = append(result, ir.ServiceFromPorts(p[0], "", p[1]))
+		} else {
+			// E.g. application-default
+			result = append(result, &ir.Service{Unknown: n})
+		}
+	}
+	for _, n := range l {
+		add(n)
+	}
+	return result
+}
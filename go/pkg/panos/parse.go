@@ -61,6 +61,13 @@ func checkRaw(c *panConfig) error {
 	for _, d := range c.Devices.Entries {
 		for _, v := range d.Vsys {
 			for _, r := range v.Rules {
+				if err := checkMarkers(r); err != nil {
+					return err
+				}
+				// Rule from Netspoc is replaced or deleted by name.
+				if r.Replace != nil || r.Delete != nil {
+					continue
+				}
 				if re.MatchString(r.Name) {
 					return fmt.Errorf(
 						"Must not use rule name starting with 'r<NUM>': %s",
@@ -72,6 +79,24 @@ func checkRaw(c *panConfig) error {
 	return nil
 }
 
+func checkMarkers(r *panRule) error {
+	count := 0
+	for _, set := range []bool{
+		r.Append != nil, r.Prepend != nil, r.Replace != nil,
+		r.Delete != nil, r.Before != "", r.After != "",
+	} {
+		if set {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf(
+			"Must use only one of <APPEND>, <PREPEND>, <REPLACE>, <DELETE>,"+
+				" <BEFORE>, <AFTER> in rule %s", r.Name)
+	}
+	return nil
+}
+
 func parseResponseConfig(body []byte) (*panConfig, error) {
 	_, data, err := parseResponse(body)
 	if err != nil {
@@ -86,7 +111,7 @@ func parseResponseConfig(body []byte) (*panConfig, error) {
 }
 
 type PanResponse struct {
-	XMLName xml.Name   `xml:"response"`
+	XMLName xml.Name   `xml:"response" json:"-"`
 	Status  string     `xml:"status,attr"`
 	Msg     string     `xml:"msg"`
 	Result  *panResult `xml:"result"`
@@ -106,13 +131,13 @@ type PanResultDevices struct {
 }
 
 type panConfig struct {
-	XMLName xml.Name    `xml:"config"`
+	XMLName xml.Name    `xml:"config" json:"-"`
 	Devices *panDevices `xml:"devices"`
 	origin  string
 }
 
 type panDevices struct {
-	XMLName xml.Name     `xml:"devices"`
+	XMLName xml.Name     `xml:"devices" json:"-"`
 	Entries []*panDevice `xml:"entry"`
 }
 
@@ -134,7 +159,7 @@ type panVsys struct {
 }
 
 type panRule struct {
-	XMLName xml.Name `xml:"entry"`
+	XMLName xml.Name `xml:"entry" json:"-"`
 	Name    string   `xml:"name,attr"`
 	Action  string   `xml:"action"`
 	From    []string `xml:"from>member"`
@@ -148,8 +173,16 @@ type panRule struct {
 	LogSetting  string   `xml:"log-setting,omitempty"`
 	RuleType    string   `xml:"rule-type,omitempty"`
 	Unknown     RuleAttr `xml:",any"`
-	// Artifical attribute in raw files
-	Append *struct{} `xml:"APPEND,omitempty"`
+	// Artifical attributes in raw files, that control merging with
+	// rules from Netspoc. Rules are prepended per default.
+	Append  *struct{} `xml:"APPEND,omitempty"`
+	Prepend *struct{} `xml:"PREPEND,omitempty"`
+	Replace *struct{} `xml:"REPLACE,omitempty"`
+	Delete  *struct{} `xml:"DELETE,omitempty"`
+	Before  string    `xml:"BEFORE,omitempty"`
+	After   string    `xml:"AFTER,omitempty"`
+	// Rule on device is left unchanged.
+	protected bool
 }
 
 type panList interface {
@@ -180,7 +213,7 @@ type panMembers struct {
 }
 
 type panAddress struct {
-	XMLName   xml.Name  `xml:"entry"`
+	XMLName   xml.Name  `xml:"entry" json:"-"`
 	Name      string    `xml:"name,attr"`
 	IpNetmask string    `xml:"ip-netmask,omitempty"`
 	Unknown   OtherAttr `xml:",any"`
@@ -189,7 +222,7 @@ type panAddress struct {
 }
 
 type panAddressGroup struct {
-	XMLName      xml.Name  `xml:"entry"`
+	XMLName      xml.Name  `xml:"entry" json:"-"`
 	Name         string    `xml:"name,attr"`
 	Members      []string  `xml:"static>member"`
 	Unknown      OtherAttr `xml:",any"`
@@ -198,7 +231,7 @@ type panAddressGroup struct {
 }
 
 type panService struct {
-	XMLName  xml.Name    `xml:"entry"`
+	XMLName  xml.Name    `xml:"entry" json:"-"`
 	Name     string      `xml:"name,attr"`
 	Protocol panProtocol `xml:"protocol"`
 	Unknown  OtherAttr   `xml:",any"`
@@ -207,7 +240,7 @@ type panService struct {
 }
 
 type panServiceGroup struct {
-	XMLName xml.Name  `xml:"entry"`
+	XMLName xml.Name  `xml:"entry" json:"-"`
 	Name    string    `xml:"name,attr"`
 	Members []string  `xml:"members>member"`
 	Unknown OtherAttr `xml:",any"`
@@ -0,0 +1,93 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path"
+	"slices"
+	"strings"
+)
+
+var authActions = []string{"approve", "check-access", "compare", "force"}
+
+// Format of authorization file
+//   - multiple lines
+//   - three fields, separated by whitespace: pattern subject actions
+//   - Pattern may contain shell wildcard characters.
+//   - Subject is name of user, '@' followed by name of group of
+//     user or '*' for any user.
+//   - Actions is comma separated list of approve, check-access, compare
+//     and force. Action force allows approve with option --force.
+//   - If current device name matches pattern and subject matches user,
+//     then actions are allowed.
+//   - Actions of all matching lines are allowed.
+//
+// Groups of user are given by caller, e.g. Unix groups of user.
+// A missing authorization file allows each action for each user.
+func (c *Config) Authorize(
+	userName string, groups []string, device, action string,
+) error {
+
+	file := path.Join(c.BaseDir, "authorization")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("Can't %v", err)
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			return fmt.Errorf("Expected 3 fields in line %d of %s", i+1, file)
+		}
+		matched, err := path.Match(parts[0], device)
+		if err != nil {
+			return fmt.Errorf("Invalid pattern %q in line %d of %s",
+				parts[0], i+1, file)
+		}
+		actions := strings.Split(parts[2], ",")
+		for _, a := range actions {
+			if !slices.Contains(authActions, a) {
+				return fmt.Errorf("Unknown action %q in line %d of %s",
+					a, i+1, file)
+			}
+		}
+		if !matched || !slices.Contains(actions, action) {
+			continue
+		}
+		subject := parts[1]
+		if g, found := strings.CutPrefix(subject, "@"); found {
+			if slices.Contains(groups, g) {
+				return nil
+			}
+		} else if subject == "*" || subject == userName {
+			return nil
+		}
+	}
+	return fmt.Errorf("Not authorized to %s device %q", action, device)
+}
+
+// UnixGroups returns names of Unix groups of user.
+func UnixGroups(name string) []string {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil
+	}
+	ids, _ := u.GroupIds()
+	var result []string
+	for _, id := range ids {
+		if g, err := user.LookupGroupId(id); err == nil {
+			result = append(result, g.Name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package program
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ChangeGroup is part of the changes of a device, that is shown and
+// selected as a whole in interactive approve.
+type ChangeGroup struct {
+	Name     string // Name of group, e.g. "routes" or name of vsys
+	Category string // Category of changed objects, e.g. "routes"
+	Changes  string // Changes in same format as from ShowChanges
+}
+
+// ChangeCategories lists names of change groups of all device
+// models.
+// - acl: ACLs, object-groups and other objects of Cisco device
+// - crypto: crypto maps and tunnel-groups of Cisco device
+// - iptables: iptables of Linux device
+// - routes: routes of Cisco, Checkpoint and Linux device
+// - rules: rules and objects of Checkpoint, NSX and PAN-OS device
+var ChangeCategories = []string{"acl", "crypto", "iptables", "routes", "rules"}
+
+// CheckCategories checks values of command line options
+// --only and --exclude.
+func (c *Config) CheckCategories() error {
+	for _, name := range slices.Concat(c.Only, c.Exclude) {
+		if !slices.Contains(ChangeCategories, name) {
+			return fmt.Errorf("Unknown category %q, expected one of: %s",
+				name, strings.Join(ChangeCategories, ", "))
+		}
+	}
+	return nil
+}
+
+// IsSelected reports whether changes of given category are
+// selected by command line options --only and --exclude.
+func (c *Config) IsSelected(name string) bool {
+	if c.Only != nil && !slices.Contains(c.Only, name) {
+		return false
+	}
+	return !slices.Contains(c.Exclude, name)
+}
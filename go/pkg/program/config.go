@@ -31,9 +31,21 @@ type Config struct {
 	LoginTimeout int
 	keepHistory  int
 	compressAt   int
+	// Restore running config of ASA after this many minutes,
+	// if approve doesn't finish.
+	RestoreMinutes int
 	// Is only set by command line option -u.
 	User     string
 	Password string
+	// Is only set by command line option -i.
+	Interactive bool
+	// Are only set by command line options --only and --exclude.
+	Only    []string
+	Exclude []string
+	// Is only set by command line option --resume.
+	Resume bool
+	// Is only set by command line option --force.
+	Force bool
 }
 
 // Use most specific config file; ignore others.
@@ -118,6 +130,8 @@ func LoadConfig() (*Config, error) {
 			c.Timeout, err = getInt()
 		case "login_timeout":
 			c.LoginTimeout, err = getInt()
+		case "restore_minutes":
+			c.RestoreMinutes, err = getInt()
 		case "keep_history":
 			c.keepHistory, err = getInt()
 		case "compress_at":
@@ -184,6 +198,8 @@ func (c *Config) GetVal(key string) string {
 		return strconv.Itoa(c.Timeout)
 	case "login_timeout":
 		return strconv.Itoa(c.LoginTimeout)
+	case "restore_minutes":
+		return strconv.Itoa(c.RestoreMinutes)
 	case "keep_history":
 		return strconv.Itoa(c.keepHistory)
 	case "compress_at":
@@ -0,0 +1,107 @@
+package program
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Protect describes part of device configuration, that must neither
+// be changed nor deleted by approve.
+type Protect struct {
+	Kind   string // One of "acl", "chain", "object", "route", "rule"
+	Name   string // Shell pattern for name of protected entry
+	prefix netip.Prefix
+}
+
+type ProtectList []Protect
+
+var protectKinds = []string{"acl", "chain", "object", "route", "rule"}
+
+// Format of protect file
+// - multiple lines
+// - three fields, separated by whitespace: pattern kind name
+// - If current device name matches pattern, then entry is used.
+// - All matching lines are used.
+// - Pattern and name may contain shell wildcard characters.
+// - Kind is one of
+//   - acl: name of ACL of Cisco device; its access-group is
+//     protected as well
+//   - chain: name of iptables chain of Linux device
+//   - object: name of object, group or object-group
+//   - rule: name of rule of Checkpoint, PAN-OS or NSX device
+//   - route: IP prefix; routes to this prefix and to more specific
+//     prefixes are protected
+//
+// Kinds not known by model of current device are ignored.
+// A missing protect file is not an error.
+func (c *Config) GetProtected(name string) (ProtectList, error) {
+	file := path.Join(c.BaseDir, "protect")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Can't %v", err)
+	}
+	var result ProtectList
+	lines := strings.Split(string(data), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("Expected 3 fields in lines of %s", file)
+		}
+		matched, err := path.Match(parts[0], name)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern '%s' in %s", parts[0], file)
+		}
+		p := Protect{Kind: parts[1], Name: parts[2]}
+		if !slices.Contains(protectKinds, p.Kind) {
+			return nil, fmt.Errorf("Unknown kind '%s' in %s", p.Kind, file)
+		}
+		if p.Kind == "route" {
+			p.prefix, err = netip.ParsePrefix(p.Name)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid prefix '%s' in %s", p.Name, file)
+			}
+		} else if _, err := path.Match(p.Name, ""); err != nil {
+			return nil, fmt.Errorf("Invalid pattern '%s' in %s", p.Name, file)
+		}
+		if matched {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// Match reports whether some entry of given kind matches name.
+func (l ProtectList) Match(kind, name string) bool {
+	for _, p := range l {
+		if p.Kind == kind {
+			if m, _ := path.Match(p.Name, name); m {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchRoute reports whether route to dst is protected.
+func (l ProtectList) MatchRoute(dst netip.Prefix) bool {
+	for _, p := range l {
+		if p.Kind == "route" && dst.IsValid() &&
+			dst.Bits() >= p.prefix.Bits() && p.prefix.Contains(dst.Addr()) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path"
 
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/mytime"
 	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
 )
@@ -13,23 +14,37 @@ type action struct {
 	Result string `json:"result"`
 	Policy string `json:"policy"`
 	Time   int64  `json:"time"`
+	Error  string `json:"error,omitempty"` // Class of error, if failed
 }
 type status struct {
 	Approve action `json:"approve"`
 	Compare action `json:"compare"`
 }
 
-func SetApprove(cfg *program.Config, device, policy string, failed bool) {
+// SetApprove records result of approve. Class of error is 0 for
+// successful approve. Partial approve, where some categories of
+// changes were left unapplied, is recorded as "PARTIAL".
+func SetApprove(
+	cfg *program.Config, device, policy string, class errlog.Class,
+	partial bool,
+) error {
 	v := Read(cfg, device)
 	result := "OK"
-	if failed {
+	if class != 0 {
 		result = "FAILED"
+	} else if partial {
+		result = "PARTIAL"
 	}
-	v.Approve = action{result, policy, mytime.Now().Unix()}
-	write(cfg, device, v)
+	v.Approve = action{result, policy, mytime.Now().Unix(), class.String()}
+	return write(cfg, device, v)
 }
 
-func SetCompare(cfg *program.Config, device, policy string, changed bool) {
+// SetCompare records result of compare. Class of error is 0 for
+// successful compare.
+func SetCompare(
+	cfg *program.Config, device, policy string, changed bool,
+	class errlog.Class,
+) error {
 	v := Read(cfg, device)
 	result := ""
 	if !changed {
@@ -40,10 +55,15 @@ func SetCompare(cfg *program.Config, device, policy string, changed bool) {
 		// - or device was approved since last compare.
 		result = "DIFF"
 	} else {
-		return
+		// Record changed class of error, but leave time unchanged.
+		if v.Compare.Error != class.String() {
+			v.Compare.Error = class.String()
+			return write(cfg, device, v)
+		}
+		return nil
 	}
-	v.Compare = action{result, policy, mytime.Now().Unix()}
-	write(cfg, device, v)
+	v.Compare = action{result, policy, mytime.Now().Unix(), class.String()}
+	return write(cfg, device, v)
 }
 
 func Read(cfg *program.Config, device string) status {
@@ -54,12 +74,10 @@ func Read(cfg *program.Config, device string) status {
 	return v
 }
 
-func write(cfg *program.Config, device string, v status) {
+func write(cfg *program.Config, device string, v status) error {
 	statusDir := path.Join(cfg.BaseDir, "status")
 	os.Mkdir(statusDir, 0755)
 	fname := path.Join(statusDir, device)
 	data, _ := json.Marshal(v)
-	if err := os.WriteFile(fname, data, 0644); err != nil {
-		panic(err)
-	}
+	return os.WriteFile(fname, data, 0644)
 }
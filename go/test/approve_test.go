@@ -9,6 +9,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -45,9 +46,11 @@ type descr struct {
 	Options   string
 	Params    string
 	Setup     string
+	Input     string
 	Output    string
 	Warning   string
 	Error     string
+	Exit      string
 	DoApprove bool
 	Todo      bool
 }
@@ -161,9 +164,12 @@ func runTest(t *testing.T, d descr, devType string) {
 		// Prepare simulation.
 		// Tell approve command to use simulation by setting environment variable.
 		if talksHTTPS(devType) {
-			httpServer = httpsim.NewTLSServer(t, sc)
-			defer httpServer.Close()
-			os.Setenv("SIMULATE_ROUTER", httpServer.URL)
+			// Scenario NONE is used for commands that don't access device.
+			if sc != "NONE" {
+				httpServer = httpsim.NewTLSServer(t, sc)
+				defer httpServer.Close()
+				os.Setenv("SIMULATE_ROUTER", httpServer.URL)
+			}
 		} else {
 			scenarioFile := "scenario"
 			if err := os.WriteFile(scenarioFile, []byte(sc), 0644); err != nil {
@@ -236,6 +242,18 @@ timeout = 1
 		}
 	}
 
+	// Provide input for interactive approve.
+	// Use pipe, because STDIN must not be a terminal in tests.
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, d.Input)
+	w.Close()
+	prevStdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = prevStdin; r.Close() }()
+
 	// Call main function.
 	var status int
 	var stdout string
@@ -255,6 +273,11 @@ timeout = 1
 	}
 
 	// Check result.
+	if d.Exit != "" {
+		t.Run("Exit", func(t *testing.T) {
+			countEq(t, d.Exit, strconv.Itoa(status))
+		})
+	}
 	if status == 0 {
 		if d.Error != "" {
 			t.Error("Unexpected success")
@@ -13,6 +13,7 @@ import (
 // It writes CRLF and echoes input, supports <!> interactive markers and
 // banners.
 // Prompts are emitted as "DEVICE#".
+// Marker <prompt:TEXT> in output changes following prompts to "TEXT#".
 
 type Simulator struct {
 	device   string
@@ -149,6 +150,7 @@ type fakeExpecter struct {
 	inputSegments   []string // Current segments split by <!>
 	inputIdx        int      // Current position in input segments
 	needsPrompt     bool     // True if we need to add prompt after input segments complete
+	prompt          string   // Prompt without trailing "#"
 }
 
 // newFakeExpecter creates a fake expecter backed by the simulator.
@@ -164,6 +166,7 @@ func newFakeExpecter(
 	f := &fakeExpecter{
 		sim:     sim,
 		timeout: timeout,
+		prompt:  device,
 	}
 
 	// Process preamble immediately
@@ -179,10 +182,17 @@ func newFakeExpecter(
 	return f, cleanup, nil
 }
 
+var rePromptMarker = regexp.MustCompile(`<prompt:([^>]*)>\n?`)
+
 // sendText processes text for output, handling <!> markers and CRLF conversion.
 // If the text contains <!> markers, it writes the first segment and sets up
 // for interactive input. Otherwise, it writes all text to the buffer.
 func (f *fakeExpecter) sendText(text string, addPrompt bool) {
+	// Handle <prompt:TEXT> markers - these change the device prompt.
+	if m := rePromptMarker.FindAllStringSubmatch(text, -1); m != nil {
+		f.prompt = m[len(m)-1][1]
+		text = rePromptMarker.ReplaceAllString(text, "")
+	}
 	// Process output, replacing LF with CRLF
 	text = strings.ReplaceAll(text, "\n", "\r\n")
 	// Handle <!> markers - these indicate interactive prompts
@@ -265,7 +275,7 @@ func (f *fakeExpecter) Send(cmd string) error {
 			f.waitingForInput = false
 			// If we need to add a prompt after segments complete
 			if f.needsPrompt {
-				f.buffer.WriteString(f.sim.device + "#")
+				f.buffer.WriteString(f.prompt + "#")
 				f.needsPrompt = false
 			}
 		}
@@ -302,7 +312,7 @@ func (f *fakeExpecter) Send(cmd string) error {
 	}
 
 	// Finally, print the device prompt (no line ending in scenario)
-	f.buffer.WriteString(f.sim.device + "#")
+	f.buffer.WriteString(f.prompt + "#")
 
 	return nil
 }
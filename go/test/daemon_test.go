@@ -0,0 +1,202 @@
+package approve_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/daemon"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/device"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
+	"github.com/hknutzen/testtxt"
+)
+
+func TestDaemon(t *testing.T) {
+	// Use absolute basedir, so no file is written to current directory.
+	workDir := t.TempDir()
+	testtxt.PrepareFileOrDir(t, workDir,
+		strings.Replace(daemonFiles, "basedir = .", "basedir = "+workDir, 1))
+	os.Symlink("p1", path.Join(workDir, "policies/current"))
+	t.Setenv("SIMULATE_ROUTER", path.Join(workDir, "scenario"))
+	t.Setenv("TEST_TIME", "2024-Sep-29 16:19:50")
+	t.Setenv("HOME", workDir)
+	cfg, err := program.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(daemon.NewHandler(cfg, 2))
+	defer srv.Close()
+
+	request := func(method, url, token, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+url, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		// Normalize file names in messages.
+		return resp.StatusCode, strings.ReplaceAll(string(data), workDir+"/", "")
+	}
+	check := func(method, url, token, body string, code int, expected string) {
+		t.Helper()
+		gotCode, got := request(method, url, token, body)
+		if gotCode != code {
+			t.Errorf("%s %s: expected status %d, got %d: %s",
+				method, url, code, gotCode, got)
+		}
+		if got != expected {
+			t.Errorf("%s %s: expected\n%s\ngot\n%s", method, url, expected, got)
+		}
+	}
+
+	check("GET", "/status/router", "", "", http.StatusUnauthorized,
+		`{"error":"Authentication failed"}`+"\n")
+	check("POST", "/jobs", "read-token",
+		`{"action":"approve","device":"router"}`, http.StatusForbidden,
+		`{"error":"User \"bob\" must not approve"}`+"\n")
+	check("POST", "/jobs", "alice-token",
+		`{"action":"approve","device":"router","force":true}`,
+		http.StatusForbidden,
+		`{"error":"User \"alice\" must not force"}`+"\n")
+	check("POST", "/jobs", "alice-token",
+		`{"action":"compare","device":"router","force":true}`,
+		http.StatusBadRequest,
+		`{"error":"Must use 'only', 'exclude', 'resume' and 'force' only with approve"}`+"\n")
+	check("POST", "/jobs", "read-token",
+		`{"action":"compare","device":"../router"}`, http.StatusBadRequest,
+		`{"error":"Invalid device name \"../router\""}`+"\n")
+	check("POST", "/jobs", "alice-token",
+		`{"action":"compare","device":"router"}`, http.StatusAccepted,
+		`{"id":1,"user":"alice","action":"compare","device":"router",`+
+			`"state":"queued","output":""}`+"\n")
+	check("GET", "/jobs/1/log", "alice-token", "", http.StatusOK,
+		`Requesting device config
+Got device config
+Parsed device config
+comp: *** device changed ***
+`)
+	check("GET", "/jobs/1", "read-token", "", http.StatusForbidden,
+		`{"error":"User \"bob\" must not read job 1"}`+"\n")
+	check("GET", "/jobs/1/log", "read-token", "", http.StatusForbidden,
+		`{"error":"User \"bob\" must not read job 1"}`+"\n")
+	check("GET", "/jobs/1", "alice-token", "", http.StatusOK,
+		`{"id":1,"user":"alice","action":"compare","device":"router",`+
+			`"state":"finished","status":0,`+
+			`"output":"comp: *** device changed ***\nOK, details in `+
+			`policies/p1/log/router.compare\n"}`+"\n")
+	check("POST", "/jobs", "read-token",
+		`{"action":"compare","device":"router"}`, http.StatusAccepted,
+		`{"id":2,"user":"bob","action":"compare","device":"router",`+
+			`"state":"queued","output":""}`+"\n")
+	check("GET", "/jobs/2/log", "read-token", "", http.StatusOK, "")
+	check("GET", "/jobs/2", "read-token", "", http.StatusOK,
+		`{"id":2,"user":"bob","action":"compare","device":"router",`+
+			`"state":"finished","status":1,`+
+			`"output":"Error: Not authorized to compare device \"router\"\n"}`+
+			"\n")
+	check("GET", "/jobs/2", "admin-token", "", http.StatusOK,
+		`{"id":2,"user":"bob","action":"compare","device":"router",`+
+			`"state":"finished","status":1,`+
+			`"output":"Error: Not authorized to compare device \"router\"\n"}`+
+			"\n")
+	check("GET", "/jobs/3", "read-token", "", http.StatusNotFound,
+		`{"error":"Unknown job"}`+"\n")
+	check("GET", "/status/router", "read-token", "", http.StatusOK,
+		`{"approve":{"result":"","policy":"","time":0},`+
+			`"compare":{"result":"DIFF","policy":"p1","time":1727626790}}`+"\n")
+	_, got := request("GET", "/history/router", "read-token", "")
+	var lines []string
+	json.Unmarshal([]byte(got), &lines)
+	expected := []string{
+		"2024 09 29 16:19:50 START: compare router",
+		"2024 09 29 16:19:50 USER: alice",
+		"2024 09 29 16:19:50 POLICY: p1",
+		"2024 09 29 16:19:50 RES: comp: *** device changed ***",
+		"2024 09 29 16:19:50 END: OK",
+		"2024 09 29 16:19:50 START: compare router",
+		"2024 09 29 16:19:50 USER: bob",
+		`2024 09 29 16:19:50 DENIED: Not authorized to compare device "router"`,
+		"2024 09 29 16:19:50 END: DENIED",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Unexpected history:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// Job waits while device is locked by do-approve.
+	lockFH, err := device.SetLock("router", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("POST", "/jobs", "alice-token",
+		`{"action":"compare","device":"router"}`, http.StatusAccepted,
+		`{"id":3,"user":"alice","action":"compare","device":"router",`+
+			`"state":"queued","output":""}`+"\n")
+	check("GET", "/jobs/3", "alice-token", "", http.StatusOK,
+		`{"id":3,"user":"alice","action":"compare","device":"router",`+
+			`"state":"queued","output":""}`+"\n")
+	lockFH.Close()
+	check("GET", "/jobs/3/log", "alice-token", "", http.StatusOK,
+		`Requesting device config
+Got device config
+Parsed device config
+comp: *** device changed ***
+`)
+	// Only last two finished jobs are remembered.
+	check("GET", "/jobs/1", "alice-token", "", http.StatusNotFound,
+		`{"error":"Unknown job"}`+"\n")
+	check("GET", "/jobs/2", "admin-token", "", http.StatusOK,
+		`{"id":2,"user":"bob","action":"compare","device":"router",`+
+			`"state":"finished","status":1,`+
+			`"output":"Error: Not authorized to compare device \"router\"\n"}`+
+			"\n")
+
+	// Groups of user are taken from file api-users.
+	check("POST", "/jobs", "carol-token",
+		`{"action":"compare","device":"router"}`, http.StatusAccepted,
+		`{"id":4,"user":"carol","action":"compare","device":"router",`+
+			`"state":"queued","output":""}`+"\n")
+	check("GET", "/jobs/4/log", "carol-token", "", http.StatusOK,
+		`Requesting device config
+Got device config
+Parsed device config
+comp: *** device changed ***
+`)
+}
+
+var daemonFiles = `--scenario
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+--credentials
+* admin secret
+--authorization
+router alice compare,approve
+router @wan  compare
+--api-users
+# user token actions
+alice alice-token compare,approve
+bob   read-token  compare
+admin admin-token all-jobs
+carol carol-token compare    wan,dmz
+--.netspoc-approve
+basedir = .
+checkbanner = NetSPoC
+systemuser = admin
+timeout = 1
+--policies/p1/code/router
+ip route 10.0.0.0 255.0.0.0 10.11.22.33
+--policies/p1/code/router.info
+{ "model": "IOS", "name_list": [ "router" ], "ip_list": [ "10.1.13.33" ] }
+`
@@ -0,0 +1,160 @@
+package approve_test
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/device"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/errlog"
+	"github.com/hknutzen/Netspoc-Approve/go/pkg/program"
+	"github.com/hknutzen/testtxt"
+)
+
+// Run several sessions concurrently in one process.
+// Each session writes messages to its own logger.
+func TestSession(t *testing.T) {
+	workDir := t.TempDir()
+	testtxt.PrepareFileOrDir(t, workDir, sessionFiles)
+	t.Setenv("SIMULATE_ROUTER", path.Join(workDir, "scenario"))
+	t.Setenv("HOME", workDir)
+	prevDir, _ := os.Getwd()
+	defer func() { os.Chdir(prevDir) }()
+	os.Chdir(workDir)
+	cfg, err := program.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User = "adm"
+	cfg.Password = "secret"
+
+	type result struct {
+		changed bool
+		err     error
+		log     bytes.Buffer
+	}
+	devices := []string{"r1", "r2", "r3", "unknown"}
+	results := make([]result, len(devices))
+	var wg sync.WaitGroup
+	for i, name := range devices {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &results[i]
+			log := errlog.NewLogger(&r.log, false)
+			s, err := device.NewSession("code/"+name, cfg, "", log)
+			if err != nil {
+				r.err = err
+				return
+			}
+			defer s.Close()
+			r.changed, r.err = s.Compare()
+		}()
+	}
+	wg.Wait()
+
+	check := func(i int, changed bool, class errlog.Class, log string) {
+		t.Helper()
+		r := &results[i]
+		if r.changed != changed {
+			t.Errorf("%s: expected changed=%v", devices[i], changed)
+		}
+		if c := errlog.ClassOf(r.err); r.err != nil && c != class ||
+			r.err == nil && class != 0 {
+			t.Errorf("%s: unexpected error %v, class %v", devices[i], r.err, c)
+		}
+		if got := r.log.String(); got != log {
+			t.Errorf("%s: expected log %q, got %q", devices[i], log, got)
+		}
+	}
+	check(0, true, 0, `Requesting device config
+Got device config
+Parsed device config
+comp: *** device changed ***
+`)
+	check(1, false, 0, `Requesting device config
+Got device config
+Parsed device config
+comp: device unchanged
+`)
+	check(2, false, errlog.NetspocFile, "")
+	check(3, false, errlog.Other, "")
+	if msg := results[2].err.Error(); msg !=
+		"While reading file r3.raw: Can't replace unknown 'object-group g1' from raw" {
+		t.Errorf("r3: unexpected error %q", msg)
+	}
+}
+
+// Operator of interactive approve talks to session by given
+// input and output.
+func TestSessionOperator(t *testing.T) {
+	workDir := t.TempDir()
+	testtxt.PrepareFileOrDir(t, workDir, sessionFiles)
+	t.Setenv("SIMULATE_ROUTER", path.Join(workDir, "scenario"))
+	t.Setenv("HOME", workDir)
+	prevDir, _ := os.Getwd()
+	defer func() { os.Chdir(prevDir) }()
+	os.Chdir(workDir)
+	cfg, err := program.LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.User = "adm"
+	cfg.Password = "secret"
+	cfg.Interactive = true
+
+	var logBuf, out bytes.Buffer
+	log := errlog.NewLogger(&logBuf, true)
+	s, err := device.NewSession("code/r1", cfg, "", log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.SetOperator(strings.NewReader("n\n"), &out)
+	err = s.Approve()
+	if err == nil || err.Error() != "Approve cancelled by operator" {
+		t.Errorf("unexpected error %v", err)
+	}
+	expected := `### Changes of routes
+ip route 10.0.0.0 255.0.0.0 10.11.22.33
+Apply changes to r1? [y]es, [n]o, [s]elect: n
+`
+	if got := out.String(); got != expected {
+		t.Errorf("expected output %q, got %q", expected, got)
+	}
+}
+
+var sessionFiles = `--scenario
+Enter Password:<!>
+banner motd  managed by NetSPoC
+rtr>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+--credentials
+* adm secret
+--.netspoc-approve
+basedir = .
+checkbanner = NetSPoC
+systemuser = none
+timeout = 1
+--code/r1
+ip route 10.0.0.0 255.0.0.0 10.11.22.33
+--code/r1.info
+{ "model": "IOS", "name_list": [ "r1" ], "ip_list": [ "10.1.13.1" ] }
+--code/r2
+--code/r2.info
+{ "model": "IOS", "name_list": [ "r2" ], "ip_list": [ "10.1.13.2" ] }
+--code/r3
+--code/r3.raw
+[REPLACE]
+object-group network g1
+ network-object host 10.0.1.11
+--code/r3.info
+{ "model": "ASA", "name_list": [ "r3" ], "ip_list": [ "10.1.13.3" ] }
+--code/unknown
+--code/unknown.info
+{ "model": "Unknown", "name_list": [ "unknown" ], "ip_list": [ "10.1.13.4" ] }
+`
@@ -0,0 +1,254 @@
+############################################################
+=TITLE=Dump parsed device config
+=DEVICE=
+hostname router
+interface Ethernet0/0
+ nameif inside
+ security-level 100
+ speed 1000
+logging enable
+route inside 10.20.0.0 255.255.255.0 10.1.2.3
+object-group network g2
+ network-object host 10.2.2.2
+access-list x extended permit ip object-group g2 any4
+tunnel-group 193.155.130.20 type ipsec-l2l
+tunnel-group 193.155.130.20 ipsec-attributes
+ ikev2 local-authentication certificate Trustpoint2
+ ikev2 remote-authentication certificate
+=NETSPOC=NONE
+=OPTIONS=--dump
+=OUTPUT=
+{
+	"lookup": {
+		"access-list": {
+			"x": [
+				{
+					"orig": "access-list x extended permit ip object-group g2 any4",
+					"parsed": "access-list $NAME extended permit ip object-group $REF any4",
+					"name": "x",
+					"ref": [
+						"g2"
+					],
+					"clear_conf": true
+				}
+			]
+		},
+		"interface": {
+			"": [
+				{
+					"orig": "interface Ethernet0/0",
+					"parsed": "interface Ethernet0/0",
+					"anchor": true,
+					"sub": [
+						{
+							"orig": "nameif inside",
+							"parsed": "nameif inside",
+							"anchor": true
+						}
+					],
+					"ignored": [
+						"security-level 100",
+						"speed 1000"
+					]
+				}
+			]
+		},
+		"object-group": {
+			"g2": [
+				{
+					"orig": "object-group network g2",
+					"parsed": "object-group network $NAME",
+					"name": "g2",
+					"sub": [
+						{
+							"orig": "network-object host 10.2.2.2",
+							"parsed": "network-object host 10.2.2.2"
+						}
+					]
+				}
+			]
+		},
+		"route": {
+			"": [
+				{
+					"orig": "route inside 10.20.0.0 255.255.255.0 10.1.2.3",
+					"parsed": "route inside 10.20.0.0 255.255.255.0 10.1.2.3",
+					"anchor": true
+				}
+			]
+		},
+		"tunnel-group": {
+			"193.155.130.20": [
+				{
+					"orig": "tunnel-group 193.155.130.20 type ipsec-l2l",
+					"parsed": "tunnel-group $NAME type ipsec-l2l",
+					"name": "193.155.130.20",
+					"anchor": true,
+					"fixed_name": true,
+					"clear_conf": true
+				},
+				{
+					"orig": "tunnel-group 193.155.130.20 ipsec-attributes",
+					"parsed": "tunnel-group $NAME ipsec-attributes",
+					"name": "193.155.130.20",
+					"anchor": true,
+					"fixed_name": true,
+					"clear_conf": true,
+					"sub": [
+						{
+							"orig": "ikev2 local-authentication certificate Trustpoint2",
+							"parsed": "ikev2 local-authentication certificate Trustpoint2",
+							"clear_conf": true
+						},
+						{
+							"orig": "ikev2 remote-authentication certificate",
+							"parsed": "ikev2 remote-authentication certificate",
+							"clear_conf": true
+						}
+					]
+				}
+			]
+		}
+	},
+	"ignored": [
+		"hostname router",
+		"logging enable"
+	]
+}
+=END=
+
+############################################################
+=TITLE=Dump Netspoc config merged with raw
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp object-group g1 host 10.1.1.1 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+object-group network g1
+ network-object host 10.2.2.2
+ network-object 10.3.3.0 255.255.255.0
+--router.raw
+access-list inside_in extended permit udp any4 host 10.1.1.2 eq 53
+[APPEND]
+access-list inside_in extended permit icmp any4 any4
+access-group inside_in in interface inside
+=SETUP=
+cp code/router device
+cp code/router.raw device.raw
+=OPTIONS=--dump
+=OUTPUT=
+{
+	"lookup": {
+		"access-group": {
+			"": [
+				{
+					"orig": "access-group inside_in in interface inside",
+					"parsed": "access-group $REF in interface inside",
+					"ref": [
+						"inside_in"
+					],
+					"anchor": true
+				}
+			]
+		},
+		"access-list": {
+			"inside_in": [
+				{
+					"orig": "access-list inside_in extended permit udp any4 host 10.1.1.2 eq 53",
+					"parsed": "access-list $NAME extended permit udp any4 host 10.1.1.2 eq 53",
+					"name": "inside_in",
+					"clear_conf": true
+				},
+				{
+					"orig": "access-list inside_in extended permit tcp object-group g1 host 10.1.1.1 eq 80",
+					"parsed": "access-list $NAME extended permit tcp object-group $REF host 10.1.1.1 eq 80",
+					"name": "inside_in",
+					"ref": [
+						"g1"
+					],
+					"clear_conf": true
+				},
+				{
+					"orig": "access-list inside_in extended permit icmp any4 any4",
+					"parsed": "access-list $NAME extended permit icmp any4 any4",
+					"name": "inside_in",
+					"clear_conf": true,
+					"marker": "APPEND"
+				},
+				{
+					"orig": "access-list inside_in extended deny ip any4 any4",
+					"parsed": "access-list $NAME extended deny ip any4 any4",
+					"name": "inside_in",
+					"clear_conf": true
+				}
+			]
+		},
+		"object-group": {
+			"g1": [
+				{
+					"orig": "object-group network g1",
+					"parsed": "object-group network $NAME",
+					"name": "g1",
+					"sub": [
+						{
+							"orig": "network-object host 10.2.2.2",
+							"parsed": "network-object host 10.2.2.2"
+						},
+						{
+							"orig": "network-object 10.3.3.0 255.255.255.0",
+							"parsed": "network-object 10.3.3.0 255.255.255.0"
+						}
+					]
+				}
+			]
+		}
+	}
+}
+=END=
+
+############################################################
+=TITLE=Dump with unexpected command in raw
+=NETSPOC=
+--router.raw
+access-list inside_in extended permit udp any4 host 10.1.1.2 eq 53
+foo
+=SETUP=
+cp code/router.raw device.raw
+=OPTIONS=--dump
+=ERROR=
+ERROR>>> While reading file device.raw: Unexpected command in line 2:
+ERROR>>> >>foo<<
+=END=
+
+############################################################
+=TITLE=Dump with invalid options
+=NETSPOC=NONE
+=OPTIONS=--dump -C
+=ERROR=
+Usage: drc [options] FILE1
+     : drc [-q] [--trace FLOW] FILE1 FILE2
+     : drc --dump|--rules FILE1 [FILE2]
+     : drc [-q] --lint FILE|DIR ...
+      --LOGFILE string       Path to redirect STDERR
+  -A, --check-access         Only check login to device, don't read its configuration
+  -C, --compare              Compare only
+  -D, --dump                 Print parsed config of FILE1 as JSON,
+                             take model from info file of FILE2 if given
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+  -i, --interactive          Show changes and ask for confirmation before approve,
+                             default if STDIN is a terminal
+  -l, --lint                 Check raw file of FILE or raw files of all devices in DIR,
+                             don't access any device
+  -L, --logdir string        Path for saving session logs
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES,
+                             from acl, crypto, iptables, routes, rules
+  -q, --quiet                No info messages
+      --resume               Continue interrupted approve, show commands from journal
+  -R, --rules                Print rules of FILE1 in vendor neutral format as JSON,
+                             take model from info file of FILE2 if given
+  -T, --trace FLOW           Show first rule matching FLOW on device and from Netspoc,
+                             FLOW is given as "SRC DST PROTO[:PORT] [IN[:OUT]]"
+  -u, --user string          Username for login to remote device
+  -v, --version              Show version
+=END=
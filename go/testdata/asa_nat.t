@@ -0,0 +1,222 @@
+############################################################
+=TITLE=Join object definition and object NAT from device
+=DEVICE=
+object network web
+ host 10.1.1.10
+object network web
+ nat (inside,outside) static 192.0.2.10
+=NETSPOC=
+object network web
+ host 10.1.1.10
+ nat (inside,outside) static 192.0.2.10
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Add object NAT
+=DEVICE=NONE
+=NETSPOC=
+object network web
+ host 10.1.1.10
+ nat (inside,outside) static 192.0.2.10
+=OUTPUT=
+object network web
+host 10.1.1.10
+nat (inside,outside) static 192.0.2.10
+=END=
+
+############################################################
+=TITLE=Change object NAT
+=DEVICE=
+object network web
+ host 10.1.1.10
+object network web
+ nat (inside,outside) static 192.0.2.10
+=NETSPOC=
+object network web
+ host 10.1.1.11
+ nat (inside,outside) static 192.0.2.11
+=OUTPUT=
+object network web
+no host 10.1.1.10
+no nat (inside,outside) static 192.0.2.10
+host 10.1.1.11
+nat (inside,outside) static 192.0.2.11
+=END=
+
+############################################################
+=TITLE=Remove only NAT from unknown object
+=DEVICE=
+object network web
+ host 10.1.1.10
+object network web
+ nat (inside,outside) static 192.0.2.10
+=NETSPOC=
+object network mail
+ host 10.1.1.25
+ nat (inside,outside) static 192.0.2.25
+=OUTPUT=
+object network mail
+host 10.1.1.25
+nat (inside,outside) static 192.0.2.25
+exit
+object network web
+no nat (inside,outside) static 192.0.2.10
+=END=
+
+############################################################
+=TITLE=Leave NAT unchanged, if Netspoc has no NAT
+=DEVICE=
+object network web
+ host 10.1.1.10
+object network web
+ nat (inside,outside) static 192.0.2.10
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object-group network g1
+ network-object host 10.1.2.10
+nat (inside,outside) source static n1 n1 destination static g1 g1
+nat (inside,outside) after-auto source dynamic any interface
+=NETSPOC=NONE
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Add manual NAT with line numbers
+=DEVICE=
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object network n2
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static n1 n1 destination static n2 n2
+nat (inside,outside) after-auto source dynamic any interface
+=NETSPOC=
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object network n2
+ subnet 10.1.2.0 255.255.255.0
+object network n3
+ subnet 10.1.3.0 255.255.255.0
+nat (inside,outside) source static n3 n3 destination static n2 n2
+nat (inside,outside) source static n1 n1 destination static n2 n2
+nat (inside,outside) source static n1 n1 destination static n3 n3
+nat (inside,outside) after-auto source dynamic n3 interface
+nat (inside,outside) after-auto source dynamic any interface
+=OUTPUT=
+object network n3-DRC-0
+subnet 10.1.3.0 255.255.255.0
+nat (inside,outside) line 1 source static n3-DRC-0 n3-DRC-0 destination static n2 n2
+nat (inside,outside) line 3 source static n1 n1 destination static n3-DRC-0 n3-DRC-0
+nat (inside,outside) after-auto line 1 source dynamic n3-DRC-0 interface
+=END=
+
+############################################################
+=TITLE=Replace and delete manual NAT
+=DEVICE=
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object network n2
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static n1 n1 destination static n2 n2
+nat (inside,outside) source static n2 n2 destination static n1 n1
+nat (inside,outside) source static any any destination static n2 n2
+=NETSPOC=
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object network n2
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static n1 n1 destination static n2 n2 no-proxy-arp
+nat (inside,outside) source static any any destination static n2 n2
+=OUTPUT=
+nat (inside,outside) line 1 source static n1 n1 destination static n2 n2 no-proxy-arp
+no nat (inside,outside) source static n2 n2 destination static n1 n1
+no nat (inside,outside) source static n1 n1 destination static n2 n2
+=END=
+
+############################################################
+=TITLE=Replace object referenced by manual NAT
+=DEVICE=
+object network n1-DRC-0
+ subnet 10.1.1.0 255.255.255.0
+object network n2-DRC-0
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static n1-DRC-0 n1-DRC-0 destination static n2-DRC-0 n2-DRC-0
+=NETSPOC=
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object network n2
+ subnet 10.1.9.0 255.255.255.0
+nat (inside,outside) source static n1 n1 destination static n2 n2
+=OUTPUT=
+object network n2-DRC-1
+subnet 10.1.9.0 255.255.255.0
+nat (inside,outside) line 1 source static n1-DRC-0 n1-DRC-0 destination static n2-DRC-1 n2-DRC-1
+no nat (inside,outside) source static n1-DRC-0 n1-DRC-0 destination static n2-DRC-0 n2-DRC-0
+no object network n2-DRC-0
+=END=
+
+############################################################
+=TITLE=Manual NAT with object-group
+=DEVICE=
+object-group network g0-DRC-0
+ network-object host 10.1.1.10
+ network-object host 10.1.1.11
+object network n2-DRC-0
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static g0-DRC-0 g0-DRC-0 destination static n2-DRC-0 n2-DRC-0
+=NETSPOC=
+object-group network g0
+ network-object host 10.1.1.10
+ network-object host 10.1.1.12
+object network n2
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static g0 g0 destination static n2 n2
+=OUTPUT=
+object-group network g0-DRC-0
+no network-object host 10.1.1.11
+network-object host 10.1.1.12
+=END=
+
+############################################################
+=TITLE=Remove manual NAT and unused objects
+=DEVICE=
+object network n1-DRC-0
+ subnet 10.1.1.0 255.255.255.0
+object network n2-DRC-0
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static n1-DRC-0 n1-DRC-0 destination static n2-DRC-0 n2-DRC-0
+=NETSPOC=NONE
+=OUTPUT=
+no nat (inside,outside) source static n1-DRC-0 n1-DRC-0 destination static n2-DRC-0 n2-DRC-0
+no object network n1-DRC-0
+no object network n2-DRC-0
+=END=
+
+############################################################
+=TITLE=Merge manual NAT from raw
+=DEVICE=NONE
+=NETSPOC=
+--router
+object network n1
+ subnet 10.1.1.0 255.255.255.0
+object network n2
+ subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) source static n1 n1 destination static n2 n2
+nat (inside,outside) after-auto source dynamic any interface
+--router.raw
+object network r1
+ host 10.1.1.99
+nat (inside,outside) source static r1 r1
+[APPEND]
+nat (inside,outside) after-auto source dynamic r1 interface
+[DELETE]
+nat (inside,outside) after-auto source dynamic any interface
+=OUTPUT=
+object network r1-DRC-0
+host 10.1.1.99
+nat (inside,outside) line 1 source static r1-DRC-0 r1-DRC-0
+object network n1-DRC-0
+subnet 10.1.1.0 255.255.255.0
+object network n2-DRC-0
+subnet 10.1.2.0 255.255.255.0
+nat (inside,outside) line 2 source static n1-DRC-0 n1-DRC-0 destination static n2-DRC-0 n2-DRC-0
+nat (inside,outside) after-auto line 1 source dynamic r1-DRC-0 interface
+=END=
@@ -2021,7 +2021,7 @@ aaa-server LDAP_KV (inside) host 10.2.8.16
  ldap-attribute-map MAP2
 =NETSPOC=[[input]]
 =ERROR=
-ERROR>>> aaa-server LDAP_KV must not use different values in 'ldap-attribute-map'
+ERROR>>> While reading file device: aaa-server LDAP_KV must not use different values in 'ldap-attribute-map'
 =END=
 
 ############################################################
@@ -2262,7 +2262,7 @@ ldap attribute-map LDAPMAP
  map-value memberOf "CN=g-m1,OU=VPN,DC=example,DC=com VPN-group-G1
 =NETSPOC=NONE
 =ERROR=
-panic: Incomplete string in: [map-value memberOf "CN=g-m1,OU=VPN,DC=example,DC=com VPN-group-G1]
+ERROR>>> While reading file device: Incomplete string in: [map-value memberOf "CN=g-m1,OU=VPN,DC=example,DC=com VPN-group-G1]
 =END=
 
 ############################################################
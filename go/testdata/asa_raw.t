@@ -12,7 +12,7 @@ route inside 10.20.0.0 255.248.0.0 10.1.2.3
 --router.raw
 unexpected foo
 =ERROR=
-ERROR>>> While reading file router.raw: Unexpected command:
+ERROR>>> While reading file router.raw: Unexpected command in line 1:
 ERROR>>> >>unexpected foo<<
 =END=
 
@@ -103,6 +103,269 @@ access-list inside_in-DRC-0 extended deny ip any4 any4
 access-group inside_in-DRC-0 in interface inside
 =END=
 
+############################################################
+=TITLE=Switch back to prepend with [PREPEND]
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+[APPEND]
+access-list inside_in extended deny ip any4 host 224.0.1.1 log
+[PREPEND]
+access-list inside_in extended permit udp 10.0.6.0 0.0.0.255 host 224.0.1.1 eq 123
+=OUTPUT=
+access-list inside_in-DRC-0 extended permit udp 10.0.6.0 0.0.0.255 host 224.0.1.1 eq 123
+access-list inside_in-DRC-0 extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in-DRC-0 extended deny ip any4 host 224.0.1.1 log
+access-list inside_in-DRC-0 extended deny ip any4 any4
+access-group inside_in-DRC-0 in interface inside
+=END=
+
+############################################################
+=TITLE=Insert ACL lines with [BEFORE] and [AFTER]
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in extended permit tcp any4 host 10.0.1.12 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+[AFTER permit tcp any4 host 10.0.1.11 eq 80]
+access-list inside_in extended deny tcp host 10.0.6.1 any4
+access-list inside_in extended deny tcp host 10.0.6.2 any4
+[BEFORE access-list inside_in extended  permit tcp any4 host 10.0.1.11 eq 80]
+access-list inside_in extended permit tcp host 10.0.6.3 any4
+[BEFORE deny ip any4 any4]
+access-list inside_in extended permit tcp host 10.0.6.4 any4
+=OUTPUT=
+access-list inside_in-DRC-0 extended permit tcp host 10.0.6.3 any4
+access-list inside_in-DRC-0 extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in-DRC-0 extended deny tcp host 10.0.6.1 any4
+access-list inside_in-DRC-0 extended deny tcp host 10.0.6.2 any4
+access-list inside_in-DRC-0 extended permit tcp any4 host 10.0.1.12 eq 80
+access-list inside_in-DRC-0 extended permit tcp host 10.0.6.4 any4
+access-list inside_in-DRC-0 extended deny ip any4 any4
+access-group inside_in-DRC-0 in interface inside
+=END=
+
+############################################################
+=TITLE=Reference line added by preceeding marker
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+[AFTER permit tcp any4 host 10.0.1.11 eq 80]
+access-list inside_in extended deny tcp host 10.0.6.1 any4
+[AFTER deny tcp host 10.0.6.1 any4]
+access-list inside_in extended deny tcp host 10.0.6.2 any4
+[BEFORE deny tcp host 10.0.6.1 any4]
+access-list inside_in extended permit tcp host 10.0.6.3 any4
+=OUTPUT=
+access-list inside_in-DRC-0 extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in-DRC-0 extended permit tcp host 10.0.6.3 any4
+access-list inside_in-DRC-0 extended deny tcp host 10.0.6.1 any4
+access-list inside_in-DRC-0 extended deny tcp host 10.0.6.2 any4
+access-list inside_in-DRC-0 extended deny ip any4 any4
+access-group inside_in-DRC-0 in interface inside
+=END=
+
+############################################################
+=TITLE=Unknown line in [BEFORE]
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+[BEFORE deny ip any any]
+access-list inside_in extended permit tcp host 10.0.6.4 any4
+=ERROR=
+ERROR>>> While reading file router.raw: Can't find line of [BEFORE deny ip any any] in ACL inside_in from raw
+=END=
+
+############################################################
+=TITLE=Delete ACL line with [DELETE]
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+object-group network g0
+ network-object host 10.0.1.11
+ network-object host 10.0.1.12
+access-list inside_in extended permit tcp any4 object-group g0 eq 80
+access-list inside_in extended permit tcp any4 host 10.0.1.13 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+access-list inside_in extended permit tcp host 10.0.6.1 any4
+[DELETE]
+access-list inside_in extended permit tcp any4 host 10.0.1.13 eq 80
+=OUTPUT=
+access-list inside_in-DRC-0 extended permit tcp host 10.0.6.1 any4
+object-group network g0-DRC-0
+network-object host 10.0.1.11
+network-object host 10.0.1.12
+access-list inside_in-DRC-0 extended permit tcp any4 object-group g0-DRC-0 eq 80
+access-list inside_in-DRC-0 extended deny ip any4 any4
+access-group inside_in-DRC-0 in interface inside
+=END=
+
+############################################################
+=TITLE=Delete unknown ACL line
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+[DELETE]
+access-list inside_in extended permit tcp any4 host 10.0.1.13 eq 80
+=ERROR=
+ERROR>>> While reading file router.raw: Can't delete unknown line 'permit tcp any4 host 10.0.1.13 eq 80' of ACL inside_in from raw
+=END=
+
+############################################################
+=TITLE=Replace ACL with [REPLACE]
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp any4 host 10.0.1.11 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-group inside_in in interface inside
+[REPLACE]
+access-list inside_in extended permit ip 10.0.6.0 255.255.255.0 any4
+access-list inside_in extended deny ip any4 any4 log
+[APPEND]
+access-list inside_in extended permit ip host 10.0.6.7 any4
+=OUTPUT=
+access-list inside_in-DRC-0 extended permit ip 10.0.6.0 255.255.255.0 any4
+access-list inside_in-DRC-0 extended permit ip host 10.0.6.7 any4
+access-list inside_in-DRC-0 extended deny ip any4 any4 log
+access-group inside_in-DRC-0 in interface inside
+=END=
+
+############################################################
+=TITLE=Replace object-group with [REPLACE]
+=DEVICE=
+interface Ethernet0/1
+ nameif inside
+=NETSPOC=
+--router
+object-group network g0
+ network-object host 10.0.1.11
+ network-object host 10.0.1.12
+access-list inside_in extended permit tcp any4 object-group g0 eq 80
+access-group inside_in in interface inside
+--router.raw
+[REPLACE]
+object-group network g0
+ network-object host 10.0.1.11
+ network-object host 10.0.1.13
+=OUTPUT=
+object-group network g0-DRC-0
+network-object host 10.0.1.11
+network-object host 10.0.1.13
+access-list inside_in-DRC-0 extended permit tcp any4 object-group g0-DRC-0 eq 80
+access-group inside_in-DRC-0 in interface inside
+=END=
+
+############################################################
+=TITLE=Replace unknown object-group
+=DEVICE=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.255.0.0 10.1.2.3
+--router.raw
+[REPLACE]
+object-group network g1
+ network-object host 10.0.1.11
+=ERROR=
+ERROR>>> While reading file router.raw: Can't replace unknown 'object-group g1' from raw
+=END=
+
+############################################################
+=TITLE=Delete route with [DELETE]
+=DEVICE=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.255.0.0 10.1.2.3
+route inside 10.21.0.0 255.255.0.0 10.1.2.3
+--router.raw
+[DELETE]
+route inside 10.20.0.0 255.255.0.0 10.1.2.3
+=OUTPUT=
+route inside 10.21.0.0 255.255.0.0 10.1.2.3
+=END=
+
+############################################################
+=TITLE=Delete unknown route
+=DEVICE=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.255.0.0 10.1.2.3
+--router.raw
+[DELETE]
+route inside 10.22.0.0 255.255.0.0 10.1.2.3
+=ERROR=
+ERROR>>> While reading file router.raw: Can't delete unknown 'route inside 10.22.0.0 255.255.0.0 10.1.2.3' from raw
+=END=
+
+############################################################
+=TITLE=Replace all routes
+=DEVICE=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.255.0.0 10.1.2.3
+route inside 10.21.0.0 255.255.0.0 10.1.2.3
+--router.raw
+[REPLACE]
+route inside 10.0.0.0 255.0.0.0 10.1.2.4
+=OUTPUT=
+route inside 10.0.0.0 255.0.0.0 10.1.2.4
+=END=
+
+############################################################
+=TITLE=Invalid marker
+=DEVICE=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.255.0.0 10.1.2.3
+--router.raw
+[AFTER]
+route inside 10.0.0.0 255.0.0.0 10.1.2.4
+=ERROR=
+ERROR>>> While reading file router.raw: Unexpected command in line 1:
+ERROR>>> >>[AFTER]<<
+=END=
+
 ############################################################
 =TITLE=Merge ACL, duplicate access-group in raw
 =DEVICE=
@@ -119,7 +382,7 @@ access-group inside_in in interface inside
 access-list inside_in extended deny ip any4 host 224.0.1.1 log
 access-group inside_in in interface inside
 =ERROR=
-ERROR>>> Must reference 'access-list inside_in' only once in raw
+ERROR>>> While reading file router.raw: Must reference 'access-list inside_in' only once in raw
 =END=
 
 ############################################################
@@ -181,7 +444,7 @@ access-group inside_in in interface inside
 access-list inside_in extended deny ip host 10.0.6.1 any4
 access-group inside_in out interface inside
 =ERROR=
-ERROR>>> Name clash for 'access-list inside_in' from raw
+ERROR>>> While reading file router.raw: Name clash for 'access-list inside_in' from raw
 =END=
 
 ############################################################
@@ -193,7 +456,7 @@ access-list in_out extended permit ip any4 host 10.0.6.1
 access-group in_out in interface inside
 access-group in_out out interface inside
 =ERROR=
-ERROR>>> Name clash for 'access-list in_out' from raw
+ERROR>>> While reading file router.raw: Name clash for 'access-list in_out' from raw
 =END=
 
 ############################################################
@@ -210,7 +473,7 @@ access-list in_out extended permit ip any4 host 10.0.6.1
 access-group in_out in interface inside
 access-group in_out out interface inside
 =ERROR=
-ERROR>>> Must reference 'access-list in_out' only once in raw
+ERROR>>> While reading file router.raw: Must reference 'access-list in_out' only once in raw
 =END=
 
 ############################################################
@@ -260,7 +523,7 @@ object-group network g1
 access-list inside extended permit ip object-group g1 any4
 access-group inside in interface inside
 =ERROR=
-ERROR>>> Name clash for 'object-group g1' from raw
+ERROR>>> While reading file router.raw: Name clash for 'object-group g1' from raw
 =END=
 
 ############################################################
@@ -772,7 +1035,7 @@ tunnel-group 1.1.1.2 type ipsec-l2l
 tunnel-group 1.1.1.2 general-attributes
  default-group-policy VPN-group2
 =ERROR=
-ERROR>>> Name clash for 'ip local pool pool' from raw
+ERROR>>> While reading file router.raw: Name clash for 'ip local pool pool' from raw
 =END=
 
 ############################################################
@@ -839,3 +1102,95 @@ access-group inside_in in interface inside
 =OUTPUT=
 no access-list inside_in line 2 extended permit sctp any4 any4 eq foo
 =END=
+
+############################################################
+=TITLE=Lint raw file with unexpected command
+=SCENARIO=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.248.0.0 10.1.2.3
+--router.raw
+route inside 10.30.0.0 255.255.0.0 10.1.2.3
+unexpected foo
+=PARAMS=--lint code/router.raw
+=ERROR=
+ERROR>>> While reading file router.raw: Unexpected command in line 2:
+ERROR>>> >>unexpected foo<<
+=END=
+
+############################################################
+=TITLE=Lint raw file with name clash
+=SCENARIO=NONE
+=NETSPOC=
+--router
+object-group network g0
+ network-object host 10.0.1.11
+access-list inside_in extended permit ip object-group g0 any4
+access-group inside_in in interface inside
+--router.raw
+object-group network g0
+ network-object host 10.0.1.12
+access-list inside_in extended permit ip object-group g0 any4
+access-group inside_in in interface inside
+=PARAMS=--lint code/router
+=ERROR=
+ERROR>>> While reading file router.raw: Name clash for 'object-group g0' from raw
+=END=
+
+############################################################
+=TITLE=Lint raw file with unused ACL
+=SCENARIO=NONE
+=NETSPOC=
+--router
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-list outside_in extended deny ip host 10.0.6.0 any4
+=PARAMS=--lint code/router
+=WARNING=
+WARNING>>> Ignoring unused 'access-list outside_in' in raw
+=END=
+
+############################################################
+=TITLE=Lint raw files of all devices in directory
+=SCENARIO=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.248.0.0 10.1.2.3
+--router.raw
+route inside 10.30.0.0 255.255.0.0 10.1.2.3
+=SETUP=
+cp code/router code/r1
+cp code/router.info code/r1.info
+echo 'foo' > code/r1.raw
+cp code/router code/r2
+cp code/router.info code/r2.info
+echo 'route inside 10.40.0.0 255.255.0.0 10.1.2.3' > code/r2.raw
+cp code/router code/r3
+cp code/router.info code/r3.info
+echo 'bar' > code/r3.raw
+=PARAMS=--lint code
+=ERROR=
+ERROR>>> While reading file r1.raw: Unexpected command in line 1:
+ERROR>>> >>foo<<
+ERROR>>> While reading file r3.raw: Unexpected command in line 1:
+ERROR>>> >>bar<<
+=END=
+
+############################################################
+=TITLE=Lint raw files of all devices, show checked files
+=SCENARIO=NONE
+=NETSPOC=
+--router
+route inside 10.20.0.0 255.248.0.0 10.1.2.3
+--router.raw
+route inside 10.30.0.0 255.255.0.0 10.1.2.3
+=SETUP=
+cp code/router code/r1
+cp code/router.info code/r1.info
+cp code/router.raw code/r1.raw
+=PARAMS=-q=0 --lint code
+=WARNING=
+lint: code/r1.raw
+lint: code/router.raw
+=END=
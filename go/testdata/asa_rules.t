@@ -0,0 +1,350 @@
+############################################################
+=TITLE=Export rules of bound and unbound ACLs
+=DEVICE=
+interface Ethernet0/0
+ nameif inside
+object-group network g1
+ network-object host 10.2.2.2
+ network-object 10.3.3.0 255.255.255.0
+ group-object g2
+object-group network g2
+ network-object 2001:db8::/64
+access-list inside_in extended permit tcp object-group g1 host 10.1.1.1 eq 80 log
+access-list inside_in extended permit udp 10.1.0.0 255.255.0.0 range 1024 65535 any4 neq 53
+access-list inside_in remark Ping
+access-list inside_in extended permit icmp any4 any4 3 4
+access-list inside_in extended permit icmp6 any6 any6
+access-list inside_in extended permit 50 any4 object interface-x
+access-list inside_in extended deny ip any any
+access-group inside_in in interface inside
+access-list global extended permit tcp any4 gt 1023 10.0.0.0 255.0.0.0 lt 1024
+access-group global global
+access-list filter extended permit ip any4 host 10.1.1.1
+access-list split standard permit 10.1.0.0 255.255.0.0
+=NETSPOC=NONE
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "filter",
+			"rules": [
+				{
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.1.1.1/32"
+						]
+					},
+					"orig": "access-list filter extended permit ip any4 host 10.1.1.1"
+				}
+			],
+			"default": "deny"
+		},
+		{
+			"name": "global",
+			"bindings": [
+				{}
+			],
+			"rules": [
+				{
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.0.0.0/8"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"src_ports": [
+								{
+									"low": 1024,
+									"high": 65535
+								}
+							],
+							"dst_ports": [
+								{
+									"low": 0,
+									"high": 1023
+								}
+							]
+						}
+					],
+					"orig": "access-list global extended permit tcp any4 gt 1023 10.0.0.0 255.0.0.0 lt 1024"
+				}
+			],
+			"default": "deny"
+		},
+		{
+			"name": "inside_in",
+			"bindings": [
+				{
+					"name": "inside",
+					"direction": "in"
+				}
+			],
+			"rules": [
+				{
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.2.2.2/32",
+							"10.3.3.0/24",
+							"2001:db8::/64"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.1.1.1/32"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 80,
+									"high": 80
+								}
+							]
+						}
+					],
+					"log": true,
+					"orig": "access-list inside_in extended permit tcp object-group g1 host 10.1.1.1 eq 80 log"
+				},
+				{
+					"position": 2,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.1.0.0/16"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "udp",
+							"src_ports": [
+								{
+									"low": 1024,
+									"high": 65535
+								}
+							],
+							"dst_ports": [
+								{
+									"low": 0,
+									"high": 52
+								},
+								{
+									"low": 54,
+									"high": 65535
+								}
+							]
+						}
+					],
+					"orig": "access-list inside_in extended permit udp 10.1.0.0 255.255.0.0 range 1024 65535 any4 neq 53"
+				},
+				{
+					"position": 3,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "icmp",
+							"type": 3,
+							"code": 4
+						}
+					],
+					"orig": "access-list inside_in extended permit icmp any4 any4 3 4"
+				},
+				{
+					"position": 4,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"::/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"::/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "icmpv6"
+						}
+					],
+					"orig": "access-list inside_in extended permit icmp6 any6 any6"
+				},
+				{
+					"position": 5,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"unknown": [
+							"object interface-x"
+						]
+					},
+					"services": [
+						{
+							"proto": "50"
+						}
+					],
+					"orig": "access-list inside_in extended permit 50 any4 object interface-x"
+				},
+				{
+					"position": 6,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0",
+							"::/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0",
+							"::/0"
+						]
+					},
+					"orig": "access-list inside_in extended deny ip any any"
+				}
+			],
+			"default": "deny"
+		}
+	]
+}
+=END=
+
+############################################################
+=TITLE=Mark rules from raw file
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp any4 host 10.1.1.1 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-list inside_in extended deny udp host 10.1.1.2 any4 eq 53
+access-group inside_in in interface inside
+=SETUP=
+cp code/router device
+cp code/router.raw device.raw
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "inside_in",
+			"bindings": [
+				{
+					"name": "inside",
+					"direction": "in"
+				}
+			],
+			"rules": [
+				{
+					"position": 1,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"10.1.1.2/32"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "udp",
+							"dst_ports": [
+								{
+									"low": 53,
+									"high": 53
+								}
+							]
+						}
+					],
+					"raw": true,
+					"orig": "access-list inside_in extended deny udp host 10.1.1.2 any4 eq 53"
+				},
+				{
+					"position": 2,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.1.1.1/32"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 80,
+									"high": 80
+								}
+							]
+						}
+					],
+					"orig": "access-list inside_in extended permit tcp any4 host 10.1.1.1 eq 80"
+				},
+				{
+					"position": 3,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"orig": "access-list inside_in extended deny ip any4 any4"
+				}
+			],
+			"default": "deny"
+		}
+	]
+}
+=END=
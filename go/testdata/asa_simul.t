@@ -17,6 +17,10 @@ Width = 80, no monitor
 terminal interactive
 # show hostname
 router
+# copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
 # sh ver
 Cisco Adaptive Security Appliance Software Version 9.4(4)5
 Hardware:   ASA5550, 4096 MB RAM, CPU Pentium 4 3000 MHz
@@ -59,6 +63,7 @@ Hardware:   ASA5550, 4096 MB RAM, CPU Pentium 4 3000 MHz
 Configuration last modified by netspoc at 10:40:44.291 CEDT Thu Oct 19 2017
 router#show hostname
 router
+router#show failover
 router#
 --router.config
 sh run
@@ -110,12 +115,17 @@ access-list inside extended permit ip host 4.4.4.4 any
 access-group inside in interface inside
 router#
 --router.change
-configure terminal
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
 router#no access-list inside line 4 extended permit ip host 4.4.4.4 any
 router#access-list inside line 2 extended permit ip host 4.4.4.4 any
 router#no route inside 0.0.0.0 0.0.0.0 10.1.2.3
 router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
 router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
 router#write memory
 Building configuration...
 Cryptochecksum: abcdef01 44444444 12345678 98765432
@@ -145,7 +155,11 @@ access-list inside extended permit ip object-group g1 object-group g1
 access-group inside in interface inside
 =OUTPUT=
 --router.change
-configure terminal
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
 router#object-group network g1-DRC-0
 router#network-object host 1.1.1.1
 router#access-list inside-DRC-0 extended permit ip object-group g1-DRC-0 object-group g1-DRC-0
@@ -153,6 +167,7 @@ WARNING: Same object-group is used more than once in one config line. This confi
 router#access-group inside-DRC-0 in interface inside
 router#clear configure access-list inside
 router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
 router#write memory
 [OK]
 router#
@@ -178,7 +193,11 @@ access-list inside_in extended permit ip 10.1.3.0 255.255.240.0 host 10.3.4.5
 access-group inside_in in interface inside
 =OUTPUT=
 --router.change
-configure terminal
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
 router#access-list inside_in-DRC-0 extended permit ip 10.1.3.0 255.255.240.0 host 10.3.4.5
 router#access-group inside_in-DRC-0 in interface inside
 router#no crypto map crypto-inside interface inside
@@ -187,6 +206,7 @@ WARNING: The crypto map entry is incomplete!
 router#no crypto dynamic-map VPN66@example.com 10 match address crypto-acl2
 router#clear configure access-list crypto-acl2
 router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
 router#write memory
 [OK]
 router#
@@ -214,7 +234,11 @@ tunnel-group some-name ipsec-attributes
 tunnel-group-map some-name 10 some-name
 =OUTPUT=
 --router.change
-configure terminal
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
 router#crypto ca certificate map some-name-DRC-0 10
 router#subject-name attr ea eq some-name
 router#tunnel-group some-name-DRC-0 type ipsec-l2l
@@ -228,6 +252,7 @@ router#ikev2 local-authentication certificate Trustpoint2
 router#ikev2 remote-authentication certificate
 router#tunnel-group-map some-name-DRC-0 10 some-name-DRC-0
 router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
 router#write memory
 [OK]
 router#
@@ -254,7 +279,11 @@ webvpn
  certificate-group-map map-1 10 tunnel-1
 =OUTPUT=
 --router.change
-configure terminal
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
 router#crypto ca certificate map map-1-DRC-0 10
 router#subject-name attr ea co @sub.example.com
 router#tunnel-group tunnel-1-DRC-0 type remote-access
@@ -267,6 +296,7 @@ router#webvpn
 router#certificate-group-map map-1-DRC-0 10 tunnel-1-DRC-0
 INFO: If a certificate map is configured ASA  will ask all users loading the logon page for a client certificate.
 router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
 router#write memory
 [OK]
 router#
@@ -288,16 +318,194 @@ WARNING>>> WARNING: Route already exists
 =END=
 
 ############################################################
-=TITLE=Unexpected command output
+=TITLE=Unexpected command output, restore backup
+=SCENARIO=
+[[login_scenario]]
+# route inside 0.0.0.0 0.0.0.0 10.1.2.4
+foo
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=ERROR=
+WARNING>>> Restored running config from disk0:/netspoc-approve-router.cfg
+ERROR>>> Got unexpected output from 'route inside 0.0.0.0 0.0.0.0 10.1.2.4':
+ERROR>>> foo
+=OUTPUT=
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
+foo
+router#end
+router#configure replace disk0:/netspoc-approve-router.cfg
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
+router#
+=END=
+
+############################################################
+=TITLE=Unexpected command output, restoring backup fails
 =SCENARIO=
 [[login_scenario]]
 # route inside 0.0.0.0 0.0.0.0 10.1.2.4
 foo
+# configure replace disk0:/netspoc-approve-router.cfg
+ERROR: Failed to read file disk0:/netspoc-approve-router.cfg
 =NETSPOC=
 route inside 0.0.0.0 0.0.0.0 10.1.2.4
 =ERROR=
+WARNING>>> Restoring running config from disk0:/netspoc-approve-router.cfg failed:
+WARNING>>> ERROR: Failed to read file disk0:/netspoc-approve-router.cfg
 ERROR>>> Got unexpected output from 'route inside 0.0.0.0 0.0.0.0 10.1.2.4':
 ERROR>>> foo
+=OUTPUT=
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
+foo
+router#end
+router#configure replace disk0:/netspoc-approve-router.cfg
+ERROR: Failed to read file disk0:/netspoc-approve-router.cfg
+router#
+=END=
+
+############################################################
+=TITLE=Lost connection, restore backup after reconnect
+=SCENARIO=
+[[login_scenario]]
+# route inside 0.0.0.0 0.0.0.0 10.1.2.4
+<!>
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=ERROR=
+WARNING>>> Restored running config from disk0:/netspoc-approve-router.cfg
+ERROR>>> while waiting for prompt '
+ERROR>>> router\S*#': expect: timer expired after 1 seconds
+=OUTPUT=
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+end
+
+router#Are you sure you want to continue connecting (yes/no)?yes
+
+***********************************************************
+**                 managed by NetSPoC                    **
+***********************************************************
+netspoc@10.1.2.3's password: secret
+
+Type help or '?' for a list of available commands.
+router>enable
+Password: secret
+
+router#
+router#sh pager
+pager lines 24
+
+router#terminal pager 0
+router#sh term
+
+Width = 80, no monitor
+terminal interactive
+router#configure terminal
+router#terminal width 511
+router#end
+router#sh ver
+Cisco Adaptive Security Appliance Software Version 9.4(4)5
+Hardware:   ASA5550, 4096 MB RAM, CPU Pentium 4 3000 MHz
+Configuration last modified by netspoc at 10:40:44.291 CEDT Thu Oct 19 2017
+
+router#show hostname
+router
+router#show failover
+router#configure replace disk0:/netspoc-approve-router.cfg
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
+router#
+=END=
+
+############################################################
+=TITLE=Schedule restore of running config
+=SCENARIO=
+[[login_scenario]]
+# write memory
+[OK]
+=SETUP=
+echo "restore_minutes = 5" >> .netspoc-approve
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=OUTPUT=
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#event manager applet netspoc-approve-restore
+router#event timer countdown time 300
+router#action 1 cli command "configure replace disk0:/netspoc-approve-router.cfg"
+router#end
+router#configure terminal
+router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
+router#end
+router#configure terminal
+router#no event manager applet netspoc-approve-restore
+router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
+router#write memory
+[OK]
+router#
+=END=
+
+############################################################
+=TITLE=Can't schedule restore in multiple context mode
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+asa/router#<prompt:asa/router>
+# show hostname
+asa
+# show mode
+Security context mode: multiple
+# copy /noconfirm running-config context:/netspoc-approve-router.cfg
+4523 bytes copied in 0.220 secs
+# write memory
+[OK]
+=SETUP=
+echo "restore_minutes = 5" >> .netspoc-approve
+=NETSPOC=
+--router
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+--router.info
+{ "model": "ASA", "name_list": [ "asa" ], "ip_list": [ "10.1.13.33" ] }
+=WARNING=
+WARNING>>> Can't schedule restore of running config in multiple context mode
+=END=
+
+############################################################
+=TITLE=Abort if backup of running config fails
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+router#
+# show hostname
+router
+# copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+%Error writing disk0:/netspoc-approve-router.cfg (No space left on device)
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=ERROR=
+ERROR>>> Creating backup of running config failed:
+ERROR>>> %Error writing disk0:/netspoc-approve-router.cfg (No space left on device)
 =END=
 
 ############################################################
@@ -386,6 +594,7 @@ Configuration last modified by netspoc at 10:40:44.291 CEDT Thu Oct 19 2017
 
 router#show hostname
 router
+router#show failover
 router#
 =END=
 
@@ -401,6 +610,318 @@ wrong
 ERROR>>> Wrong device name: "wrong", expected: "router"
 =END=
 
+############################################################
+=TITLE=Multiple context mode: change to context of device
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+asa/admin#<prompt:asa/admin>
+# show hostname
+asa
+# show mode
+Security context mode: multiple
+# changeto context router
+<prompt:asa/router>
+# sh run
+route inside 0.0.0.0 0.0.0.0 10.1.2.3
+# copy /noconfirm running-config context:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+# write memory
+Building configuration...
+Cryptochecksum: 2ae3b6c0 ac4d2ab5 0d1b3aa8 b6e9b8c3
+[OK]
+=NETSPOC=
+--router
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+--router.info
+{ "model": "ASA", "name_list": [ "asa" ], "ip_list": [ "10.1.13.33" ] }
+=OUTPUT=
+--router.login
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: secret
+
+asa/admin#
+asa/admin#sh pager
+asa/admin#terminal pager 0
+asa/admin#sh term
+asa/admin#configure terminal
+asa/admin#terminal width 511
+asa/admin#end
+asa/admin#sh ver
+asa/admin#show hostname
+asa
+asa/admin#show mode
+Security context mode: multiple
+asa/admin#
+asa/admin#changeto context router
+asa/router#show failover
+asa/router#
+--router.config
+sh run
+route inside 0.0.0.0 0.0.0.0 10.1.2.3
+asa/router#
+--router.change
+copy /noconfirm running-config context:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+asa/router#configure terminal
+asa/router#no route inside 0.0.0.0 0.0.0.0 10.1.2.3
+asa/router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
+asa/router#end
+asa/router#delete /noconfirm context:/netspoc-approve-router.cfg
+asa/router#write memory
+Building configuration...
+Cryptochecksum: 2ae3b6c0 ac4d2ab5 0d1b3aa8 b6e9b8c3
+[OK]
+asa/router#
+=END=
+
+############################################################
+=TITLE=Multiple context mode: wrong physical device
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+asa2/admin#<prompt:asa2/admin>
+# show hostname
+asa2
+# show mode
+Security context mode: multiple
+=NETSPOC=
+--router
+--router.info
+{ "model": "ASA", "name_list": [ "asa" ], "ip_list": [ "10.1.13.33" ] }
+=ERROR=
+ERROR>>> Wrong name of physical device: "asa2", expected: "asa"
+=END=
+
+############################################################
+=TITLE=Multiple context mode: logged into context of device
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+asa/router#<prompt:asa/router>
+# show hostname
+asa
+# show mode
+Security context mode: multiple
+=NETSPOC=
+--router
+--router.info
+{ "model": "ASA", "name_list": [ "asa" ], "ip_list": [ "10.1.13.33" ] }
+=OUTPUT=
+--router.login
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: secret
+
+asa/router#
+asa/router#sh pager
+asa/router#terminal pager 0
+asa/router#sh term
+asa/router#configure terminal
+asa/router#terminal width 511
+asa/router#end
+asa/router#sh ver
+asa/router#show hostname
+asa
+asa/router#show mode
+Security context mode: multiple
+asa/router#
+asa/router#show failover
+asa/router#
+=END=
+
+############################################################
+=TITLE=Multiple context mode: unknown context
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+asa/admin#<prompt:asa/admin>
+# show hostname
+asa
+# show mode
+Security context mode: multiple
+# changeto context router
+ERROR: Context 'router' does not exist
+=NETSPOC=
+--router
+--router.info
+{ "model": "ASA", "name_list": [ "asa" ], "ip_list": [ "10.1.13.33" ] }
+=ERROR=
+ERROR>>> Can't change to context "router", current context: "admin"
+ERROR>>> ERROR: Context 'router' does not exist
+=END=
+
+############################################################
+=TEMPL=failover_active
+Failover On
+Failover unit Primary
+Failover LAN Interface: folink GigabitEthernet0/2 (up)
+        This host: Primary - Active
+                Active time: 13434 (sec)
+        Other host: Secondary - {{.}}
+                Active time: 0 (sec)
+=END=
+
+############################################################
+=TITLE=Failover: change active unit
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+router#
+# show hostname
+router
+# show failover
+[[failover_active "Standby Ready"]]
+# copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+# write memory
+Building configuration...
+Cryptochecksum: 2ae3b6c0 ac4d2ab5 0d1b3aa8 b6e9b8c3
+[OK]
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=OUTPUT=
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#route inside 0.0.0.0 0.0.0.0 10.1.2.4
+router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
+router#write memory
+Building configuration...
+Cryptochecksum: 2ae3b6c0 ac4d2ab5 0d1b3aa8 b6e9b8c3
+[OK]
+router#show failover
+Failover On
+Failover unit Primary
+Failover LAN Interface: folink GigabitEthernet0/2 (up)
+        This host: Primary - Active
+                Active time: 13434 (sec)
+        Other host: Secondary - Standby Ready
+                Active time: 0 (sec)
+router#
+=END=
+
+############################################################
+=TITLE=Failover: check access shows HA state
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+router#
+# show hostname
+router
+# show failover
+[[failover_active "Standby Ready"]]
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=WARNING=
+access: login succeeded
+access: device name: router
+access: HA state: Active
+access: banner: found
+=END=
+
+############################################################
+=TITLE=Failover: refuse to change standby unit
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+router#
+# show hostname
+router
+# show failover
+Failover On
+Failover unit Secondary
+Failover LAN Interface: folink GigabitEthernet0/2 (up)
+        This host: Secondary - Standby Ready
+                Active time: 0 (sec)
+        Other host: Primary - Active
+                Active time: 13434 (sec)
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=ERROR=
+ERROR>>> Device isn't active unit of failover pair, state: "Standby Ready"
+=END=
+
+############################################################
+=TITLE=Failover: standby unit not synchronized after write memory
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+router#
+# show hostname
+router
+# show failover
+[[failover_active Failed]]
+# copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+# write memory
+Building configuration...
+Cryptochecksum: 2ae3b6c0 ac4d2ab5 0d1b3aa8 b6e9b8c3
+[OK]
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=ERROR=
+ERROR>>> Standby unit of failover pair isn't synchronized, state: "Failed"
+=END=
+
+############################################################
+=TITLE=Failover: restore backup
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+router#
+# show hostname
+router
+# show failover
+[[failover_active "Standby Ready"]]
+# copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+4523 bytes copied in 0.220 secs
+# route inside 0.0.0.0 0.0.0.0 10.1.2.4
+foo
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=ERROR=
+WARNING>>> Restored running config from disk0:/netspoc-approve-router.cfg
+ERROR>>> Got unexpected output from 'route inside 0.0.0.0 0.0.0.0 10.1.2.4':
+ERROR>>> foo
+=END=
+
+############################################################
+=TITLE=Failover: logged into context
+=SCENARIO=
+** managed by NetSPoC **
+netspoc@10.1.2.3's password: <!>
+asa/router#<prompt:asa/router>
+# show hostname
+asa
+# show mode
+Security context mode: multiple
+# show failover
+Failover On
+Last Failover at: 14:41:21 CEST Oct 5 2026
+        This context: Standby Ready
+                Active time: 0 (sec)
+        Peer context: Active
+                Active time: 7220 (sec)
+=NETSPOC=
+--router
+--router.info
+{ "model": "ASA", "name_list": [ "asa" ], "ip_list": [ "10.1.13.33" ] }
+=ERROR=
+ERROR>>> Device isn't active unit of failover pair, state: "Standby Ready"
+=END=
+
 ############################################################
 =TITLE=Missing NetSPoC banner
 =SCENARIO=
@@ -422,6 +943,10 @@ netspoc@10.1.2.3's password: <!>
 router#
 # show hostname
 router
+# copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
 # write memory
 FAILED
 =NETSPOC=
@@ -453,12 +978,17 @@ ipv6 route inside 10::2:0/1 10::2:5
 }
 =OUTPUT=
 --router.change
-configure terminal
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
 router#ipv6 route inside 10::3:0/120 10::2:2
 router#ipv6 route inside 10::2:0/1 10::2:5
 router#route inside 10.20.0.0 255.255.255.0 10.1.2.3
 router#route inside 10.22.0.0 255.255.0.0 10.1.2.4
 router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
 router#write memory
 [OK]
 router#
@@ -496,3 +1026,262 @@ route inside 10.22.0.0 255.255.0.0 10.1.2.4
 =ERROR=
 ERROR>>> Missing IP address in [code/router.info]
 =END=
+
+############################################################
+=TITLE=Trace flow on device
+=SCENARIO=
+[[login_scenario]]
+# sh run
+access-list inside_in extended permit tcp any4 host 10.9.9.9 eq 80
+access-group inside_in in interface inside
+=NETSPOC=
+access-list inside_in extended permit tcp any4 host 10.9.9.9 eq 80
+access-group inside_in in interface inside
+=OPTIONS=--trace 10.1.1.1,10.9.9.9,tcp:80,inside
+=OUTPUT=
+device: inside_in: rule 1 permit: access-list inside_in extended permit tcp any4 host 10.9.9.9 eq 80
+netspoc: inside_in: rule 1 permit: access-list inside_in extended permit tcp any4 host 10.9.9.9 eq 80
+result: unchanged
+=END=
+
+############################################################
+=TITLE=Warn about shadowed, redundant and overriding rules
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif inside
+=NETSPOC=
+--router
+access-list inside_in extended permit tcp host 10.1.1.1 host 10.9.9.9 eq 80
+access-list inside_in extended permit tcp 10.1.1.0 255.255.255.0 host 10.9.9.9 range 80 90
+access-list inside_in extended permit tcp host 10.1.1.2 host 10.9.9.9 eq 81
+access-list inside_in extended permit udp 10.1.2.0 255.255.255.0 any4 eq 53
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--router.raw
+access-list inside_in extended deny tcp host 10.1.1.1 any4
+access-list inside_in extended deny udp host 10.1.2.2 any4
+access-group inside_in in interface inside
+=OPTIONS=-C
+=WARNING=
+WARNING>>> Rule 3 of inside_in is shadowed by raw rule 1
+WARNING>>>  1: access-list inside_in extended deny tcp host 10.1.1.1 any4
+WARNING>>>  3: access-list inside_in extended permit tcp host 10.1.1.1 host 10.9.9.9 eq 80
+WARNING>>> Raw rule 1 of inside_in overrides rule 4 from Netspoc
+WARNING>>>  1: access-list inside_in extended deny tcp host 10.1.1.1 any4
+WARNING>>>  4: access-list inside_in extended permit tcp 10.1.1.0 255.255.255.0 host 10.9.9.9 range 80 90
+WARNING>>> Rule 5 of inside_in is redundant to rule 4
+WARNING>>>  4: access-list inside_in extended permit tcp 10.1.1.0 255.255.255.0 host 10.9.9.9 range 80 90
+WARNING>>>  5: access-list inside_in extended permit tcp host 10.1.1.2 host 10.9.9.9 eq 81
+WARNING>>> Raw rule 2 of inside_in overrides rule 6 from Netspoc
+WARNING>>>  2: access-list inside_in extended deny udp host 10.1.2.2 any4
+WARNING>>>  6: access-list inside_in extended permit udp 10.1.2.0 255.255.255.0 any4 eq 53
+=END=
+
+############################################################
+=TITLE=Leave protected ACL, object-group and route unchanged
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif inside
+interface Ethernet0/1
+ nameif outside
+route inside 10.99.1.0 255.255.255.0 10.1.2.9
+route inside 10.20.0.0 255.255.0.0 10.1.2.9
+object-group network EMERG-hosts
+ network-object host 10.1.1.66
+access-list EMERG extended permit ip object-group EMERG-hosts any4
+access-list inside_in-DRC-0 extended permit ip host 1.1.1.1 any4
+access-group EMERG in interface outside
+access-group inside_in-DRC-0 in interface inside
+# write memory
+Building configuration...
+[OK]
+=SETUP=
+cat <<END > protect
+# Emergency rules of operator
+router acl EMERG
+* route 10.99.0.0/16
+other acl inside_in-DRC-0
+END
+=NETSPOC=
+route inside 10.30.0.0 255.255.0.0 10.1.2.9
+access-list inside_in extended permit ip host 2.2.2.2 any4
+access-group inside_in in interface inside
+=WARNING=
+WARNING>>> Interface 'outside' on device is not known by Netspoc
+=OUTPUT=
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#access-list inside_in-DRC-1 extended permit ip host 2.2.2.2 any4
+router#access-group inside_in-DRC-1 in interface inside
+router#route inside 10.30.0.0 255.255.0.0 10.1.2.9
+router#no route inside 10.20.0.0 255.255.0.0 10.1.2.9
+router#clear configure access-list inside_in-DRC-0
+router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
+router#write memory
+Building configuration...
+[OK]
+router#
+=END=
+
+############################################################
+=TITLE=Netspoc must not change binding of protected ACL
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif inside
+access-list EMERG extended permit ip host 10.1.1.66 any4
+access-group EMERG in interface inside
+=SETUP=
+echo '* acl EMERG' > protect
+=NETSPOC=
+access-list inside_in extended permit ip host 2.2.2.2 any4
+access-group inside_in in interface inside
+=ERROR=
+ERROR>>> Must not change binding of protected ACL: access-group EMERG in interface inside
+=END=
+
+############################################################
+=TITLE=Netspoc must not change protected route
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif inside
+route inside 10.99.1.0 255.255.255.0 10.1.2.9
+=SETUP=
+echo '* route 10.99.0.0/16' > protect
+=NETSPOC=
+route inside 10.99.1.0 255.255.255.0 10.1.2.8
+=ERROR=
+ERROR>>> Must not change protected route: route inside 10.99.1.0 255.255.255.0 10.1.2.8
+=END=
+
+############################################################
+=TITLE=Invalid protect file
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif inside
+=SETUP=
+echo 'router interface Ethernet0/0' > protect
+=NETSPOC=
+route inside 10.99.1.0 255.255.255.0 10.1.2.8
+=ERROR=
+ERROR>>> Unknown kind 'interface' in protect
+=END=
+
+############################################################
+=TITLE=Interactive approve, leave routes unchanged
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif inside
+route inside 0.0.0.0 0.0.0.0 10.1.2.3
+access-list inside extended permit ip host 1.1.1.1 any
+access-group inside in interface inside
+# write memory
+Building configuration...
+Cryptochecksum: abcdef01 44444444 12345678 98765432
+
+123456 bytes copied in 0.330 secs
+[OK]
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+access-list inside extended permit ip host 1.1.1.1 any
+access-list inside extended permit ip host 2.2.2.2 any
+access-group inside in interface inside
+=OPTIONS=-i
+=INPUT=
+s
+y
+n
+=OUTPUT=
+### Changes of acl
+access-list inside line 2 extended permit ip host 2.2.2.2 any
+### Changes of routes
+no route inside 0.0.0.0 0.0.0.0 10.1.2.3\N route inside 0.0.0.0 0.0.0.0 10.1.2.4
+Apply changes to router? [y]es, [n]o, [s]elect: s
+Apply changes of acl? [y]es, [n]o: y
+Apply changes of routes? [y]es, [n]o: n
+--router.change
+copy /noconfirm running-config disk0:/netspoc-approve-router.cfg
+Cryptochecksum: 8b2a3bbc 32bfed4b 19ab8d39 0b2e5b61
+
+4523 bytes copied in 0.220 secs
+router#configure terminal
+router#access-list inside line 2 extended permit ip host 2.2.2.2 any
+router#end
+router#delete /noconfirm disk0:/netspoc-approve-router.cfg
+router#write memory
+Building configuration...
+Cryptochecksum: abcdef01 44444444 12345678 98765432
+
+123456 bytes copied in 0.330 secs
+[OK]
+router#
+=END=
+
+############################################################
+=TITLE=Interactive approve, ACL of crypto map in group crypto
+=SCENARIO=
+[[login_scenario]]
+# sh run
+interface Ethernet0/0
+ nameif outside
+access-list crypto-DRC-0 extended permit ip host 10.1.1.1 any4
+crypto map map-outside 1 match address crypto-DRC-0
+crypto map map-outside 1 set peer 10.2.2.2
+crypto map map-outside interface outside
+=NETSPOC=
+access-list crypto extended permit ip host 10.1.1.2 any4
+crypto map map-outside 1 match address crypto
+crypto map map-outside 1 set peer 10.2.2.2
+crypto map map-outside interface outside
+route outside 0.0.0.0 0.0.0.0 10.1.2.4
+=OPTIONS=-i
+=INPUT=
+n
+=ERROR=
+ERROR>>> Approve cancelled by operator
+=OUTPUT=
+### Changes of crypto
+access-list crypto-DRC-1 extended permit ip host 10.1.1.2 any4
+crypto map map-outside 1 match address crypto-DRC-1
+clear configure access-list crypto-DRC-0
+### Changes of routes
+route outside 0.0.0.0 0.0.0.0 10.1.2.4
+Apply changes to router? [y]es, [n]o, [s]elect: n
+=END=
+
+############################################################
+=TITLE=Interactive approve, cancelled
+=SCENARIO=
+[[login_scenario]]
+# sh run
+route inside 0.0.0.0 0.0.0.0 10.1.2.3
+=NETSPOC=
+route inside 0.0.0.0 0.0.0.0 10.1.2.4
+=OPTIONS=-i
+=INPUT=
+x
+n
+=ERROR=
+ERROR>>> Approve cancelled by operator
+=OUTPUT=
+### Changes of routes
+no route inside 0.0.0.0 0.0.0.0 10.1.2.3\N route inside 0.0.0.0 0.0.0.0 10.1.2.4
+Apply changes to router? [y]es, [n]o, [s]elect: x
+Apply changes to router? [y]es, [n]o, [s]elect: n
+=END=
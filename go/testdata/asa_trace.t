@@ -0,0 +1,150 @@
+############################################################
+=TITLE=Flow permitted on device, denied by Netspoc
+=DEVICE=
+object-group network g1
+ network-object 10.1.1.0 255.255.255.0
+ network-object host 10.1.2.2
+access-list inside_in extended permit tcp object-group g1 host 10.9.9.9 eq 80
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+=NETSPOC=
+access-list inside_in extended permit tcp host 10.1.1.1 host 10.9.9.9 eq 443
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,tcp:80,inside
+=OUTPUT=
+device: inside_in: rule 1 permit: access-list inside_in extended permit tcp object-group g1 host 10.9.9.9 eq 80
+netspoc: inside_in: rule 2 deny: access-list inside_in extended deny ip any4 any4
+result: changed
+=END=
+
+############################################################
+=TITLE=Flow denied by default
+=DEVICE=
+access-list inside_in extended permit udp any4 host 10.9.9.9 range 50 60
+access-group inside_in in interface inside
+=NETSPOC=
+access-list inside_in extended permit udp any4 host 10.9.9.9 eq 53
+access-group inside_in in interface inside
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,udp:123,inside
+=OUTPUT=
+device: inside_in: default deny
+netspoc: inside_in: default deny
+result: unchanged
+=END=
+
+############################################################
+=TITLE=Evaluate ACL of incoming, outgoing and global access-group
+=DEVICE=NONE
+=NETSPOC=
+access-list inside_in extended permit icmp any4 any4 8
+access-group inside_in in interface inside
+access-list outside_out extended permit icmp any4 any4 3
+access-group outside_out out interface outside
+access-list dmz_in extended permit ip any4 any4
+access-group dmz_in in interface dmz
+access-list global extended deny ip any4 any4
+access-group global global
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,icmp:8,inside:outside
+=OUTPUT=
+device: no rules apply
+netspoc: global: rule 1 deny: access-list global extended deny ip any4 any4
+netspoc: inside_in: rule 1 permit: access-list inside_in extended permit icmp any4 any4 8
+netspoc: outside_out: default deny
+result: changed
+=END=
+
+############################################################
+=TITLE=Rule with unknown object may match
+=DEVICE=
+access-list inside_in extended permit tcp any4 object o1 eq 80
+access-list inside_in extended permit tcp any4 host 10.9.9.9
+access-group inside_in in interface inside
+=NETSPOC=
+access-list inside_in extended permit tcp any4 host 10.9.9.9
+access-group inside_in in interface inside
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,tcp:80,inside
+=OUTPUT=
+device: inside_in: rule 1 may match: access-list inside_in extended permit tcp any4 object o1 eq 80
+device: inside_in: rule 2 permit: access-list inside_in extended permit tcp any4 host 10.9.9.9
+netspoc: inside_in: rule 1 permit: access-list inside_in extended permit tcp any4 host 10.9.9.9
+result: unchanged
+=END=
+
+############################################################
+=TITLE=IPv6 flow
+=DEVICE=NONE
+=NETSPOC=
+--router
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+--ipv6/router
+access-list inside_in extended permit tcp 2001:db8:1::/64 any6 eq 22
+access-list inside_in extended deny ip any6 any6
+access-group inside_in in interface inside
+=OPTIONS=--trace 2001:db8:1::10,2001:db8:9::9,tcp:22,inside
+=OUTPUT=
+device: no rules apply
+netspoc: inside_in: rule 1 permit: access-list inside_in extended permit tcp 2001:db8:1::/64 any6 eq 22
+result: changed
+=END=
+
+############################################################
+=TITLE=Port neq 0 matches all other ports
+=DEVICE=
+access-list inside_in extended permit tcp any4 host 10.9.9.9 neq 0
+access-group inside_in in interface inside
+=NETSPOC=
+access-list inside_in extended permit tcp any4 host 10.9.9.9 eq 0
+access-group inside_in in interface inside
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,tcp:65535,inside
+=OUTPUT=
+device: inside_in: rule 1 permit: access-list inside_in extended permit tcp any4 host 10.9.9.9 neq 0
+netspoc: inside_in: default deny
+result: changed
+=END=
+
+############################################################
+=TITLE=Port lt 0 and gt 65535 can't be converted
+=DEVICE=
+access-list inside_in extended permit tcp any4 host 10.9.9.9 lt 0
+access-list inside_in extended permit tcp any4 host 10.9.9.9 gt 65535
+access-group inside_in in interface inside
+=NETSPOC=
+access-list inside_in extended deny ip any4 any4
+access-group inside_in in interface inside
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,tcp:0,inside
+=OUTPUT=
+device: inside_in: rule 1 may match: access-list inside_in extended permit tcp any4 host 10.9.9.9 lt 0
+device: inside_in: rule 2 may match: access-list inside_in extended permit tcp any4 host 10.9.9.9 gt 65535
+device: inside_in: default deny
+netspoc: inside_in: rule 1 deny: access-list inside_in extended deny ip any4 any4
+result: unchanged
+=END=
+
+############################################################
+=TITLE=Invalid flow
+=DEVICE=NONE
+=NETSPOC=NONE
+=OPTIONS=--trace 10.1.2.2,2001:db8::1,tcp
+=ERROR=
+Error: Must not mix IPv4 and IPv6 addresses in flow
+=END=
+
+############################################################
+=TITLE=Invalid port in flow
+=DEVICE=NONE
+=NETSPOC=NONE
+=OPTIONS=--trace 10.1.2.2,10.9.9.9,tcp:http
+=ERROR=
+Error: Invalid port "http" in flow
+=END=
+
+############################################################
+=TITLE=Incomplete flow
+=DEVICE=NONE
+=NETSPOC=NONE
+=OPTIONS=--trace 10.1.2.2,10.9.9.9
+=ERROR=
+Error: Expected flow as "SRC DST PROTO[:PORT] [IN[:OUT]]", got "10.1.2.2,10.9.9.9"
+=END=
@@ -0,0 +1,149 @@
+=TEMPL=vti
+crypto ipsec ikev2 ipsec-proposal Proposal1
+ protocol esp encryption aes-256
+ protocol esp integrity sha-256
+crypto ipsec profile vti-prof
+ set ikev2 ipsec-proposal Proposal1
+ set pfs group14
+interface Tunnel1
+ nameif vti1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source interface outside
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof
+access-list vti1_in extended permit ip 10.2.0.0 255.255.0.0 any4
+access-group vti1_in in interface vti1
+route vti1 10.2.0.0 255.255.0.0 10.9.9.2
+=TEMPL=device
+interface Ethernet0/1
+ nameif outside
+=END=
+
+############################################################
+=TITLE=Add tunnel interface with IPsec profile, ACL and route
+=DEVICE=
+[[device]]
+=NETSPOC=
+[[vti]]
+=WARNING=
+WARNING>>> Interface 'outside' on device is not known by Netspoc
+=OUTPUT=
+crypto ipsec ikev2 ipsec-proposal Proposal1-DRC-0
+protocol esp encryption aes-256
+protocol esp integrity sha-256
+crypto ipsec profile vti-prof-DRC-0
+set ikev2 ipsec-proposal Proposal1-DRC-0
+set pfs group14
+interface Tunnel1
+nameif vti1
+ip address 10.9.9.1 255.255.255.252
+tunnel source interface outside
+tunnel destination 198.51.100.1
+tunnel mode ipsec ipv4
+tunnel protection ipsec profile vti-prof-DRC-0
+access-list vti1_in-DRC-0 extended permit ip 10.2.0.0 255.255.0.0 any4
+access-group vti1_in-DRC-0 in interface vti1
+route vti1 10.2.0.0 255.255.0.0 10.9.9.2
+=END=
+
+############################################################
+=TITLE=Unchanged tunnel interface
+=DEVICE=
+[[device]]
+crypto ipsec ikev2 ipsec-proposal Proposal1-DRC-0
+ protocol esp encryption aes-256
+ protocol esp integrity sha-256
+crypto ipsec profile vti-prof-DRC-0
+ set ikev2 ipsec-proposal Proposal1-DRC-0
+ set pfs group14
+interface Tunnel1
+ nameif vti1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source interface outside
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+access-list vti1_in-DRC-0 extended permit ip 10.2.0.0 255.255.0.0 any4
+access-group vti1_in-DRC-0 in interface vti1
+route vti1 10.2.0.0 255.255.0.0 10.9.9.2
+=NETSPOC=
+[[vti]]
+=WARNING=
+WARNING>>> Interface 'outside' on device is not known by Netspoc
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change tunnel destination
+=DEVICE=
+[[device]]
+crypto ipsec ikev2 ipsec-proposal Proposal1-DRC-0
+ protocol esp encryption aes-256
+ protocol esp integrity sha-256
+crypto ipsec profile vti-prof-DRC-0
+ set ikev2 ipsec-proposal Proposal1-DRC-0
+ set pfs group14
+interface Tunnel1
+ nameif vti1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source interface outside
+ tunnel destination 198.51.100.9
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+access-list vti1_in-DRC-0 extended permit ip 10.2.0.0 255.255.0.0 any4
+access-group vti1_in-DRC-0 in interface vti1
+route vti1 10.2.0.0 255.255.0.0 10.9.9.2
+=NETSPOC=
+[[vti]]
+=WARNING=
+WARNING>>> Interface 'outside' on device is not known by Netspoc
+=OUTPUT=
+interface Tunnel1
+no tunnel destination 198.51.100.9
+tunnel destination 198.51.100.1
+=END=
+
+############################################################
+=TITLE=Leave ip address of other interface unchanged
+=DEVICE=
+interface Ethernet0/1
+ nameif outside
+ ip address 192.0.2.1 255.255.255.0
+=NETSPOC=
+interface Ethernet0/1
+ nameif outside
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Remove tunnel interface
+=DEVICE=
+[[device]]
+crypto ipsec ikev2 ipsec-proposal Proposal1-DRC-0
+ protocol esp encryption aes-256
+ protocol esp integrity sha-256
+crypto ipsec profile vti-prof-DRC-0
+ set ikev2 ipsec-proposal Proposal1-DRC-0
+ set pfs group14
+interface Tunnel1
+ nameif vti1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source interface outside
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+access-list vti1_in-DRC-0 extended permit ip 10.2.0.0 255.255.0.0 any4
+access-group vti1_in-DRC-0 in interface vti1
+route vti1 10.2.0.0 255.255.0.0 10.9.9.2
+=NETSPOC=
+interface Ethernet0/1
+ nameif outside
+route outside 10.3.0.0 255.255.0.0 192.0.2.2
+=OUTPUT=
+route outside 10.3.0.0 255.255.0.0 192.0.2.2
+no route vti1 10.2.0.0 255.255.0.0 10.9.9.2
+no access-group vti1_in-DRC-0 in interface vti1
+no interface Tunnel1
+clear configure access-list vti1_in-DRC-0
+no crypto ipsec profile vti-prof-DRC-0
+no crypto ipsec ikev2 ipsec-proposal Proposal1-DRC-0
+=END=
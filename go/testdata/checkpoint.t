@@ -1079,3 +1079,266 @@ delete-access-rule
 delete-service-other
 {"uid":"id-1"}
 =END=
+
+############################################################
+=TITLE=Dump Netspoc config
+=NETSPOC=
+{
+  "TargetRules": {"fw1": [
+    {
+      "name": "test rule",
+      "action": "Accept",
+      "source": ["n_10.1.1.0-24"],
+      "destination": ["h_10.1.8.1"],
+      "service": ["tcp_81"],
+      "install-on": ["Policy Targets"]
+    }
+  ]},
+  "Networks": [
+    {
+      "name": "n_10.1.1.0-24",
+      "subnet4": "10.1.1.0",
+      "mask-length4": 24
+    }
+ ],
+  "Hosts": [
+    {
+      "name": "h_10.1.8.1",
+      "ipv4-address": "10.1.8.1"
+    }
+ ],
+  "TCP": [
+    {
+      "name": "tcp_81",
+      "port": "81"
+    }
+ ]
+}
+=SETUP=
+cp code/router device
+=OPTIONS=--dump
+=OUTPUT=
+{
+	"TargetPolicy": null,
+	"TargetRules": {
+		"fw1": [
+			{
+				"name": "test rule",
+				"action": "Accept",
+				"source": [
+					"n_10.1.1.0-24"
+				],
+				"destination": [
+					"h_10.1.8.1"
+				],
+				"service": [
+					"tcp_81"
+				],
+				"install-on": [
+					"Policy Targets"
+				]
+			}
+		]
+	},
+	"Networks": [
+		{
+			"name": "n_10.1.1.0-24",
+			"subnet4": "10.1.1.0",
+			"mask-length4": 24
+		}
+	],
+	"Hosts": [
+		{
+			"name": "h_10.1.8.1",
+			"ipv4-address": "10.1.8.1"
+		}
+	],
+	"Groups": null,
+	"TCP": [
+		{
+			"name": "tcp_81",
+			"port": "81"
+		}
+	],
+	"UDP": null,
+	"ICMP": null,
+	"ICMP6": null,
+	"SvOther": null,
+	"GatewayRoutes": null,
+	"GatewayIPs": null
+}
+=END=
+
+############################################################
+=TITLE=Export rules
+=NETSPOC=
+{
+  "TargetRules": {"fw1": [
+    {
+      "name": "r1",
+      "action": "Accept",
+      "source": ["g1"],
+      "destination": ["h_10.1.8.1"],
+      "service": ["tcp_81", "udp_high"],
+      "track": {"type": "Log"},
+      "install-on": ["Policy Targets"]
+    },
+    {
+      "name": "r2",
+      "action": "Drop",
+      "source": ["Any"],
+      "destination": ["h_10.1.8.1"],
+      "destination-negate": true,
+      "service": ["Any"],
+      "install-on": ["Policy Targets"]
+    }
+  ]},
+  "Networks": [
+    {
+      "name": "n_10.1.1.0-24",
+      "subnet4": "10.1.1.0",
+      "mask-length4": 24
+    }
+ ],
+  "Hosts": [
+    {
+      "name": "h_10.1.8.1",
+      "ipv4-address": "10.1.8.1"
+    }
+ ],
+  "Groups": [
+    {
+      "name": "g1",
+      "members": ["n_10.1.1.0-24", "h_10.1.8.1"]
+    }
+ ],
+  "TCP": [
+    {
+      "name": "tcp_81",
+      "port": "81"
+    }
+ ],
+  "UDP": [
+    {
+      "name": "udp_high",
+      "port": ">1023"
+    }
+ ]
+}
+=SETUP=
+cp code/router device
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "fw1",
+			"bindings": [
+				{
+					"name": "fw1"
+				}
+			],
+			"rules": [
+				{
+					"name": "r1",
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.1.1.0/24",
+							"10.1.8.1/32"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.1.8.1/32"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 81,
+									"high": 81
+								}
+							]
+						},
+						{
+							"proto": "udp",
+							"dst_ports": [
+								{
+									"low": 1024,
+									"high": 65535
+								}
+							]
+						}
+					],
+					"log": true,
+					"orig": "r1"
+				},
+				{
+					"name": "r2",
+					"position": 2,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0",
+							"::/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.1.8.1/32"
+						],
+						"negate": true
+					},
+					"orig": "r2"
+				}
+			],
+			"default": "deny"
+		}
+	]
+}
+=END=
+
+############################################################
+=TITLE=Trace flow on gateway
+=DEVICE=
+{
+  "TargetRules": {"fw1": [
+    {
+      "name": "r1",
+      "action": "Drop",
+      "source": ["Any"],
+      "destination": ["Any"],
+      "service": ["Any"],
+      "install-on": ["Policy Targets"]
+    }
+  ]}
+}
+=NETSPOC=
+{
+  "TargetRules": {"fw1": [
+    {
+      "name": "r1",
+      "action": "Accept",
+      "source": ["Any"],
+      "destination": ["h_10.1.8.1"],
+      "service": ["Any"],
+      "install-on": ["Policy Targets"]
+    }
+  ]},
+  "Hosts": [
+    {
+      "name": "h_10.1.8.1",
+      "ipv4-address": "10.1.8.1"
+    }
+ ]
+}
+=OPTIONS=--trace 10.1.1.1,10.1.8.1,udp:53,fw1
+=OUTPUT=
+device: fw1: rule 1 deny: r1
+netspoc: fw1: rule 1 permit: r1
+result: changed
+=END=
@@ -8,7 +8,7 @@
  "TargetRules": INVALID
 }
 =ERROR=
-ERROR>>> While reading file router.raw: invalid character 'I' looking for beginning of value
+ERROR>>> While reading file router.raw: invalid character 'I' looking for beginning of value in line 2
 =END=
 
 ############################################################
@@ -246,3 +246,170 @@ add-access-rule
  "action":"Accept","source":["g1"],"destination":null,"service":["tcp_8080"],
  "install-on":["Policy Targets"],"position":{"above":"id"}}
 =END=
+
+############################################################
+=TITLE=Lint attribute "install-on" from raw
+=SCENARIO=NONE
+=NETSPOC=
+--router
+{
+  "TargetRules": {"fw1": []}
+}
+--router.raw
+{
+  "TargetRules": {"fw1": [
+    {
+      "name": "Raw http",
+      "uid": "id-http",
+      "action": "Accept",
+      "source": ["Any"],
+      "destination": ["Any"],
+      "service": ["http"],
+      "install-on": ["other-fw"]
+    }
+  ]}
+}
+=PARAMS=--lint code/router
+=ERROR=
+ERROR>>> While reading file router.raw: Must use "install-on": ["Policy Targets"] in rule "Raw http" of "fw1"
+=END=
+
+############################################################
+=TITLE=Replace, delete and insert rules from raw
+=DEVICE=
+{ "TargetPolicy": {"fw1": {"Name": "standard", "Layer": "network"}},
+  "TargetRules": {"fw1": [
+   { "name": "rule_1", "uid": "id-1", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["http"] },
+   { "name": "rule_2", "uid": "id-2", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["smtp"] },
+   { "name": "rule_3", "uid": "id-3", "install-on": ["Policy Targets"],
+     "action": "Drop" }
+  ]}
+}
+=NETSPOC=
+-- router
+{ "TargetRules": {"fw1": [
+   { "name": "rule_1", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["http"] },
+   { "name": "rule_2", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["smtp"] },
+   { "name": "rule_3", "install-on": ["Policy Targets"],
+     "action": "Drop" }
+  ]}
+}
+-- router.raw
+{ "TargetRules": {"fw1": [
+   { "name": "rule_1", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["https"], "replace": true },
+   { "name": "rule_2", "delete": true },
+   { "name": "Raw a1", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["ssh"], "after": "rule_1" },
+   { "name": "Raw a2", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["ftp"], "after": "rule_1" },
+   { "name": "Raw b", "install-on": ["Policy Targets"],
+     "action": "Drop", "service": ["telnet"], "before": "rule_3" }
+  ]}
+}
+=OUTPUT=
+set-access-rule
+{"layer":"network","service":{"add":["https"]},"uid":"id-1"}
+set-access-rule
+{"layer":"network","service":{"remove":["http"]},"uid":"id-1"}
+delete-access-rule
+{"layer":"network","uid":"id-2"}
+add-access-rule
+{"name":"Raw a1","layer":"network","action":"Accept","source":null,"destination":null,"service":["ssh"],"install-on":["Policy Targets"],"position":{"above":"id-3"}}
+add-access-rule
+{"name":"Raw a2","layer":"network","action":"Accept","source":null,"destination":null,"service":["ftp"],"install-on":["Policy Targets"],"position":{"above":"id-3"}}
+add-access-rule
+{"name":"Raw b","layer":"network","action":"Drop","source":null,"destination":null,"service":["telnet"],"install-on":["Policy Targets"],"position":{"above":"id-3"}}
+=END=
+
+############################################################
+=TITLE=Replace object from raw
+=DEVICE=
+{ "TargetPolicy": {"fw1": {"Name": "standard", "Layer": "network"}} }
+=NETSPOC=
+-- router
+{ "TargetRules": {"fw1": [
+   { "name": "rule_1", "install-on": ["Policy Targets"],
+     "action": "Accept", "source": ["g_1"], "service": ["tcp_8080"] }
+  ]},
+ "Hosts": [
+ { "name": "h_1", "ipv4-address": "10.1.8.1" },
+ { "name": "h_2", "ipv4-address": "10.1.8.2" } ],
+ "Groups": [
+ { "name": "g_1", "members": ["h_1"] } ],
+ "TCP": [
+ { "name": "tcp_8080", "port": "8080" } ]
+}
+-- router.raw
+{ "Groups": [
+ { "name": "g_1", "members": ["h_1", "h_2"], "replace": true } ]
+}
+=OUTPUT=
+add-host
+{"name":"h_1","ignore-warnings":true,"ipv4-address":"10.1.8.1"}
+add-host
+{"name":"h_2","ignore-warnings":true,"ipv4-address":"10.1.8.2"}
+add-group
+{"name":"g_1","members":["h_1","h_2"]}
+add-service-tcp
+{"name":"tcp_8080","ignore-warnings":true,"port":"8080"}
+add-access-rule
+{"name":"rule_1","layer":"network","action":"Accept","source":["g_1"],"destination":null,"service":["tcp_8080"],"install-on":["Policy Targets"],"position":"bottom"}
+=END=
+
+############################################################
+=TITLE=Unknown rule in "before" of raw
+=DEVICE=
+{ "TargetPolicy": {"fw1": {"Name": "standard", "Layer": "network"}} }
+=NETSPOC=
+-- router
+{ "TargetRules": {"fw1": [
+   { "name": "rule_1", "install-on": ["Policy Targets"],
+     "action": "Accept", "service": ["http"] }
+  ]}
+}
+-- router.raw
+{ "TargetRules": {"fw1": [
+   { "name": "Raw b", "install-on": ["Policy Targets"],
+     "action": "Drop", "service": ["telnet"], "before": "rule_9" }
+  ]}
+}
+=ERROR=
+ERROR>>> While reading file router.raw: Unknown rule "rule_9" in "before" of "fw1"
+=END=
+
+############################################################
+=TITLE=Replace unknown object from raw
+=DEVICE=
+{ "TargetPolicy": {"fw1": {"Name": "standard", "Layer": "network"}} }
+=NETSPOC=
+-- router
+{ "TargetRules": {"fw1": []} }
+-- router.raw
+{ "Hosts": [
+ { "name": "h_1", "ipv4-address": "10.1.8.1", "replace": true } ]
+}
+=ERROR=
+ERROR>>> While reading file router.raw: Can't replace unknown host "h_1"
+=END=
+
+############################################################
+=TITLE=Conflicting attributes of rule from raw
+=DEVICE=
+{ "TargetPolicy": {"fw1": {"Name": "standard", "Layer": "network"}} }
+=NETSPOC=
+-- router
+{ "TargetRules": {"fw1": []} }
+-- router.raw
+{ "TargetRules": {"fw1": [
+   { "name": "Raw b", "install-on": ["Policy Targets"],
+     "action": "Drop", "append": true, "before": "rule_1" }
+  ]}
+}
+=ERROR=
+ERROR>>> While reading file router.raw: Must use only one of "append", "prepend", "replace", "delete", "before", "after" in rule "Raw b"
+=END=
@@ -475,6 +475,36 @@ TESTSERVER/web_api/login
 
 =END=
 
+############################################################
+=TITLE=Leave protected rule unchanged
+=SCENARIO=
+[[standard]]
+[[simple_rule]]
+=SETUP=
+echo 'router rule rule*' > protect
+=NETSPOC=
+{ "TargetRules": {"fw1": []} }
+=OUTPUT=
+--router.change
+No changes applied
+=END=
+
+############################################################
+=TITLE=Netspoc must not change protected rule
+=SCENARIO=
+[[standard]]
+[[simple_rule]]
+=SETUP=
+echo 'router rule rule*' > protect
+=NETSPOC=
+{ "TargetRules": {"fw1": [
+  { "name": "rule1", "action": "Drop", "install-on": ["Policy Targets"] }
+ ]}
+}
+=ERROR=
+ERROR>>> Must not change protected rule "rule1" of "fw1"
+=END=
+
 ############################################################
 =TITLE=Remove simple rule, delete-access-rule fails
 =SCENARIO=
@@ -6,8 +6,12 @@
 =PARAMS=--unknown
 =ERROR=
 Error: unknown flag: --unknown
-Usage: do-approve [options] approve|compare DEVICE
-  -b, --brief   Suppress message about unreachable device
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
 =END=
 
 ############################################################
@@ -17,8 +21,12 @@ Usage: do-approve [options] approve|compare DEVICE
 =NETSPOC=NONE
 =PARAMS=-h
 =ERROR=
-Usage: do-approve [options] approve|compare DEVICE
-  -b, --brief   Suppress message about unreachable device
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
 =END=
 
 ############################################################
@@ -28,8 +36,12 @@ Usage: do-approve [options] approve|compare DEVICE
 =NETSPOC=NONE
 =PARAMS=NONE
 =ERROR=
-Usage: do-approve [options] approve|compare DEVICE
-  -b, --brief   Suppress message about unreachable device
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
 =END=
 
 ############################################################
@@ -39,8 +51,12 @@ Usage: do-approve [options] approve|compare DEVICE
 =NETSPOC=NONE
 =PARAMS=blabla router
 =ERROR=
-Usage: do-approve [options] approve|compare DEVICE
-  -b, --brief   Suppress message about unreachable device
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
 =END=
 
 ############################################################
@@ -50,8 +66,12 @@ Usage: do-approve [options] approve|compare DEVICE
 =NETSPOC=NONE
 =PARAMS=compare
 =ERROR=
-Usage: do-approve [options] approve|compare DEVICE
-  -b, --brief   Suppress message about unreachable device
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
 =END=
 
 ############################################################
@@ -61,8 +81,27 @@ Usage: do-approve [options] approve|compare DEVICE
 =NETSPOC=NONE
 =PARAMS=compare router1 router2
 =ERROR=
-Usage: do-approve [options] approve|compare DEVICE
-  -b, --brief   Suppress message about unreachable device
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
+=END=
+
+############################################################
+=TITLE=Option --force together with --resume
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=PARAMS=--force --resume approve router
+=ERROR=
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
 =END=
 
 ############################################################
@@ -98,6 +137,77 @@ rm policies/p1/code/router
 Error: unknown device "router"
 =END=
 
+############################################################
+=TITLE=Not authorized to compare device
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=SETUP=
+echo 'other * compare,approve' > authorization
+=ERROR=
+Error: Not authorized to compare device "router"
+=OUTPUT=
+--history/router
+2024 09 29 16:19:50 START: compare router
+2024 09 29 16:19:50 DENIED: Not authorized to compare device "router"
+2024 09 29 16:19:50 END: DENIED
+=END=
+
+############################################################
+=TITLE=Authorized to compare device by pattern
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=SETUP=
+echo '# Any user may compare
+rou* * compare' > authorization
+=ERROR=
+FAILED, details in policies/p1/log/router.compare
+=OUTPUT=
+ERROR>>> Unexpected model "DO-APPROVE" in file policies/p1/code/router.info
+=END=
+
+############################################################
+=TITLE=Unknown action in authorization file
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=SETUP=
+echo 'router * compare,delete' > authorization
+=ERROR=
+Error: Unknown action "delete" in line 1 of authorization
+=END=
+
+############################################################
+=TITLE=Not authorized to approve with --force
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=PARAMS=--force approve router
+=SETUP=
+echo '* * compare,approve' > authorization
+=ERROR=
+Error: Not authorized to force device "router"
+=OUTPUT=
+--history/router
+2024 09 29 16:19:50 START: --force approve router
+2024 09 29 16:19:50 DENIED: Not authorized to force device "router"
+2024 09 29 16:19:50 END: DENIED
+=END=
+
+############################################################
+=TITLE=Invalid pattern in authorization file
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=SETUP=
+echo '# Comment
+
+[router * compare' > authorization
+=ERROR=
+Error: Invalid pattern "[router" in line 3 of authorization
+=END=
+
 ############################################################
 =TITLE=Missing lockfile dir
 =DO_APPROVE=
@@ -1,12 +1,30 @@
 =TEMPL=usage
 Usage: drc [options] FILE1
-     : drc [-q] FILE1 FILE2
-      --LOGFILE string   Path to redirect STDERR
-  -C, --compare          Compare only
-  -L, --logdir string    Path for saving session logs
-  -q, --quiet            No info messages
-  -u, --user string      Username for login to remote device
-  -v, --version          Show version
+     : drc [-q] [--trace FLOW] FILE1 FILE2
+     : drc --dump|--rules FILE1 [FILE2]
+     : drc [-q] --lint FILE|DIR ...
+      --LOGFILE string       Path to redirect STDERR
+  -A, --check-access         Only check login to device, don't read its configuration
+  -C, --compare              Compare only
+  -D, --dump                 Print parsed config of FILE1 as JSON,
+                             take model from info file of FILE2 if given
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+  -i, --interactive          Show changes and ask for confirmation before approve,
+                             default if STDIN is a terminal
+  -l, --lint                 Check raw file of FILE or raw files of all devices in DIR,
+                             don't access any device
+  -L, --logdir string        Path for saving session logs
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES,
+                             from acl, crypto, iptables, routes, rules
+  -q, --quiet                No info messages
+      --resume               Continue interrupted approve, show commands from journal
+  -R, --rules                Print rules of FILE1 in vendor neutral format as JSON,
+                             take model from info file of FILE2 if given
+  -T, --trace FLOW           Show first rule matching FLOW on device and from Netspoc,
+                             FLOW is given as "SRC DST PROTO[:PORT] [IN[:OUT]]"
+  -u, --user string          Username for login to remote device
+  -v, --version              Show version
 =END=
 
 ############################################################
@@ -61,6 +79,45 @@ version devel
 [[usage]]
 =END=
 
+############################################################
+=TITLE=Lint without argument
+=SCENARIO=NONE
+=NETSPOC=NONE
+=PARAMS=--lint
+=ERROR=
+[[usage]]
+=END=
+
+############################################################
+=TITLE=Lint with other option
+=SCENARIO=NONE
+=NETSPOC=NONE
+=PARAMS=--lint -C code/router
+=ERROR=
+[[usage]]
+=END=
+
+############################################################
+=TITLE=Lint unknown file
+=SCENARIO=NONE
+=NETSPOC=NONE
+=PARAMS=--lint code/other
+=ERROR=
+ERROR>>> Can't stat code/other: no such file or directory
+=END=
+
+############################################################
+=TITLE=Lint with missing device type
+=SCENARIO=NONE
+=NETSPOC=
+--router.info
+{"ip_list": ["1.2.3.4"] }
+--router.raw
+=PARAMS=--lint code
+=ERROR=
+ERROR>>> Unexpected model "" in file code/router.info
+=END=
+
 ############################################################
 =TITLE=Missing device type
 =NETSPOC=
@@ -76,7 +133,7 @@ ERROR>>> Unexpected model "" in file code/router.info
 --router.info
 NO_JSON
 =ERROR=
-panic: invalid character 'N' looking for beginning of value
+ERROR>>> Invalid JSON in code/router.info: invalid character 'N' looking for beginning of value
 =END=
 
 ############################################################
@@ -87,7 +144,7 @@ panic: invalid character 'N' looking for beginning of value
 =SETUP=
 chmod a-r code/router.info
 =ERROR=
-panic: open code/router.info: permission denied
+ERROR>>> Can't open code/router.info: permission denied
 =END=
 
 ############################################################
@@ -0,0 +1,127 @@
+############################################################
+=TITLE=Parse IPv6 ACL with sequence numbers
+=DEVICE=
+ipv6 access-list test
+ permit tcp host 10::1 10::3:0/112 eq 22 sequence 10
+ permit udp any 10::3:0/112 eq domain sequence 20
+ deny ipv6 any any sequence 30
+interface Ethernet1
+ ipv6 traffic-filter test in
+=NETSPOC=
+--router
+interface Ethernet1
+--ipv6/router
+ipv6 access-list test
+ permit tcp host 10::1 10::3:0/112 eq 22
+ permit udp any 10::3:0/112 eq 53
+ deny ipv6 any any
+interface Ethernet1
+ ipv6 traffic-filter test in
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change IPv6 ACL incrementally
+=DEVICE=
+ipv6 access-list test
+ permit tcp host 10::1 host 10::3:1 eq 22 sequence 10
+ permit tcp host 10::1 host 10::5:1 eq 22 sequence 20
+ deny ipv6 any 10::2:0/112 sequence 30
+ permit tcp any 10::3:0/112 eq 80 sequence 40
+ deny ipv6 any any sequence 50
+interface Ethernet1
+ ipv6 traffic-filter test in
+=NETSPOC=
+--router
+interface Ethernet1
+--ipv6/router
+ipv6 access-list test
+ permit tcp host 10::1 host 10::5:1 eq 22
+ permit tcp host 10::1 host 10::9:1 eq 22
+ permit tcp any 10::3:0/112 eq 80
+ deny ipv6 any any
+interface Ethernet1
+ ipv6 traffic-filter test in
+=OUTPUT=
+ipv6 access-list resequence test 10000 10000
+ipv6 access-list test
+sequence 30001 permit tcp host 10::1 host 10::9:1 eq 22
+no sequence 30000
+no sequence 10000
+ipv6 access-list resequence test 10 10
+=END=
+
+############################################################
+=TITLE=Merge IPv4 and IPv6 ACL on same interface
+=DEVICE=
+interface Ethernet1
+ ip address 10.1.1.1 255.255.255.0
+=NETSPOC=
+--router
+ip access-list extended Ethernet1_in
+ permit ip 10.1.1.0 0.0.0.255 any
+ deny ip any any
+interface Ethernet1
+ ip address 10.1.1.1 255.255.255.0
+ ip access-group Ethernet1_in in
+--ipv6/router
+ipv6 access-list Ethernet1_in
+ permit ipv6 10::1:0/112 any
+ deny ipv6 any any
+interface Ethernet1
+ ipv6 traffic-filter Ethernet1_in in
+=OUTPUT=
+ip access-list extended Ethernet1_in-DRC-0
+permit ip 10.1.1.0 0.0.0.255 any
+deny ip any any
+exit
+interface Ethernet1
+ip access-group Ethernet1_in-DRC-0 in
+ipv6 access-list Ethernet1_in-DRC-0
+permit ipv6 10::1:0/112 any
+deny ipv6 any any
+exit
+interface Ethernet1
+ipv6 traffic-filter Ethernet1_in-DRC-0 in
+=END=
+
+############################################################
+=TITLE=Add line in front of IPv6 ACL
+=DEVICE=
+ipv6 access-list Ethernet1_in-DRC-0
+ deny ipv6 any any sequence 10
+interface Ethernet1
+ ipv6 traffic-filter Ethernet1_in-DRC-0 in
+=NETSPOC=
+--router
+interface Ethernet1
+--ipv6/router
+ipv6 access-list Ethernet1_in
+ permit ipv6 10::1:0/112 any
+ deny ipv6 any any
+interface Ethernet1
+ ipv6 traffic-filter Ethernet1_in in
+=OUTPUT=
+ipv6 access-list resequence Ethernet1_in-DRC-0 10000 10000
+ipv6 access-list Ethernet1_in-DRC-0
+sequence 1 permit ipv6 10::1:0/112 any
+ipv6 access-list resequence Ethernet1_in-DRC-0 10 10
+=END=
+
+############################################################
+=TITLE=Remove IPv6 ACL from interface
+=DEVICE=
+ipv6 access-list Ethernet1_in-DRC-0
+ deny ipv6 any any sequence 10
+interface Ethernet1
+ ipv6 traffic-filter Ethernet1_in-DRC-0 in
+=NETSPOC=
+--router
+interface Ethernet1
+--ipv6/router
+interface Ethernet1
+=OUTPUT=
+interface Ethernet1
+no ipv6 traffic-filter Ethernet1_in-DRC-0 in
+exit
+no ipv6 access-list Ethernet1_in-DRC-0
+=END=
@@ -0,0 +1,140 @@
+############################################################
+=TITLE=Add ACL with object-groups
+=DEVICE=
+interface Ethernet1
+ ip address 10.1.1.1 255.255.255.0
+=NETSPOC=
+object-group network g0
+ 10.1.1.0 255.255.255.0
+ host 10.1.2.3
+object-group service s0
+ tcp eq 80
+ udp range 1000 2000
+ip access-list extended Ethernet1_in
+ permit object-group s0 object-group g0 host 10.9.9.9
+ deny ip any any
+interface Ethernet1
+ ip address 10.1.1.1 255.255.255.0
+ ip access-group Ethernet1_in in
+=OUTPUT=
+object-group service s0-DRC-0
+tcp eq 80
+udp range 1000 2000
+object-group network g0-DRC-0
+10.1.1.0 255.255.255.0
+host 10.1.2.3
+ip access-list extended Ethernet1_in-DRC-0
+permit object-group s0-DRC-0 object-group g0-DRC-0 host 10.9.9.9
+deny ip any any
+exit
+interface Ethernet1
+ip access-group Ethernet1_in-DRC-0 in
+=END=
+
+############################################################
+=TITLE=Unchanged object-groups with different name and named ports
+=DEVICE=
+object-group network g0-DRC-0
+ host 10.1.2.3
+ 10.1.1.0 255.255.255.0
+object-group service s0-DRC-0
+ tcp eq www
+ icmp echo
+ip access-list extended Ethernet1_in-DRC-0
+ permit object-group s0-DRC-0 object-group g0-DRC-0 host 10.9.9.9
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in-DRC-0 in
+=NETSPOC=
+object-group network g1
+ 10.1.1.0 255.255.255.0
+ host 10.1.2.3
+object-group service s1
+ icmp 8
+ tcp eq 80
+ip access-list extended Ethernet1_in
+ permit object-group s1 object-group g1 host 10.9.9.9
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in in
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change element of object-group
+=DEVICE=
+object-group network g0-DRC-0
+ host 10.1.2.3
+ 10.1.1.0 255.255.255.0
+ip access-list extended Ethernet1_in-DRC-0
+ permit tcp object-group g0-DRC-0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in-DRC-0 in
+=NETSPOC=
+object-group network g0
+ 10.1.1.0 255.255.255.0
+ host 10.1.2.4
+ip access-list extended Ethernet1_in
+ permit tcp object-group g0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in in
+=OUTPUT=
+object-group network g0-DRC-0
+no host 10.1.2.3
+host 10.1.2.4
+=END=
+
+############################################################
+=TITLE=Replace object-group
+=DEVICE=
+object-group network g0-DRC-0
+ host 10.1.2.3
+ 10.1.1.0 255.255.255.0
+ip access-list extended Ethernet1_in-DRC-0
+ permit tcp object-group g0-DRC-0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in-DRC-0 in
+=NETSPOC=
+object-group network g0
+ 10.2.2.0 255.255.255.0
+ host 10.2.2.4
+exit
+ip access-list extended Ethernet1_in
+ permit tcp object-group g0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in in
+=OUTPUT=
+ip access-list resequence Ethernet1_in-DRC-0 10000 10000
+object-group network g0-DRC-1
+10.2.2.0 255.255.255.0
+host 10.2.2.4
+exit
+ip access-list extended Ethernet1_in-DRC-0
+10001 permit tcp object-group g0-DRC-1 host 10.9.9.9 eq 80
+no 10000
+ip access-list resequence Ethernet1_in-DRC-0 10 10
+no object-group network g0-DRC-0
+=END=
+
+############################################################
+=TITLE=Remove ACL and unused object-group
+=DEVICE=
+object-group network g0-DRC-0
+ host 10.1.2.3
+ip access-list extended Ethernet1_in-DRC-0
+ permit tcp object-group g0-DRC-0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1
+ ip access-group Ethernet1_in-DRC-0 in
+=NETSPOC=
+interface Ethernet1
+=OUTPUT=
+interface Ethernet1
+no ip access-group Ethernet1_in-DRC-0 in
+exit
+no ip access-list extended Ethernet1_in-DRC-0
+no object-group network g0-DRC-0
+=END=
@@ -470,7 +470,6 @@ permit ip any host 10.0.1.1
 
 ############################################################
 =TITLE=Crypto maps differ in peer and in name
-# match address of crypto-map is currently not handled.
 =DEVICE=
 crypto map VPN 1 ipsec-isakmp
  set peer 10.156.4.2
@@ -491,10 +490,13 @@ crypto map crypto-Ethernet1 1 ipsec-isakmp
 interface Ethernet1
  crypto map crypto-Ethernet1
 =OUTPUT=
+ip access-list extended crypto-Ethernet1-1-DRC-0
+permit ip any 10.127.18.0 0.0.0.255
 ip access-list extended crypto-filter-Ethernet1-1-DRC-0
 permit tcp host 10.127.18.1 host 10.1.11.40 eq 49
 deny ip any any
 crypto map VPN 2 ipsec-isakmp
+match address crypto-Ethernet1-1-DRC-0
 set ip access-group crypto-filter-Ethernet1-1-DRC-0 in
 set peer 10.156.4.206
 exit
@@ -712,6 +714,143 @@ exit
 no ip access-list extended crypto-filter-Ethernet1-1-DRC-0
 =END=
 
+############################################################
+=TITLE=Change ACL of crypto match address
+=DEVICE=
+ip access-list extended crypto-Ethernet1-1
+ permit ip any 10.127.18.0 0.0.0.255
+crypto map VPN 1 ipsec-isakmp
+ match address crypto-Ethernet1-1
+ set peer 10.156.4.206
+
+interface Ethernet1
+ crypto map VPN
+=NETSPOC=
+ip access-list extended crypto-Ethernet1-1
+ permit ip any 10.127.18.0 0.0.0.255
+ permit ip any 10.127.19.0 0.0.0.255
+crypto map crypto-Ethernet1 1 ipsec-isakmp
+ match address crypto-Ethernet1-1
+ set peer 10.156.4.206
+
+interface Ethernet1
+ crypto map crypto-Ethernet1
+=OUTPUT=
+ip access-list resequence crypto-Ethernet1-1 10000 10000
+ip access-list extended crypto-Ethernet1-1
+10001 permit ip any 10.127.19.0 0.0.0.255
+ip access-list resequence crypto-Ethernet1-1 10 10
+=END=
+
+############################################################
+=TITLE=Find transform-set on device, change profile
+=DEVICE=
+crypto isakmp profile prof1
+ keyring key1
+ match identity address 10.156.4.206 255.255.255.255
+crypto isakmp profile prof2
+ keyring key2
+ match identity address 10.156.4.206 255.255.255.255
+crypto ipsec transform-set Trans1 esp-aes 256 esp-sha-hmac
+ mode tunnel
+crypto map VPN 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set transform-set Trans1
+ set isakmp-profile prof1
+
+interface Ethernet1
+ crypto map VPN
+=NETSPOC=
+crypto isakmp profile prof2
+crypto ipsec transform-set Trans esp-aes 256 esp-sha-hmac
+ mode tunnel
+crypto map crypto-Ethernet1 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set transform-set Trans
+ set isakmp-profile prof2
+
+interface Ethernet1
+ crypto map crypto-Ethernet1
+=OUTPUT=
+crypto map VPN 1 ipsec-isakmp
+set isakmp-profile prof2
+=END=
+
+############################################################
+=TITLE=Unchanged ikev2 profile
+=DEVICE=
+crypto ikev2 profile prof1
+ match identity remote address 10.156.4.206 255.255.255.255
+ authentication local pre-share
+crypto map VPN 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set ikev2-profile prof1
+
+interface Ethernet1
+ crypto map VPN
+=NETSPOC=
+crypto ikev2 profile prof1
+crypto map crypto-Ethernet1 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set ikev2-profile prof1
+
+interface Ethernet1
+ crypto map crypto-Ethernet1
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Profile must be transferred manually
+=DEVICE=
+crypto isakmp profile prof1
+ keyring key1
+crypto map VPN 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set isakmp-profile prof1
+
+interface Ethernet1
+ crypto map VPN
+=NETSPOC=
+crypto isakmp profile prof2
+crypto map crypto-Ethernet1 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set isakmp-profile prof2
+
+interface Ethernet1
+ crypto map crypto-Ethernet1
+=ERROR=
+ERROR>>> 'crypto isakmp profile prof2' must be transferred manually
+=END=
+
+############################################################
+=TITLE=Change transform-set
+=DEVICE=
+crypto ipsec transform-set Trans1 esp-aes 256 esp-sha-hmac
+ mode tunnel
+crypto map VPN 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set transform-set Trans1
+
+interface Ethernet1
+ crypto map VPN
+=NETSPOC=
+crypto ipsec transform-set Trans esp-aes 256 esp-sha256-hmac
+ mode tunnel
+crypto map crypto-Ethernet1 1 ipsec-isakmp
+ set peer 10.156.4.206
+ set transform-set Trans
+
+interface Ethernet1
+ crypto map crypto-Ethernet1
+=OUTPUT=
+crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha256-hmac
+mode tunnel
+exit
+crypto map VPN 1 ipsec-isakmp
+set transform-set Trans-DRC-0
+exit
+no crypto ipsec transform-set Trans1 esp-aes 256 esp-sha-hmac
+=END=
+
 ############################################################
 =TITLE=Interface with dhcp address
 =DEVICE=
@@ -72,6 +72,96 @@ interface Ethernet1
 ip access-group Ethernet1_in-DRC-0 in
 =END=
 
+############################################################
+=TITLE=Merge ACL using [BEFORE], [AFTER], [DELETE]
+=DEVICE=
+interface Ethernet1
+ ip address 10.0.6.1 255.255.255.0
+=NETSPOC=
+--router
+ip access-list extended Ethernet1_in
+ permit udp 10.0.6.0 0.0.0.255 host 10.0.1.11 eq 123
+ permit tcp 10.0.6.0 0.0.0.255 host 10.0.1.11 eq 80
+ permit tcp 10.0.6.0 0.0.0.255 host 10.0.1.12 eq 80
+ deny ip any any
+interface Ethernet1
+ ip address 10.0.6.1 255.255.255.0
+ ip access-group Ethernet1_in in
+--router.raw
+interface Ethernet1
+ ip access-group Ethernet1x in
+[BEFORE permit tcp 10.0.6.0 0.0.0.255 host 10.0.1.11 eq 80]
+ip access-list extended Ethernet1x
+ deny tcp host 10.0.6.9 any
+[AFTER deny ip any any]
+ip access-list extended Ethernet1x
+ permit ip any host 224.0.1.1
+[DELETE]
+ip access-list extended Ethernet1x
+ permit tcp 10.0.6.0 0.0.0.255 host 10.0.1.12 eq 80
+=OUTPUT=
+ip access-list extended Ethernet1_in-DRC-0
+permit udp 10.0.6.0 0.0.0.255 host 10.0.1.11 eq 123
+deny tcp host 10.0.6.9 any
+permit tcp 10.0.6.0 0.0.0.255 host 10.0.1.11 eq 80
+deny ip any any
+permit ip any host 224.0.1.1
+exit
+interface Ethernet1
+ip access-group Ethernet1_in-DRC-0 in
+=END=
+
+############################################################
+=TITLE=Replace ACL using [REPLACE]
+=DEVICE=
+interface Ethernet1
+ ip address 10.0.6.1 255.255.255.0
+=NETSPOC=
+--router
+ip access-list extended Ethernet1_in
+ permit udp 10.0.6.0 0.0.0.255 host 10.0.1.11 eq 123
+ deny ip any any
+interface Ethernet1
+ ip address 10.0.6.1 255.255.255.0
+ ip access-group Ethernet1_in in
+--router.raw
+interface Ethernet1
+ ip access-group Ethernet1x in
+[REPLACE]
+ip access-list extended Ethernet1x
+ permit ip 10.0.6.0 0.0.0.255 any
+ deny ip any any log
+=OUTPUT=
+ip access-list extended Ethernet1_in-DRC-0
+permit ip 10.0.6.0 0.0.0.255 any
+deny ip any any log
+exit
+interface Ethernet1
+ip access-group Ethernet1_in-DRC-0 in
+=END=
+
+############################################################
+=TITLE=Delete unknown line of ACL
+=DEVICE=
+interface Ethernet1
+ ip address 10.0.6.1 255.255.255.0
+=NETSPOC=
+--router
+ip access-list extended Ethernet1_in
+ deny ip any any
+interface Ethernet1
+ ip address 10.0.6.1 255.255.255.0
+ ip access-group Ethernet1_in in
+--router.raw
+interface Ethernet1
+ ip access-group Ethernet1x in
+[DELETE]
+ip access-list extended Ethernet1x
+ permit ip any any
+=ERROR=
+ERROR>>> While reading file router.raw: Can't delete unknown line 'permit ip any any' of ACL Ethernet1_in from raw
+=END=
+
 ############################################################
 =TITLE=ADD ACL
 =DEVICE=
@@ -146,7 +236,7 @@ ip access-list extended Ethernet1_in
 interface Ethernet1
  ip access-group Ethernet1_in out
 =ERROR=
-ERROR>>> Name clash for 'ip access-list extended Ethernet1_in' from raw
+ERROR>>> While reading file router.raw: Name clash for 'ip access-list extended Ethernet1_in' from raw
 =END=
 
 ############################################################
@@ -165,7 +255,7 @@ interface Ethernet1
  ip access-group in_out in
  ip access-group in_out out
 =ERROR=
-ERROR>>> Name clash for 'ip access-list extended in_out' from raw
+ERROR>>> While reading file router.raw: Name clash for 'ip access-list extended in_out' from raw
 =END=
 
 ############################################################
@@ -195,7 +285,7 @@ interface Ethernet1
 interface Ethernet2
  ip access-group foo in
 =ERROR=
-ERROR>>> Must reference 'ip access-list extended foo' only once in raw
+ERROR>>> While reading file router.raw: Must reference 'ip access-list extended foo' only once in raw
 =END=
 
 ############################################################
@@ -0,0 +1,135 @@
+############################################################
+=TITLE=Export rules of ACL with wildcard masks
+=DEVICE=
+interface Ethernet0/1
+ ip address 10.1.1.1 255.255.255.0
+ ip access-group Ethernet0/1_in in
+ip access-list extended Ethernet0/1_in
+ 10 permit tcp 10.1.1.0 0.0.0.255 host 10.2.2.2 eq www log
+ 20 permit udp 10.1.0.0 0.0.255.255 eq domain any
+ 30 permit ip 10.1.0.0 0.255.0.255 any
+ 40 deny ip any any
+=NETSPOC=NONE
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "Ethernet0/1_in",
+			"bindings": [
+				{
+					"name": "Ethernet0/1",
+					"direction": "in"
+				}
+			],
+			"rules": [
+				{
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.1.1.0/24"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.2.2.2/32"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 80,
+									"high": 80
+								}
+							]
+						}
+					],
+					"log": true,
+					"orig": "permit tcp 10.1.1.0 0.0.0.255 host 10.2.2.2 eq www log"
+				},
+				{
+					"position": 2,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.1.0.0/16"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "udp",
+							"src_ports": [
+								{
+									"low": 53,
+									"high": 53
+								}
+							]
+						}
+					],
+					"orig": "permit udp 10.1.0.0 0.0.255.255 eq domain any"
+				},
+				{
+					"position": 3,
+					"action": "permit",
+					"src": {
+						"unknown": [
+							"10.1.0.0 0.255.0.255"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"orig": "permit ip 10.1.0.0 0.255.0.255 any"
+				},
+				{
+					"position": 4,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"orig": "deny ip any any"
+				}
+			],
+			"default": "deny"
+		}
+	]
+}
+=END=
+
+############################################################
+=TITLE=Trace flow through ACL of outgoing interface
+=DEVICE=
+interface Ethernet0/2
+ ip access-group Ethernet0/2_out out
+ip access-list extended Ethernet0/2_out
+ 10 deny ip 10.1.0.0 0.0.255.255 any
+ 20 permit ip any any
+=NETSPOC=
+interface Ethernet0/2
+ ip access-group Ethernet0/2_out out
+ip access-list extended Ethernet0/2_out
+ permit tcp 10.1.1.0 0.0.0.255 any eq 22
+ deny ip any any
+=OPTIONS=--trace 10.1.1.1,10.9.9.9,tcp:22,Ethernet0/1:Ethernet0/2
+=OUTPUT=
+device: Ethernet0/2_out: rule 1 deny: deny ip 10.1.0.0 0.0.255.255 any
+netspoc: Ethernet0/2_out: rule 1 permit: permit tcp 10.1.1.0 0.0.0.255 any eq 22
+result: changed
+=END=
@@ -102,7 +102,7 @@ ERROR>>> Authentication failed
 --policies/p1/log/router.drc
 ERROR>>> Authentication failed
 --status/router
-{"approve":{"result":"FAILED","policy":"p1","time":1727626790},"compare":{"result":"","policy":"","time":0}}
+{"approve":{"result":"FAILED","policy":"p1","time":1727626790,"error":"authentication"},"compare":{"result":"","policy":"","time":0}}
 =END=
 
 ############################################################
@@ -119,7 +119,7 @@ router:ERROR>>> Authentication failed
 --policies/p1/log/router.drc
 ERROR>>> Authentication failed
 --status/router
-{"approve":{"result":"FAILED","policy":"p1","time":1727626790},"compare":{"result":"","policy":"","time":0}}
+{"approve":{"result":"FAILED","policy":"p1","time":1727626790,"error":"authentication"},"compare":{"result":"","policy":"","time":0}}
 =END=
 
 ############################################################
@@ -159,6 +159,94 @@ router#
 router#
 =END=
 
+############################################################
+=TITLE=Check access
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=WARNING=
+access: login succeeded
+access: device name: router
+access: banner: found
+=OUTPUT=
+--router.login
+Enter Password:secret
+
+banner motd  managed by NetSPoC
+router>enable
+router#
+router#term len 0
+router#term width 512
+router#sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+router#
+router#
+=END=
+
+############################################################
+=TITLE=Check access: missing banner
+=SCENARIO=
+Enter Password:<!>
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=WARNING=
+access: login succeeded
+access: device name: router
+access: banner: missing
+WARNING>>> Missing banner at NetSPoC managed device
+=END=
+
+############################################################
+=TITLE=do-approve check-access
+=DO_APPROVE=
+=PARAMS=check-access router
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+=NETSPOC=NONE
+=OUTPUT=
+access: login succeeded
+access: device name: router
+access: banner: found
+--policies/p1/log/router.access
+access: login succeeded
+access: device name: router
+access: banner: found
+--history/router
+2024 09 29 16:19:50 START: check-access router
+2024 09 29 16:19:50 POLICY: p1
+2024 09 29 16:19:50 RES: access: login succeeded
+2024 09 29 16:19:50 RES: access: device name: router
+2024 09 29 16:19:50 RES: access: banner: found
+2024 09 29 16:19:50 END: OK
+=END=
+
+############################################################
+=TITLE=do-approve --brief check-access: SSH login failed
+=DO_APPROVE=
+=PARAMS=--brief check-access router
+=SCENARIO=
+Enter Password:<!>
+Enter Password:<!>
+=NETSPOC=NONE
+=ERROR=NONE
+=OUTPUT=
+router:ERROR>>> Authentication failed
+--policies/p1/log/router.access
+ERROR>>> Authentication failed
+=END=
+
 ############################################################
 =TITLE=Approve unchanged
 =SCENARIO=
@@ -197,6 +285,95 @@ Parsed device config
 {"approve":{"result":"OK","policy":"p1","time":1727626790},"compare":{"result":"","policy":"","time":0}}
 =END=
 
+############################################################
+=TITLE=do-approve approve: only routes
+=DO_APPROVE=
+=PARAMS=--only routes approve router
+=SCENARIO=
+[[std_scenario]]
+# sh run
+ip route 10.20.0.0 255.255.0.0 10.1.2.3
+ip access-list extended inside
+ permit ip host 1.1.1.1 any
+interface Ethernet1
+ ip access-group inside in
+END
+=NETSPOC=
+ip route 10.20.0.0 255.255.0.0 10.1.2.4
+ip access-list extended inside
+ permit ip host 2.2.2.2 any
+interface Ethernet1
+ ip access-group inside in
+=WARNING=
+OK, details in policies/p1/log/router.drc
+=OUTPUT=
+approve: partial, left unapplied: acl
+--policies/p1/log/router.change
+configure terminal
+Enter configuration commands, one per line.  End with CNTL/Z.
+router#no logging console
+router#line vty 0 15
+router#logging synchronous level all
+router#ip subnet-zero
+router#ip classless
+router#end
+router#reload in 2
+
+System configuration has been modified. Save? [yes/no]: n
+
+Reload reason: Reload Command
+Proceed with reload? [confirm]
+
+router#configure terminal
+Enter configuration commands, one per line.  End with CNTL/Z.
+router#no ip route 10.20.0.0 255.255.0.0 10.1.2.3
+router#ip route 10.20.0.0 255.255.0.0 10.1.2.4
+router#end
+router#reload cancel
+
+
+***
+*** --- SHUTDOWN ABORTED ---
+***
+router#
+router#write memory
+Building configuration...
+  Compressed configuration from 106098 bytes to 30504 bytes[OK]
+router#
+--history/router
+2024 09 29 16:19:50 START: --only routes approve router
+2024 09 29 16:19:50 POLICY: p1
+2024 09 29 16:19:50 RES: approve: partial, left unapplied: acl
+2024 09 29 16:19:50 END: OK
+--status/router
+{"approve":{"result":"PARTIAL","policy":"p1","time":1727626790},"compare":{"result":"","policy":"","time":0}}
+=END=
+
+############################################################
+=TITLE=do-approve approve: unknown category
+=DO_APPROVE=
+=PARAMS=--exclude acl,route approve router
+=SCENARIO=NONE
+=NETSPOC=NONE
+=ERROR=
+Error: Unknown category "route", expected one of: acl, crypto, iptables, routes, rules
+=END=
+
+############################################################
+=TITLE=do-approve compare: option --only not allowed
+=DO_APPROVE=
+=PARAMS=--only routes compare router
+=SCENARIO=NONE
+=NETSPOC=NONE
+=ERROR=
+Usage: do-approve [options] approve|compare|check-access DEVICE
+  -b, --brief                Suppress message about unreachable device
+      --exclude CATEGORIES   Leave changes of comma separated CATEGORIES unapplied
+      --force                Approve, even if previous approve was interrupted
+      --only CATEGORIES      Approve only changes of comma separated CATEGORIES
+      --resume               Continue interrupted approve, show commands from journal
+=END=
+
 ############################################################
 =TITLE=do-approve compare: unchanged
 =DO_APPROVE=
@@ -692,6 +869,175 @@ router#reload cancel
 ***
 router#
 router#
+--journal/router
+{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}
+{"n":1,"state":"failed","error":"Got unexpected output from 'ip route 10.0.0.0 255.0.0.0 10.1.2.4':\nfailed\n"}
+{"state":"aborted","error":"Got unexpected output from 'ip route 10.0.0.0 255.0.0.0 10.1.2.4':\nfailed\n"}
+=END=
+
+############################################################
+=TITLE=do-approve approve: record failed command in status
+=DO_APPROVE=
+=PARAMS=approve router
+=SCENARIO=
+[[std_scenario]]
+# ip route 10.0.0.0 255.0.0.0 10.1.2.4
+failed
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=ERROR=
+FAILED, details in policies/p1/log/router.drc
+=OUTPUT=
+--status/router
+{"approve":{"result":"FAILED","policy":"p1","time":1727626790,"error":"apply"},"compare":{"result":"","policy":"","time":0}}
+=END=
+
+############################################################
+=TITLE=do-approve approve: device stops responding while applying changes
+=DO_APPROVE=
+=PARAMS=approve router
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+# reload in 2
+
+System configuration has been modified. Save? [yes/no]: <!>
+Reload reason: Reload Command
+Proceed with reload? [confirm]<!>
+# configure terminal
+Enter configuration commands, one per line.  End with CNTL/Z.<!>
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=EXIT=8
+=ERROR=
+FAILED, details in policies/p1/log/router.drc
+=OUTPUT=
+--status/router
+{"approve":{"result":"FAILED","policy":"p1","time":1727626790,"error":"apply"},"compare":{"result":"","policy":"","time":0}}
+=END=
+
+############################################################
+=TITLE=do-approve approve: record failed write mem in status
+=DO_APPROVE=
+=PARAMS=approve router
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+# configure terminal
+Enter configuration commands, one per line.  End with CNTL/Z.
+# reload in 2
+
+System configuration has been modified. Save? [yes/no]: <!>
+Reload reason: Reload Command
+Proceed with reload? [confirm]<!>
+# reload cancel
+
+
+***
+*** --- SHUTDOWN ABORTED ---
+***
+# write memory
+failed
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=ERROR=
+FAILED, details in policies/p1/log/router.drc
+=OUTPUT=
+--status/router
+{"approve":{"result":"FAILED","policy":"p1","time":1727626790,"error":"commit"},"compare":{"result":"","policy":"","time":0}}
+=END=
+
+############################################################
+=TITLE=Previous approve was interrupted
+=SCENARIO=
+[[std_scenario]]
+=SETUP=
+mkdir journal
+echo '{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}' > journal/router
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=ERROR=
+ERROR>>> Previous approve was interrupted, see journal/router
+ERROR>>> Use option --resume to continue or option --force to start new approve
+=END=
+
+############################################################
+=TITLE=Force new approve after interrupted approve
+=SCENARIO=
+[[std_scenario]]
+=SETUP=
+mkdir journal
+echo '{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}' > journal/router
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=OPTIONS=--force
+=WARNING=
+WARNING>>> Ignoring interrupted approve in journal/router
+=OUTPUT=
+--journal/router
+{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}
+{"n":1,"state":"acknowledged"}
+{"state":"finished"}
+=END=
+
+############################################################
+=TITLE=Failed approve doesn't block later approve
+=SCENARIO=
+[[std_scenario]]
+=SETUP=
+mkdir journal
+echo '{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}
+{"n":1,"state":"failed"}
+{"state":"aborted","error":"failed"}' > journal/router
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=OUTPUT=
+--journal/router
+{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}
+{"n":1,"state":"acknowledged"}
+{"state":"finished"}
+=END=
+
+############################################################
+=TITLE=Resume interrupted approve
+=SCENARIO=
+[[std_scenario]]
+# sh run
+ip route 10.0.0.0 255.0.0.0 10.1.2.3
+END
+=SETUP=
+mkdir journal
+echo '{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.3"}
+{"n":1,"state":"acknowledged"}
+{"n":2,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}' > journal/router
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.3
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=OPTIONS=--resume
+=WARNING=
+WARNING>>> Command of interrupted approve has unknown result: ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=OUTPUT=
+--journal/router
+{"n":1,"state":"sent","cmd":"ip route 10.0.0.0 255.0.0.0 10.1.2.4"}
+{"n":1,"state":"acknowledged"}
+{"state":"finished"}
+=END=
+
+############################################################
+=TITLE=Nothing to resume
+=SCENARIO=
+[[std_scenario]]
+=NETSPOC=
+ip route 10.0.0.0 255.0.0.0 10.1.2.4
+=OPTIONS=--resume
+=ERROR=
+ERROR>>> No interrupted approve found in journal/router
 =END=
 
 ############################################################
@@ -940,7 +1286,18 @@ ERROR>>> Can't open credentials: no such file or directory
 echo some_stuff > status/router
 chmod a-w status/router
 =ERROR=
-panic: open status/router: permission denied
+Error: can't open status/router: permission denied
+=END=
+
+############################################################
+=TITLE=do-approve compare: status file is directory
+=DO_APPROVE=
+=SCENARIO=NONE
+=NETSPOC=NONE
+=SETUP=
+mkdir status/router
+=ERROR=
+Error: can't open status/router: is a directory
 =END=
 
 ############################################################
@@ -973,7 +1330,7 @@ ERROR>>> while waiting for login prompt '(?i)password:|\(yes/no.*\)\?': expect:
 2024 09 29 16:19:50 RES: ERROR>>> while waiting for login prompt '(?i)password:|\(yes/no.*\)\?': expect: timer expired after 3 seconds
 2024 09 29 16:19:50 END: FAILED
 --status/router
-{"approve":{"result":"","policy":"","time":0},"compare":{"result":"DIFF","policy":"p1","time":1727626790}}
+{"approve":{"result":"","policy":"","time":0},"compare":{"result":"DIFF","policy":"p1","time":1727626790,"error":"unreachable"}}
 =END=
 
 ############################################################
@@ -994,7 +1351,7 @@ ERROR>>> while waiting for login prompt '(?i)password:|\(yes/no.*\)\?': expect:
 2024 09 29 16:19:50 RES: ERROR>>> while waiting for login prompt '(?i)password:|\(yes/no.*\)\?': expect: timer expired after 3 seconds
 2024 09 29 16:19:50 END: FAILED
 --status/router
-{"approve":{"result":"","policy":"","time":0},"compare":{"result":"DIFF","policy":"p1","time":1727626790}}
+{"approve":{"result":"","policy":"","time":0},"compare":{"result":"DIFF","policy":"p1","time":1727626790,"error":"unreachable"}}
 =END=
 
 ############################################################
@@ -1014,7 +1371,49 @@ ERROR>>> while waiting for login prompt '(?i)password:|\(yes/no.*\)\?': expect:
 2024 09 29 16:19:50 RES: ERROR>>> while waiting for login prompt '(?i)password:|\(yes/no.*\)\?': expect: timer expired after 3 seconds
 2024 09 29 16:19:50 END: FAILED
 -- status/router
-{"approve":{"result":"FAILED","policy":"p1","time":1727626790},"compare":{"result":"","policy":"","time":0}}
+{"approve":{"result":"FAILED","policy":"p1","time":1727626790,"error":"unreachable"},"compare":{"result":"","policy":"","time":0}}
+=END=
+
+############################################################
+=TITLE=do-approve compare: timeout while reading config
+=DO_APPROVE=
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+# sh run
+ip route 10.0.0.0 255.0.0.0 10.1.2.3<!>
+=NETSPOC=NONE
+=ERROR=
+FAILED, details in policies/p1/log/router.compare
+=OUTPUT=
+--policies/p1/log/router.compare
+Requesting device config
+ERROR>>> while waiting for prompt '
+ERROR>>> router\S*#': expect: timer expired after 1 seconds
+--status/router
+{"approve":{"result":"","policy":"","time":0},"compare":{"result":"DIFF","policy":"p1","time":1727626790,"error":"unreachable"}}
+=END=
+
+############################################################
+=TITLE=do-approve --brief compare: timeout while reading config
+=DO_APPROVE=
+=OPTIONS=--brief
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router>
+# sh ver
+Cisco IOS Software, C2900 Software (C2900-UNIVERSALK9-M), Version 15.1(4)M4,
+# sh run
+ip route 10.0.0.0 255.0.0.0 10.1.2.3<!>
+=NETSPOC=NONE
+=ERROR=NONE
+=OUTPUT=
+--status/router
+{"approve":{"result":"","policy":"","time":0},"compare":{"result":"DIFF","policy":"p1","time":1727626790,"error":"unreachable"}}
 =END=
 
 ############################################################
@@ -0,0 +1,195 @@
+=TEMPL=ikev2
+crypto ikev2 profile ikev2-prof
+ match identity remote address 198.51.100.1 255.255.255.255
+=END=
+
+=TEMPL=vti
+[[ikev2]]
+crypto ipsec transform-set Trans esp-aes 256 esp-sha256-hmac
+ mode tunnel
+crypto ipsec profile vti-prof
+ set transform-set Trans
+ set ikev2-profile ikev2-prof
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source Ethernet1
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof
+ip route 10.2.0.0 255.255.0.0 Tunnel1
+=END=
+
+############################################################
+=TITLE=Add tunnel interface with IPsec profile and route
+=DEVICE=
+[[ikev2]]
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+=NETSPOC=
+[[vti]]
+=OUTPUT=
+crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha256-hmac
+mode tunnel
+crypto ipsec profile vti-prof-DRC-0
+set transform-set Trans-DRC-0
+set ikev2-profile ikev2-prof
+interface Tunnel1
+ip address 10.9.9.1 255.255.255.252
+tunnel source Ethernet1
+tunnel destination 198.51.100.1
+tunnel mode ipsec ipv4
+tunnel protection ipsec profile vti-prof-DRC-0
+ip route 10.2.0.0 255.255.0.0 Tunnel1
+=END=
+
+############################################################
+=TITLE=Unchanged tunnel interface
+=DEVICE=
+[[ikev2]]
+crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha256-hmac
+ mode tunnel
+crypto ipsec profile vti-prof-DRC-0
+ set transform-set Trans-DRC-0
+ set ikev2-profile ikev2-prof
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source Ethernet1
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+ip route 10.2.0.0 255.255.0.0 Tunnel1
+=NETSPOC=
+[[vti]]
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change transform-set of IPsec profile
+=DEVICE=
+[[ikev2]]
+crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha-hmac
+ mode tunnel
+crypto ipsec profile vti-prof-DRC-0
+ set transform-set Trans-DRC-0
+ set ikev2-profile ikev2-prof
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source Ethernet1
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+ip route 10.2.0.0 255.255.0.0 Tunnel1
+=NETSPOC=
+[[vti]]
+=OUTPUT=
+crypto ipsec transform-set Trans-DRC-1 esp-aes 256 esp-sha256-hmac
+mode tunnel
+crypto ipsec profile vti-prof-DRC-1
+set transform-set Trans-DRC-1
+set ikev2-profile ikev2-prof
+exit
+interface Tunnel1
+tunnel protection ipsec profile vti-prof-DRC-1
+exit
+no crypto ipsec profile vti-prof-DRC-0
+no crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha-hmac
+=END=
+
+############################################################
+=TITLE=Change ikev2 profile of IPsec profile
+=DEVICE=
+[[ikev2]]
+crypto ikev2 profile ikev2-prof2
+ match identity remote address 198.51.100.2 255.255.255.255
+crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha256-hmac
+ mode tunnel
+crypto ipsec profile vti-prof-DRC-0
+ set transform-set Trans-DRC-0
+ set ikev2-profile ikev2-prof2
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source Ethernet1
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+ip route 10.2.0.0 255.255.0.0 Tunnel1
+=NETSPOC=
+[[vti]]
+=OUTPUT=
+crypto ipsec profile vti-prof-DRC-1
+set transform-set Trans-DRC-0
+set ikev2-profile ikev2-prof
+exit
+interface Tunnel1
+tunnel protection ipsec profile vti-prof-DRC-1
+exit
+no crypto ipsec profile vti-prof-DRC-0
+=END=
+
+############################################################
+=TITLE=Remove tunnel interface
+=DEVICE=
+[[ikev2]]
+crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha256-hmac
+ mode tunnel
+crypto ipsec profile vti-prof-DRC-0
+ set transform-set Trans-DRC-0
+ set ikev2-profile ikev2-prof
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel1
+ ip address 10.9.9.1 255.255.255.252
+ tunnel source Ethernet1
+ tunnel destination 198.51.100.1
+ tunnel mode ipsec ipv4
+ tunnel protection ipsec profile vti-prof-DRC-0
+ip route 10.2.0.0 255.255.0.0 Tunnel1
+=NETSPOC=
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+ip route 10.3.0.0 255.255.0.0 192.0.2.2
+=OUTPUT=
+ip route 10.3.0.0 255.255.0.0 192.0.2.2
+no ip route 10.2.0.0 255.255.0.0 Tunnel1
+no interface Tunnel1
+no crypto ipsec profile vti-prof-DRC-0
+no crypto ipsec transform-set Trans-DRC-0 esp-aes 256 esp-sha256-hmac
+=END=
+
+############################################################
+=TITLE=Leave manually configured tunnel unchanged
+=DEVICE=
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel2
+ ip address 10.8.8.1 255.255.255.252
+ tunnel source Ethernet1
+ tunnel destination 198.51.100.2
+=NETSPOC=
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Tunnel2
+ ip address 10.8.8.1 255.255.255.252
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Must not create other unknown interface
+=DEVICE=
+[[ikev2]]
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+=NETSPOC=
+interface Ethernet1
+ ip address 192.0.2.1 255.255.255.0
+interface Ethernet2
+ ip address 192.0.3.1 255.255.255.0
+=ERROR=
+ERROR>>> Interface 'Ethernet2' from Netspoc not known on device
+=END=
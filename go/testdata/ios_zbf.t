@@ -0,0 +1,228 @@
+=TEMPL=zbf
+zone security inside
+zone security outside
+ip access-list extended acl-in-out
+ permit tcp 10.1.1.0 0.0.0.255 any eq 80
+{{- with .acl}}
+ {{.}}
+{{- end}}
+class-map type inspect match-any cm-in-out
+ match access-group name acl-in-out
+policy-map type inspect pm-in-out
+ class type inspect cm-in-out
+  {{or .action "inspect"}}
+ class class-default
+  drop log
+zone-pair security in-out source inside destination outside
+ service-policy type inspect pm-in-out
+interface Ethernet1
+ zone-member security inside
+interface Ethernet2
+ zone-member security outside
+=TEMPL=zbf_device
+zone security inside
+zone security outside
+ip access-list extended acl-in-out-DRC-0
+ 10 permit tcp 10.1.1.0 0.0.0.255 any eq www
+class-map type inspect match-any cm-in-out-DRC-0
+ match access-group name acl-in-out-DRC-0
+policy-map type inspect pm-in-out-DRC-0
+ class type inspect cm-in-out-DRC-0
+  inspect
+ class class-default
+  drop log
+zone-pair security in-out source inside destination outside
+ service-policy type inspect pm-in-out-DRC-0
+interface Ethernet1
+ zone-member security inside
+interface Ethernet2
+ zone-member security outside
+=END=
+
+############################################################
+=TITLE=Add zone-based firewall
+=DEVICE=
+interface Ethernet1
+interface Ethernet2
+=NETSPOC=
+[[zbf]]
+=OUTPUT=
+zone security inside
+exit
+interface Ethernet1
+zone-member security inside
+zone security outside
+exit
+interface Ethernet2
+zone-member security outside
+ip access-list extended acl-in-out-DRC-0
+permit tcp 10.1.1.0 0.0.0.255 any eq 80
+class-map type inspect match-any cm-in-out-DRC-0
+match access-group name acl-in-out-DRC-0
+policy-map type inspect pm-in-out-DRC-0
+class type inspect cm-in-out-DRC-0\N inspect
+class class-default\N drop log
+zone-pair security in-out source inside destination outside
+service-policy type inspect pm-in-out-DRC-0
+=END=
+
+############################################################
+=TITLE=Unchanged zone-based firewall
+=DEVICE=
+[[zbf_device]]
+=NETSPOC=
+[[zbf]]
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change ACL referenced from class-map
+=DEVICE=
+[[zbf_device]]
+=NETSPOC=
+[[zbf {acl: permit tcp 10.1.1.0 0.0.0.255 any eq 443}]]
+=OUTPUT=
+ip access-list resequence acl-in-out-DRC-0 10000 10000
+ip access-list extended acl-in-out-DRC-0
+10001 permit tcp 10.1.1.0 0.0.0.255 any eq 443
+ip access-list resequence acl-in-out-DRC-0 10 10
+=END=
+
+############################################################
+=TITLE=Change action of class in policy-map
+=DEVICE=
+[[zbf_device]]
+=NETSPOC=
+[[zbf {action: pass}]]
+=OUTPUT=
+policy-map type inspect pm-in-out-DRC-0
+class type inspect cm-in-out-DRC-0\N pass
+=END=
+
+############################################################
+=TITLE=Action with multiple lines
+=DEVICE=
+[[zbf_device]]
+=NETSPOC=
+[[zbf {action: "inspect\n  police rate 8000 burst 1000"}]]
+=OUTPUT=
+policy-map type inspect pm-in-out-DRC-0
+class type inspect cm-in-out-DRC-0\N inspect\N police rate 8000 burst 1000
+=END=
+
+############################################################
+=TITLE=Unchanged action with multiple lines
+=DEVICE=
+zone security inside
+zone security outside
+class-map type inspect match-any cm-in-out-DRC-0
+ match protocol http
+policy-map type inspect pm-in-out-DRC-0
+ class type inspect cm-in-out-DRC-0
+  inspect
+  police rate 8000 burst 1000
+zone-pair security in-out source inside destination outside
+ service-policy type inspect pm-in-out-DRC-0
+=NETSPOC=
+zone security inside
+zone security outside
+class-map type inspect match-any cm-in-out
+ match protocol http
+policy-map type inspect pm-in-out
+ class type inspect cm-in-out
+  inspect
+  police rate 8000 burst 1000
+zone-pair security in-out source inside destination outside
+ service-policy type inspect pm-in-out
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Replace class-map of class in policy-map
+=DEVICE=
+[[zbf_device]]
+=NETSPOC=
+zone security inside
+zone security outside
+class-map type inspect match-all cm-in-out
+ match protocol http
+policy-map type inspect pm-in-out
+ class type inspect cm-in-out
+  inspect
+ class class-default
+  drop log
+zone-pair security in-out source inside destination outside
+ service-policy type inspect pm-in-out
+interface Ethernet1
+ zone-member security inside
+interface Ethernet2
+ zone-member security outside
+=OUTPUT=
+class-map type inspect match-all cm-in-out-DRC-1
+match protocol http
+exit
+policy-map type inspect pm-in-out-DRC-0
+no class type inspect cm-in-out-DRC-0
+class type inspect cm-in-out-DRC-1\N inspect
+exit
+no class-map type inspect match-any cm-in-out-DRC-0
+no ip access-list extended acl-in-out-DRC-0
+=END=
+
+############################################################
+=TITLE=Remove zone-pair and unused objects
+=DEVICE=
+[[zbf_device]]
+=NETSPOC=
+zone security inside
+zone security outside
+interface Ethernet1
+ zone-member security inside
+interface Ethernet2
+ zone-member security outside
+=OUTPUT=
+no zone-pair security in-out source inside destination outside
+no policy-map type inspect pm-in-out-DRC-0
+no class-map type inspect match-any cm-in-out-DRC-0
+no ip access-list extended acl-in-out-DRC-0
+=END=
+
+############################################################
+=TITLE=Leave zone-based firewall unchanged, if Netspoc has no ZBF
+=DEVICE=
+zone security inside
+zone security outside
+class-map type inspect match-any cm-in-out
+ match protocol http
+policy-map type inspect pm-in-out
+ class type inspect cm-in-out
+  inspect
+zone-pair security in-out source inside destination outside
+ service-policy type inspect pm-in-out
+interface Ethernet1
+ zone-member security inside
+interface Ethernet2
+ zone-member security outside
+=NETSPOC=
+interface Ethernet1
+interface Ethernet2
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Remove only zone-pair generated by Netspoc, if Netspoc has no ZBF
+=DEVICE=
+[[zbf_device]]
+class-map type inspect match-any cm-manual
+ match protocol http
+policy-map type inspect pm-manual
+ class type inspect cm-manual
+  inspect
+zone-pair security out-in source outside destination inside
+ service-policy type inspect pm-manual
+=NETSPOC=
+interface Ethernet1
+interface Ethernet2
+=OUTPUT=
+no zone-pair security in-out source inside destination outside
+no policy-map type inspect pm-in-out-DRC-0
+no class-map type inspect match-any cm-in-out-DRC-0
+no ip access-list extended acl-in-out-DRC-0
+=END=
@@ -4,7 +4,7 @@
 =NETSPOC=
 :c1 -
 =ERROR=
-ERROR>>> Found chain policy outside of table: ":c1 -"
+ERROR>>> While reading file router: Found chain policy outside of table: ":c1 -"
 =END=
 
 ############################################################
@@ -12,7 +12,7 @@ ERROR>>> Found chain policy outside of table: ":c1 -"
 =NETSPOC=
 -A c2 -g c1 -d 10.1.1.2 -p icmp
 =ERROR=
-ERROR>>> Found rule outside of table: "-A c2 -g c1 -d 10.1.1.2 -p icmp"
+ERROR>>> While reading file router: Found rule outside of table: "-A c2 -g c1 -d 10.1.1.2 -p icmp"
 =END=
 
 ############################################################
@@ -21,7 +21,7 @@ ERROR>>> Found rule outside of table: "-A c2 -g c1 -d 10.1.1.2 -p icmp"
 *filter
 -A c2 -g c1 -d 10.1.1.2 -p icmp
 =ERROR=
-ERROR>>> Must define policy before adding rules of chain "c2"
+ERROR>>> While reading file router: Must define policy before adding rules of chain "c2"
 =END=
 
 ############################################################
@@ -30,7 +30,7 @@ ERROR>>> Must define policy before adding rules of chain "c2"
 *filter
 -I c2 -g c1 -d 10.1.1.2 -p icmp
 =ERROR=
-ERROR>>> Unsupported command "-I"
+ERROR>>> While reading file router: Unsupported command "-I"
 =END=
 
 ############################################################
@@ -39,7 +39,7 @@ ERROR>>> Unsupported command "-I"
 *filter
  -A
 =ERROR=
-ERROR>>> Incomplete command "-A"
+ERROR>>> While reading file router: Incomplete command "-A"
 =END=
 
 ############################################################
@@ -48,7 +48,7 @@ ERROR>>> Incomplete command "-A"
 foo
 =NETSPOC=NONE
 =ERROR=
-ERROR>>> Unknown command: "foo"
+ERROR>>> While reading file device: Unknown command: "foo"
 =END=
 
 ############################################################
@@ -59,7 +59,7 @@ ERROR>>> Unknown command: "foo"
 -A FORWARD -i eth1 -j ACCEPT -p TCP --syn !
 =NETSPOC=NONE
 =ERROR=
-ERROR>>> Unexpected trailing '!' in line
+ERROR>>> While reading file device: Unexpected trailing '!' in line
 ERROR>>>  -A FORWARD -i eth1 -j ACCEPT -p TCP --syn !
 =END=
 
@@ -422,3 +422,300 @@ COMMIT
 -A PREROUTING -j MARK --set-mark 1 -p TCP --dport 80
 -A PREROUTING -j MARK --set-mark 15 -p TCP --dport 81
 =OUTPUT=NONE
+
+############################################################
+=TITLE=Dump Netspoc config merged with raw
+=NETSPOC=
+--router
+ip route add 10.1.0.0/16 via 10.2.2.1
+*filter
+:INPUT DROP
+:c1 -
+-A INPUT -j c1 -s 10.1.11.0/24 -p tcp --dport 22
+-A INPUT -j DROP
+-A c1 -j ACCEPT -d 10.10.1.2/32 -p TCP
+--router.raw
+*filter
+:INPUT DROP
+[APPEND]
+-A INPUT -j ACCEPT -p icmp
+=SETUP=
+cp code/router device
+cp code/router.raw device.raw
+=OPTIONS=--dump
+=OUTPUT=
+{
+	"routes": [
+		"ip route add 10.1.0.0/16 via 10.2.2.1"
+	],
+	"iptables": {
+		"filter": {
+			"INPUT": {
+				"policy": "DROP",
+				"rules": [
+					{
+						"orig": "-A INPUT -j c1 -s 10.1.11.0/24 -p tcp --dport 22",
+						"pairs": {
+							"--dport": "22",
+							"-j": "c1",
+							"-p": "tcp",
+							"-s": "10.1.11.0/24"
+						}
+					},
+					{
+						"orig": "-A INPUT -j ACCEPT -p icmp",
+						"pairs": {
+							"-j": "ACCEPT",
+							"-p": "icmp"
+						},
+						"append": true
+					},
+					{
+						"orig": "-A INPUT -j DROP",
+						"pairs": {
+							"-j": "DROP"
+						}
+					}
+				]
+			},
+			"c1": {
+				"policy": "-",
+				"rules": [
+					{
+						"orig": "-A c1 -j ACCEPT -d 10.10.1.2/32 -p TCP",
+						"pairs": {
+							"-d": "10.10.1.2",
+							"-j": "ACCEPT",
+							"-p": "tcp"
+						}
+					}
+				]
+			}
+		}
+	}
+}
+=END=
+
+############################################################
+=TITLE=Export rules
+=NETSPOC=
+--router
+*filter
+:INPUT DROP
+:FORWARD DROP
+:c1 -
+-A INPUT -m state --state ESTABLISHED,RELATED -j ACCEPT
+-A INPUT -j c1 -s 10.1.11.0/24 -p tcp --dport 22
+-A INPUT -j ACCEPT -i eth0 -p udp --sport 1024:65535 --dport 53
+-A FORWARD -j ACCEPT -p icmp --icmp-type 3/4 -o eth1
+-A c1 -j ACCEPT -d 10.10.1.2/32 -p TCP
+-A c1 -j RETURN ! -s 10.1.11.5
+--router.raw
+*filter
+:INPUT DROP
+[APPEND]
+-A INPUT -j ACCEPT -p 50
+=SETUP=
+cp code/router device
+cp code/router.raw device.raw
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "FORWARD",
+			"bindings": [
+				{}
+			],
+			"rules": [
+				{
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "icmp",
+							"type": 3,
+							"code": 4
+						}
+					],
+					"to": [
+						"eth1"
+					],
+					"orig": "-A FORWARD -j ACCEPT -p icmp --icmp-type 3/4 -o eth1"
+				}
+			],
+			"default": "deny"
+		},
+		{
+			"name": "INPUT",
+			"bindings": [
+				{}
+			],
+			"rules": [
+				{
+					"position": 2,
+					"action": "jump",
+					"target": "c1",
+					"src": {
+						"prefixes": [
+							"10.1.11.0/24"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 22,
+									"high": 22
+								}
+							]
+						}
+					],
+					"orig": "-A INPUT -j c1 -s 10.1.11.0/24 -p tcp --dport 22"
+				},
+				{
+					"position": 3,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "udp",
+							"src_ports": [
+								{
+									"low": 1024,
+									"high": 65535
+								}
+							],
+							"dst_ports": [
+								{
+									"low": 53,
+									"high": 53
+								}
+							]
+						}
+					],
+					"from": [
+						"eth0"
+					],
+					"orig": "-A INPUT -j ACCEPT -i eth0 -p udp --sport 1024:65535 --dport 53"
+				},
+				{
+					"position": 4,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "50"
+						}
+					],
+					"raw": true,
+					"orig": "-A INPUT -j ACCEPT -p 50"
+				}
+			],
+			"default": "deny"
+		},
+		{
+			"name": "c1",
+			"rules": [
+				{
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.10.1.2/32"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp"
+						}
+					],
+					"orig": "-A c1 -j ACCEPT -d 10.10.1.2/32 -p TCP"
+				},
+				{
+					"position": 2,
+					"action": "return",
+					"src": {
+						"prefixes": [
+							"10.1.11.5/32"
+						],
+						"negate": true
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"orig": "-A c1 -j RETURN ! -s 10.1.11.5"
+				}
+			]
+		}
+	]
+}
+=END=
+
+############################################################
+=TITLE=Trace flow through user defined chain
+=DEVICE=
+*filter
+:INPUT DROP
+:FORWARD DROP
+:c1 -
+-A FORWARD -j c1 -s 10.1.11.0/24
+-A c1 -j ACCEPT -p tcp --dport 80
+=NETSPOC=
+*filter
+:INPUT DROP
+:FORWARD DROP
+:c1 -
+-A FORWARD -j c1 -s 10.1.11.0/24 -i eth0
+-A FORWARD -j ACCEPT -s 10.1.11.0/24 -p tcp --dport 80
+-A c1 -j RETURN -p tcp --dport 80
+-A c1 -j ACCEPT -p tcp
+=OPTIONS=--trace 10.1.11.1,10.9.9.9,tcp:80,eth0
+=OUTPUT=
+device: FORWARD -> c1: rule 1 permit: -A c1 -j ACCEPT -p tcp --dport 80
+device: INPUT: default deny
+netspoc: FORWARD: rule 2 permit: -A FORWARD -j ACCEPT -s 10.1.11.0/24 -p tcp --dport 80
+netspoc: INPUT: default deny
+result: unchanged
+=END=
@@ -69,5 +69,35 @@ COMMIT
 :c1 -
 -A c1 -s 10.0.7.0/24 -j ACCEPT
 =ERROR=
-ERROR>>> Must not redefine chain "c1" of table "filter" from rawdata
+ERROR>>> While reading file router.raw: Must not redefine chain "c1" of table "filter" from rawdata
+=END=
+
+############################################################
+=TITLE=Show line of bad command in raw file
+=NETSPOC=
+--router
+*filter
+:INPUT DROP
+-A INPUT -j DROP
+--router.raw
+*filter
+:c1 -
+
+-A c1 -s 10.0.7.0/24 -j ACCEPT
+-I c1 -s 10.0.8.0/24 -j ACCEPT
+=ERROR=
+ERROR>>> While reading file router.raw: Unsupported command "-I" in line 5
+=END=
+
+############################################################
+=TITLE=Show line of bad route in raw file
+=NETSPOC=
+--router
+ip route add 10.20.0.0/19 via 10.1.2.3
+--router.raw
+# Static routes
+ip route add 10.22.0.0/16 via 10.1.2.4
+ip route add 10.0.0.0/8
+=ERROR=
+ERROR>>> While reading file router.raw: Unexpected route: ip route add 10.0.0.0/8 in line 3
 =END=
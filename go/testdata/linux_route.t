@@ -51,7 +51,7 @@ ip route del 10.30.0.0/16 via 10.1.2.3
 =NETSPOC=
 ip route del
 =ERROR=
-ERROR>>> Unexpected route: ip route del
+ERROR>>> While reading file router: Unexpected route: ip route del
 =END=
 
 ############################################################
@@ -61,7 +61,7 @@ ERROR>>> Unexpected route: ip route del
 =NETSPOC=
 ip route add 10.1.1.0/24
 =ERROR=
-ERROR>>> Unexpected route: ip route add 10.1.1.0/24
+ERROR>>> While reading file router: Unexpected route: ip route add 10.1.1.0/24
 =END=
 
 ############################################################
@@ -71,5 +71,5 @@ ERROR>>> Unexpected route: ip route add 10.1.1.0/24
 =NETSPOC=
 ip route add 10.1.1.0/24 via 10.1.1.1 vrf x
 =ERROR=
-ERROR>>> Unexpected route: ip route add 10.1.1.0/24 via 10.1.1.1 vrf x
+ERROR>>> While reading file router: Unexpected route: ip route add 10.1.1.0/24 via 10.1.1.1 vrf x
 =END=
@@ -166,6 +166,52 @@ xyz
 ERROR>>> Wrong device name: "xyz", expected: "router"
 =END=
 
+############################################################
+=TITLE=Check access
+=SCENARIO=[[scenario]]
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=WARNING=
+access: login succeeded
+access: device name: router
+access: banner: found
+=OUTPUT=
+--router.login
+The authenticity of host 'router (10.1.1.1)' can't be established.
+ECDSA key fingerprint is ee:6e:ee:00:33:aa:22:88:44:66:44:33:aa:77:42:f5.
+Are you sure you want to continue connecting (yes/no)? yes
+
+root@linux-router:~#PS1=router#
+router#uname -r
+3.2.89-2.custom
+router#uname -m
+i686
+router#hostname -s
+router
+router#grep 'NetSPoC' /etc/issue
+--- managed by NetSPoC ---
+router#
+=END=
+
+############################################################
+=TITLE=Check access with wrong device name
+=SCENARIO=
+
+router#
+# echo $?
+0
+# uname -r
+3.2.89-2.custom
+# uname -m
+i686
+# hostname -s
+xyz
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=ERROR=
+ERROR>>> Wrong device name: "xyz", expected: "router"
+=END=
+
 ############################################################
 =TITLE=Unknown iptables-restore
 =SCENARIO=
@@ -199,3 +245,37 @@ echo abc 123 >credentials
 =ERROR=
 ERROR>>> Expected 3 fields in lines of credentials
 =END=
+
+############################################################
+=TITLE=Leave protected chain and route unchanged
+=SCENARIO=
+[[scenario]]
+# ip route show
+0.0.0.0/0 via 10.1.1.1
+10.99.1.0/24 via 10.1.1.2
+# iptables-save
+*filter
+:INPUT DROP
+:EMERG -
+-A INPUT -j EMERG
+-A INPUT -j ACCEPT -s 10.1.11.111 -d 10.10.1.2 -p tcp --dport 22
+-A EMERG -j ACCEPT -s 10.1.66.66
+COMMIT
+=SETUP=
+echo 'router chain EMERG' > protect
+echo 'router route 10.99.0.0/16' >> protect
+=NETSPOC=
+ip route add 0.0.0.0/0 via 10.1.1.99
+
+*filter
+:INPUT DROP
+-A INPUT -j EMERG
+-A INPUT -j ACCEPT -s 10.1.11.111 -d 10.10.1.2 -p tcp --dport 22
+=OUTPUT=
+--router.change
+ip route del 0.0.0.0/0 via 10.1.1.1
+router#ip route add 0.0.0.0/0 via 10.1.1.99
+router#echo $?
+0
+router#
+=END=
@@ -45,6 +45,7 @@
  {{with .disabled}}"disabled": {{.}},{{end}}
  {{with .tag}}"tag": "{{.}}",{{end}}
  {{with .unknownattribute}}"unknownattribute": "{{.}}",{{end}}
+ {{with .extra}}{{.}},{{end}}
  "scope": [ "{{or .scope "/infra/tier-0s/v1"}}" ],
  "direction": "{{or .dir "OUT"}}",
  "ip_protocol": "{{or .proto "IPV4"}}",
@@ -748,6 +749,169 @@ rules:
 ERROR>>> While reading file router.raw: Must not use rule name starting with 'r<NUM>': r3-2-1
 =END=
 
+############################################################
+=TITLE=Replace, delete and insert rules from raw
+=DEVICE=
+[[two_rules]]
+=NETSPOC=
+-- router
+[[two_rules]]
+-- router.raw
+[[config
+rules:
+- { id: r1, src: 10.1.1.10, dst: 10.1.2.31, srv: tcp_80,
+    extra: '"replace": true' }
+- { id: r2, extra: '"delete": true' }
+- { id: raw-a, src: 10.1.1.11, extra: '"after": "r1"' }
+- { id: raw-b, act: DROP, dst: 10.1.2.32, extra: '"before": "r3"' }
+]]
+=OUTPUT=
+DELETE /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/r1
+
+DELETE /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/r2
+
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/r1-1
+{"action":"ALLOW",
+ "sequence_number":20,
+ "source_groups":["10.1.1.10"],
+ "destination_groups":["10.1.2.31"],
+ "services":["/infra/services/Netspoc-tcp_80"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-a
+{"action":"ALLOW",
+ "sequence_number":21,
+ "source_groups":["10.1.1.11"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-b
+{"action":"DROP",
+ "sequence_number":29,
+ "source_groups":["ANY"],
+ "destination_groups":["10.1.2.32"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+=END=
+
+############################################################
+=TITLE=Renumber rules inserted from raw
+=DEVICE=
+[[config
+rules:
+- { id: r1, src: 10.1.1.10, seq: 20 }
+- { id: r2, src: 10.1.1.20, seq: 21 }
+- { id: r3, act: DROP, seq: 30 }
+]]
+=NETSPOC=
+-- router
+[[config
+rules:
+- { id: r1, src: 10.1.1.10, seq: 20 }
+- { id: r2, src: 10.1.1.20, seq: 21 }
+- { id: r3, act: DROP, seq: 30 }
+]]
+-- router.raw
+[[config
+rules:
+- { id: raw-a, src: 10.1.1.11, extra: '"after": "r1"' }
+- { id: raw-b, src: 10.1.1.12, extra: '"after": "r1"' }
+- { id: raw-c, src: 10.1.1.13, extra: '"before": "raw-b"' }
+- { id: raw-d, src: 10.1.1.14, extra: '"prepend": true' }
+- { id: raw-e, act: DROP, extra: '"append": true' }
+]]
+=OUTPUT=
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-d
+{"action":"ALLOW",
+ "sequence_number":0,
+ "source_groups":["10.1.1.14"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+DELETE /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/r2
+
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-a
+{"action":"ALLOW",
+ "sequence_number":21,
+ "source_groups":["10.1.1.11"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-c
+{"action":"ALLOW",
+ "sequence_number":22,
+ "source_groups":["10.1.1.13"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-b
+{"action":"ALLOW",
+ "sequence_number":23,
+ "source_groups":["10.1.1.12"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/r2-1
+{"action":"ALLOW",
+ "sequence_number":24,
+ "source_groups":["10.1.1.20"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+PUT /policy/api/v1/infra/domains/default/gateway-policies/Netspoc-v1/rules/raw-e
+{"action":"DROP",
+ "sequence_number":31,
+ "source_groups":["ANY"],
+ "destination_groups":["ANY"],
+ "services":["ANY"],
+ "scope":["/infra/tier-0s/v1"],
+ "direction":"OUT",
+ "ip_protocol":"IPV4"}
+=END=
+
+############################################################
+=TITLE=Unknown rule in "before" of raw
+=DEVICE=
+[[one_rule]]
+=NETSPOC=
+-- router
+[[one_rule]]
+-- router.raw
+[[config
+rules:
+- { id: raw, extra: '"before": "r2"' }
+]]
+=ERROR=
+ERROR>>> While reading file router.raw: Unknown rule "r2" in "before" of policy "Netspoc-v1"
+=END=
+
+############################################################
+=TITLE=Conflicting attributes of rule from raw
+=NETSPOC=
+-- router.raw
+[[config
+rules:
+- { id: r1, extra: '"delete": true, "after": "r2"' }
+]]
+=ERROR=
+ERROR>>> While reading file router.raw: Must use only one of "replace", "delete", "before", "after", "prepend", "append" in rule r1
+=END=
+
 ############################################################
 =TITLE=Merge rule of raw policy into rule of netspoc policy
 =DEVICE=
@@ -1760,3 +1924,224 @@ rules:
 - { id: r2, scope: /infra/tier-0s/v2 }
 ]]
 =OUTPUT=NONE
+
+############################################################
+=TITLE=Export rules
+=NETSPOC=
+{
+ "groups": [
+  {
+   "id": "Netspoc-g0",
+   "expression": [
+    {
+     "id": "id",
+     "resource_type": "IPAddressExpression",
+     "ip_addresses": [ "10.1.1.0/24", "10.1.2.10" ]
+    }
+   ]
+  }
+ ],
+ "policies": [
+  {
+   "id": "Netspoc-v1",
+   "resource_type": "GatewayPolicy",
+   "rules": [
+    {
+     "resource_type": "Rule",
+     "id": "r1",
+     "logged": true,
+     "scope": [ "/infra/tier-0s/v1" ],
+     "direction": "IN",
+     "ip_protocol": "IPV4",
+     "sequence_number": 20,
+     "action": "ALLOW",
+     "source_groups": [ "/infra/domains/default/groups/Netspoc-g0" ],
+     "destination_groups": [ "ANY" ],
+     "services": [ "/infra/services/Netspoc-tcp_80" ]
+    },
+    {
+     "resource_type": "Rule",
+     "id": "r2",
+     "sources_excluded": true,
+     "scope": [ "/infra/tier-0s/v1" ],
+     "direction": "IN",
+     "ip_protocol": "IPV4",
+     "sequence_number": 30,
+     "action": "DROP",
+     "source_groups": [ "/infra/domains/default/groups/Netspoc-g0" ],
+     "destination_groups": [ "ANY" ],
+     "services": [ "/infra/services/Netspoc-icmp_8" ]
+    }
+   ]
+  }
+ ],
+ "services": [
+  {
+   "id": "Netspoc-tcp_80",
+   "service_entries": [
+    {
+     "id": "id",
+     "resource_type": "L4PortSetServiceEntry",
+     "l4_protocol": "TCP",
+     "destination_ports": [ "80" ],
+     "source_ports": []
+    }
+   ]
+  },
+  {
+   "id": "Netspoc-icmp_8",
+   "service_entries": [
+    {
+     "id": "id",
+     "resource_type": "ICMPTypeServiceEntry",
+     "protocol": "ICMPv4",
+     "icmp_type": 8
+    }
+   ]
+  }
+ ]
+}
+=SETUP=
+cp code/router device
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "Netspoc-v1",
+			"bindings": [
+				{}
+			],
+			"rules": [
+				{
+					"name": "r1",
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.1.1.0/24",
+							"10.1.2.10/32"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 80,
+									"high": 80
+								}
+							]
+						}
+					],
+					"from": [
+						"/infra/tier-0s/v1"
+					],
+					"log": true,
+					"orig": "r1"
+				},
+				{
+					"name": "r2",
+					"position": 2,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"10.1.1.0/24",
+							"10.1.2.10/32"
+						],
+						"negate": true
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0"
+						]
+					},
+					"services": [
+						{
+							"proto": "icmp",
+							"type": 8
+						}
+					],
+					"from": [
+						"/infra/tier-0s/v1"
+					],
+					"orig": "r2"
+				}
+			]
+		}
+	]
+}
+=END=
+
+############################################################
+=TITLE=Trace flow with scope of rule
+=DEVICE=
+[[config
+groups:
+ - { id: g0, ip: 10.1.1.0/24 }
+rules:
+ - { id: r1, src: g0, srv: tcp_80, scope: /infra/tier-0s/v2 }
+services:
+ - [tcp, 80]
+]]
+=NETSPOC=
+[[config
+groups:
+ - { id: g0, ip: 10.1.1.0/24 }
+rules:
+ - { id: r1, src: g0, srv: tcp_80 }
+ - { id: r2, act: DROP }
+services:
+ - [tcp, 80]
+]]
+=OPTIONS=--trace 10.1.1.1,10.1.8.1,tcp:80,/infra/tier-0s/v1
+=OUTPUT=
+device: Netspoc-v1: no matching rule
+netspoc: Netspoc-v1: rule 1 permit: r1
+result: changed
+=END=
+
+############################################################
+=TITLE=Lint name of group from raw
+=SCENARIO=NONE
+=NETSPOC=
+-- router
+[[config
+groups:
+- { id: g0, ip: 10.1.1.20 }
+]]
+-- router.raw
+[[config
+groups:
+- { id: g1, ip: 10.1.1.10 }
+]]
+=PARAMS=--lint code/router
+=ERROR=
+ERROR>>> While reading file router.raw: Must not use group name starting with 'Netspoc-g<NUM>': Netspoc-g1
+=END=
+
+############################################################
+=TITLE=Show line of syntax error in raw
+=SCENARIO=NONE
+=NETSPOC=
+-- router
+[[config
+groups:
+- { id: g0, ip: 10.1.1.20 }
+]]
+-- router.raw
+{
+ "groups": [
+  { "id": "g1" }
+ ]
+ "policies": []
+}
+=PARAMS=--lint code/router
+=ERROR=
+ERROR>>> While reading file router.raw: invalid character '"' after object key:value pair in line 5
+=END=
@@ -0,0 +1,250 @@
+############################################################
+=TITLE=Parse ACL with sequence numbers
+=DEVICE=
+ip access-list test
+  statistics per-entry
+  10 permit tcp 10.1.1.11/32 10.3.4.0/24 eq 22
+  20 permit udp any 10.3.4.0/24 eq 53
+  30 deny ip any any
+interface Ethernet1/1
+  ip access-group test in
+=NETSPOC=
+ip access-list test
+ permit tcp host 10.1.1.11 10.3.4.0/24 eq 22
+ permit udp any 10.3.4.0/24 eq domain
+ deny ip any any
+interface Ethernet1/1
+ ip access-group test in
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change ACL incrementally
+=DEVICE=
+ip access-list test
+  10 permit tcp 10.1.1.11/32 10.3.4.1/32 eq 22
+  20 permit tcp 10.1.1.11/32 10.5.6.1/32 eq 22
+  30 deny ip any 10.1.2.0/24
+  40 permit tcp any 10.3.4.0/24 eq 80
+  50 deny ip any any
+interface Ethernet1/1
+  ip access-group test in
+=NETSPOC=
+ip access-list test
+ permit tcp host 10.1.1.11 host 10.5.6.1 eq 22
+ permit tcp host 10.1.1.11 host 10.9.9.1 eq 22
+ permit tcp any 10.3.4.0/24 eq 80
+ deny ip any any
+interface Ethernet1/1
+ ip access-group test in
+=OUTPUT=
+resequence ip access-list test 10000 10000
+ip access-list test
+30001 permit tcp host 10.1.1.11 host 10.9.9.1 eq 22
+no 30000
+no 10000
+resequence ip access-list test 10 10
+=END=
+
+############################################################
+=TITLE=Add new ACL to interface
+=DEVICE=
+interface Ethernet1/1
+  ip address 10.1.1.1/24
+=NETSPOC=
+ip access-list Ethernet1/1_in
+ 10 permit ip 10.1.1.0/24 any
+ 20 deny ip any any
+interface Ethernet1/1
+ ip address 10.1.1.1/24
+ ip access-group Ethernet1/1_in in
+=OUTPUT=
+ip access-list Ethernet1/1_in-DRC-0
+permit ip 10.1.1.0/24 any
+deny ip any any
+exit
+interface Ethernet1/1
+ip access-group Ethernet1/1_in-DRC-0 in
+=END=
+
+############################################################
+=TITLE=Add ACL with object-group
+=DEVICE=
+interface Ethernet1/1
+  ip address 10.1.1.1/24
+=NETSPOC=
+object-group ip address g0
+ 10 10.1.1.0/24
+ 20 host 10.1.2.3
+ip access-list Ethernet1/1_in
+ 10 permit tcp addrgroup g0 host 10.9.9.9 eq 80
+ 20 deny ip any any
+interface Ethernet1/1
+ ip address 10.1.1.1/24
+ ip access-group Ethernet1/1_in in
+=OUTPUT=
+object-group ip address g0-DRC-0
+10.1.1.0/24
+host 10.1.2.3
+ip access-list Ethernet1/1_in-DRC-0
+permit tcp addrgroup g0-DRC-0 host 10.9.9.9 eq 80
+deny ip any any
+exit
+interface Ethernet1/1
+ip access-group Ethernet1/1_in-DRC-0 in
+=END=
+
+############################################################
+=TITLE=Unchanged object-group with different name
+=DEVICE=
+object-group ip address g0-DRC-0
+  10 host 10.1.2.3
+  20 10.1.1.0/24
+ip access-list Ethernet1/1_in-DRC-0
+  10 permit tcp addrgroup g0-DRC-0 10.9.9.9/32 eq 80
+  20 deny ip any any
+interface Ethernet1/1
+  ip access-group Ethernet1/1_in-DRC-0 in
+=NETSPOC=
+object-group ip address g1
+ 10.1.1.0/24
+ host 10.1.2.3
+ip access-list Ethernet1/1_in
+ permit tcp addrgroup g1 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1/1
+ ip access-group Ethernet1/1_in in
+=OUTPUT=NONE
+
+############################################################
+=TITLE=Change element of object-group
+=DEVICE=
+object-group ip address g0-DRC-0
+  10 host 10.1.2.3
+  20 10.1.1.0/24
+ip access-list Ethernet1/1_in-DRC-0
+  10 permit tcp addrgroup g0-DRC-0 10.9.9.9/32 eq 80
+  20 deny ip any any
+interface Ethernet1/1
+  ip access-group Ethernet1/1_in-DRC-0 in
+=NETSPOC=
+object-group ip address g0
+ 10.1.1.0/24
+ host 10.1.2.4
+ip access-list Ethernet1/1_in
+ permit tcp addrgroup g0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1/1
+ ip access-group Ethernet1/1_in in
+=OUTPUT=
+object-group ip address g0-DRC-0
+no host 10.1.2.3
+host 10.1.2.4
+=END=
+
+############################################################
+=TITLE=Replace object-group
+=DEVICE=
+object-group ip address g0-DRC-0
+  10 host 10.1.2.3
+  20 10.1.1.0/24
+ip access-list Ethernet1/1_in-DRC-0
+  10 permit tcp addrgroup g0-DRC-0 10.9.9.9/32 eq 80
+  20 deny ip any any
+interface Ethernet1/1
+  ip access-group Ethernet1/1_in-DRC-0 in
+=NETSPOC=
+object-group ip address g0
+ 10.2.2.0/24
+ host 10.2.2.4
+ip access-list Ethernet1/1_in
+ permit tcp addrgroup g0 host 10.9.9.9 eq 80
+ deny ip any any
+interface Ethernet1/1
+ ip access-group Ethernet1/1_in in
+=OUTPUT=
+resequence ip access-list Ethernet1/1_in-DRC-0 10000 10000
+object-group ip address g0-DRC-1
+10.2.2.0/24
+host 10.2.2.4
+exit
+ip access-list Ethernet1/1_in-DRC-0
+10001 permit tcp addrgroup g0-DRC-1 host 10.9.9.9 eq 80
+no 10000
+resequence ip access-list Ethernet1/1_in-DRC-0 10 10
+no object-group ip address g0-DRC-0
+=END=
+
+############################################################
+=TITLE=Routes in global and VRF context
+=DEVICE=
+vrf context management
+  ip route 0.0.0.0/0 192.168.1.1
+vrf context v1
+  address-family ipv4 unicast
+  ip route 10.2.0.0/16 10.1.1.2
+  ip route 10.3.0.0/16 10.1.1.2
+ip route 10.0.0.0/8 10.1.1.1
+interface Ethernet1/1
+  vrf member v1
+  ip address 10.1.1.5/24
+=NETSPOC=
+vrf context v1
+ ip route 10.2.0.0/16 10.1.1.2
+ ip route 10.4.0.0/16 10.1.1.3
+ip route 10.0.0.0/8 10.1.1.9
+ip route 10.9.0.0/16 10.1.1.1
+interface Ethernet1/1
+ vrf member v1
+ ip address 10.1.1.5/24
+=OUTPUT=
+ip route 10.9.0.0/16 10.1.1.1
+no ip route 10.0.0.0/8 10.1.1.1\N ip route 10.0.0.0/8 10.1.1.9
+vrf context v1
+ip route 10.4.0.0/16 10.1.1.3
+no ip route 10.3.0.0/16 10.1.1.2
+=END=
+
+############################################################
+=TITLE=Change next hop of route in VRF context
+=DEVICE=
+vrf context v1
+  ip route 0.0.0.0/0 10.1.1.1
+  ip route 10.2.0.0/16 10.1.1.2
+  ipv6 route 2001:db8:2::/48 2001:db8:1::2
+interface Ethernet1/1
+  vrf member v1
+  ip address 10.1.1.5/24
+=NETSPOC=
+vrf context v1
+ ip route 0.0.0.0/0 10.1.1.9
+ ip route 10.2.0.0/16 10.1.1.2
+ ip route 10.3.0.0/16 10.1.1.3
+ ipv6 route 2001:db8:2::/48 2001:db8:1::9
+interface Ethernet1/1
+ vrf member v1
+ ip address 10.1.1.5/24
+=OUTPUT=
+vrf context v1
+no ipv6 route 2001:db8:2::/48 2001:db8:1::2\N ipv6 route 2001:db8:2::/48 2001:db8:1::9
+ip route 10.3.0.0/16 10.1.1.3
+no ip route 0.0.0.0/0 10.1.1.1\N ip route 0.0.0.0/0 10.1.1.9
+=END=
+
+############################################################
+=TITLE=Different VRF on interface
+=DEVICE=
+interface Ethernet1/1
+  vrf member v1
+  ip address 10.1.1.5/24
+interface Ethernet1/2
+  vrf member v1
+  ip address 10.1.2.5/24
+=NETSPOC=
+interface Ethernet1/1
+ vrf member v1
+ ip address 10.1.1.5/24
+interface Ethernet1/2
+ ip address 10.1.2.5/24
+=ERROR=
+ERROR>>> Different VRFs defined for interface Ethernet1/2: Device: v1, Netspoc: <global>
+=END=
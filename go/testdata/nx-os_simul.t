@@ -0,0 +1,208 @@
+=TEMPL=std_scenario
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router#
+# sh ver
+Cisco Nexus Operating System (NX-OS) Software
+# checkpoint netspoc-approve
+...........Done
+# copy running-config startup-config
+[########################################] 100%
+Copy complete, now saving to disk (please wait)...
+Copy complete.
+=END=
+
+############################################################
+=TITLE=Login and check device name
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router#
+# sh ver
+Cisco Nexus Operating System (NX-OS) Software
+=NETSPOC=NONE
+=OUTPUT=
+--router.login
+Enter Password:secret
+
+banner motd  managed by NetSPoC
+router#
+router#terminal length 0
+router#terminal width 511
+router#sh ver
+Cisco Nexus Operating System (NX-OS) Software
+router#
+router#
+=END=
+
+############################################################
+=TITLE=Approve with checkpoint
+=SCENARIO=
+[[std_scenario]]
+# sh run
+ip route 10.0.0.0/8 10.1.2.3
+=NETSPOC=
+ip route 10.0.0.0/8 10.11.22.33
+ip route 10.1.1.0/24 10.1.2.3
+=OUTPUT=
+--router.change
+no checkpoint netspoc-approve
+router#checkpoint netspoc-approve
+...........Done
+router#configure terminal
+router#ip route 10.1.1.0/24 10.1.2.3
+router#no ip route 10.0.0.0/8 10.1.2.3
+router#ip route 10.0.0.0/8 10.11.22.33
+router#end
+router#no checkpoint netspoc-approve
+router#copy running-config startup-config
+[########################################] 100%
+Copy complete, now saving to disk (please wait)...
+Copy complete.
+router#
+=END=
+
+############################################################
+=TITLE=Rollback to checkpoint if command fails
+=SCENARIO=
+[[std_scenario]]
+# ip route 10.1.1.0/24 10.1.2.3
+% Invalid command
+# rollback running-config checkpoint netspoc-approve
+Collecting Running-Config
+Generating Rollback Patch
+Executing Rollback Patch
+Generating Running-config for verification
+Generating Patch for verification
+Rollback completed successfully.
+=NETSPOC=
+ip route 10.1.1.0/24 10.1.2.3
+=ERROR=
+ERROR>>> Got unexpected output from 'ip route 10.1.1.0/24 10.1.2.3':
+ERROR>>> % Invalid command
+=OUTPUT=
+--router.change
+no checkpoint netspoc-approve
+router#checkpoint netspoc-approve
+...........Done
+router#configure terminal
+router#ip route 10.1.1.0/24 10.1.2.3
+% Invalid command
+router#end
+router#rollback running-config checkpoint netspoc-approve
+Collecting Running-Config
+Generating Rollback Patch
+Executing Rollback Patch
+Generating Running-config for verification
+Generating Patch for verification
+Rollback completed successfully.
+router#no checkpoint netspoc-approve
+router#
+=END=
+
+############################################################
+=TITLE=Keep checkpoint if rollback fails
+=SCENARIO=
+[[std_scenario]]
+# ip route 10.1.1.0/24 10.1.2.3
+% Invalid command
+# rollback running-config checkpoint netspoc-approve
+Rollback failed.
+=NETSPOC=
+ip route 10.1.1.0/24 10.1.2.3
+=ERROR=
+WARNING>>> Rollback to checkpoint netspoc-approve failed:
+WARNING>>> Rollback failed.
+ERROR>>> Got unexpected output from 'ip route 10.1.1.0/24 10.1.2.3':
+ERROR>>> % Invalid command
+=OUTPUT=
+--router.change
+no checkpoint netspoc-approve
+router#checkpoint netspoc-approve
+...........Done
+router#configure terminal
+router#ip route 10.1.1.0/24 10.1.2.3
+% Invalid command
+router#end
+router#rollback running-config checkpoint netspoc-approve
+Rollback failed.
+router#
+=END=
+
+############################################################
+=TITLE=Abort if checkpoint can't be created
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router#
+# checkpoint netspoc-approve
+No space left on device
+=NETSPOC=
+ip route 10.1.1.0/24 10.1.2.3
+=ERROR=
+ERROR>>> Creating checkpoint failed:
+ERROR>>> No space left on device
+=END=
+
+############################################################
+=TITLE=copy running-config startup-config fails
+=SCENARIO=
+Enter Password:<!>
+banner motd  managed by NetSPoC
+router#
+# checkpoint netspoc-approve
+...........Done
+# copy running-config startup-config
+Configuration update aborted: request was aborted
+=NETSPOC=
+ip route 10.1.1.0/24 10.1.2.3
+=ERROR=
+ERROR>>> copy running-config startup-config: unexpected result: Configuration update aborted: request was aborted
+=END=
+
+############################################################
+=TITLE=Leave protected route in VRF context unchanged
+=SCENARIO=
+[[std_scenario]]
+# sh run
+vrf context v1
+  ip route 10.99.1.0/24 10.1.2.9
+  ip route 10.20.0.0/16 10.1.2.9
+=SETUP=
+echo '* route 10.99.0.0/16' > protect
+=NETSPOC=
+vrf context v1
+ ip route 10.30.0.0/16 10.1.2.9
+=OUTPUT=
+--router.change
+no checkpoint netspoc-approve
+router#checkpoint netspoc-approve
+...........Done
+router#configure terminal
+router#vrf context v1
+router#ip route 10.30.0.0/16 10.1.2.9
+router#no ip route 10.20.0.0/16 10.1.2.9
+router#end
+router#no checkpoint netspoc-approve
+router#copy running-config startup-config
+[########################################] 100%
+Copy complete, now saving to disk (please wait)...
+Copy complete.
+router#
+=END=
+
+############################################################
+=TITLE=Netspoc must not change protected route in VRF context
+=SCENARIO=
+[[std_scenario]]
+# sh run
+vrf context v1
+  ip route 10.99.1.0/24 10.1.2.9
+=SETUP=
+echo '* route 10.99.0.0/16' > protect
+=NETSPOC=
+vrf context v1
+ ip route 10.99.1.0/24 10.1.2.8
+=ERROR=
+ERROR>>> Must not change protected route: ip route 10.99.1.0/24 10.1.2.8
+=END=
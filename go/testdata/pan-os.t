@@ -1926,6 +1926,209 @@ action=set&type=config&
 ERROR>>> While reading file router.raw: Must not use rule name starting with 'r<NUM>': r3-2-1
 =END=
 
+############################################################
+=TEMPL=three_rules
+[[prefix vsys2]]
+[[rules
+- name: r1
+  src: [any]
+  dst: [any]
+  srv: [tcp 80]
+- name: r2
+  src: [any]
+  dst: [any]
+  srv: [tcp 81]
+- name: r3
+  action: drop
+  src: [any]
+  dst: [any]
+  srv: [any]
+]]
+[[services
+- {proto: tcp, port: 80}
+- {proto: tcp, port: 81}
+]]
+[[postfix]]
+=END=
+
+############################################################
+=TITLE=Replace, delete and insert rules from raw
+=DEVICE=
+[[three_rules]]
+=NETSPOC=
+-- router
+[[three_rules]]
+-- router.raw
+[[prefix vsys2]]
+[[rules
+- name: r1
+  src: [any]
+  dst: [any]
+  srv: [tcp 80, tcp 81]
+  extra: "<REPLACE/>"
+- name: r2
+  extra: "<DELETE/>"
+- name: raw-a1
+  src: [any]
+  dst: [any]
+  srv: [tcp 82]
+  extra: "<AFTER>r1</AFTER>"
+- name: raw-a2
+  src: [any]
+  dst: [any]
+  srv: [tcp 83]
+  extra: "<AFTER>r1</AFTER>"
+- name: raw-b
+  action: drop
+  src: [any]
+  dst: [any]
+  srv: [tcp 84]
+  extra: "<BEFORE>r3</BEFORE>"
+]]
+[[services
+- {proto: tcp, port: 82}
+- {proto: tcp, port: 83}
+- {proto: tcp, port: 84}
+]]
+[[postfix]]
+=OUTPUT=
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/service/entry[@name='tcp 82']&
+ element=
+  <protocol><tcp><port>82</port></tcp></protocol>
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/service/entry[@name='tcp 83']&
+ element=
+  <protocol><tcp><port>83</port></tcp></protocol>
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/service/entry[@name='tcp 84']&
+ element=
+  <protocol><tcp><port>84</port></tcp></protocol>
+action=delete&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='r1']
+action=delete&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='r2']
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='r1-1']&
+ element=
+  <action>allow</action>
+  <from><member>z1</member></from>
+  <to><member>z2</member></to>
+  <source><member>any</member></source>
+  <destination><member>any</member></destination>
+  <service><member>tcp 80</member><member>tcp 81</member></service>
+  <application><member>any</member></application>
+  <log-start>yes</log-start>
+  <log-end>yes</log-end>
+  <rule-type>interzone</rule-type>
+action=move&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='r1-1']&
+ where=before&dst=r3
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='raw-a1']&
+ element=
+  <action>allow</action>
+  <from><member>z1</member></from>
+  <to><member>z2</member></to>
+  <source><member>any</member></source>
+  <destination><member>any</member></destination>
+  <service><member>tcp 82</member></service>
+  <application><member>any</member></application>
+  <log-start>yes</log-start>
+  <log-end>yes</log-end>
+  <rule-type>interzone</rule-type>
+action=move&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='raw-a1']&
+ where=before&dst=r3
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='raw-a2']&
+ element=
+  <action>allow</action>
+  <from><member>z1</member></from>
+  <to><member>z2</member></to>
+  <source><member>any</member></source>
+  <destination><member>any</member></destination>
+  <service><member>tcp 83</member></service>
+  <application><member>any</member></application>
+  <log-start>yes</log-start>
+  <log-end>yes</log-end>
+  <rule-type>interzone</rule-type>
+action=move&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='raw-a2']&
+ where=before&dst=r3
+action=set&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='raw-b']&
+ element=
+  <action>drop</action>
+  <from><member>z1</member></from>
+  <to><member>z2</member></to>
+  <source><member>any</member></source>
+  <destination><member>any</member></destination>
+  <service><member>tcp 84</member></service>
+  <application><member>any</member></application>
+  <log-start>yes</log-start>
+  <log-end>yes</log-end>
+  <rule-type>interzone</rule-type>
+action=move&type=config&
+ xpath=/config/devices/entry[@name='localhost.localdomain']
+  /vsys/entry[@name='vsys2']/rulebase/security/rules/entry[@name='raw-b']&
+ where=before&dst=r3
+=END=
+
+############################################################
+=TITLE=Unknown rule in <AFTER> of raw
+=DEVICE=
+[[three_rules]]
+=NETSPOC=
+-- router
+[[three_rules]]
+-- router.raw
+[[prefix vsys2]]
+[[rules
+- name: raw-a
+  src: [any]
+  dst: [any]
+  srv: [any]
+  extra: "<AFTER>r4</AFTER>"
+]]
+[[postfix]]
+=ERROR=
+ERROR>>> While reading file router.raw: Unknown rule "r4" in <AFTER> of vsys "vsys2"
+=END=
+
+############################################################
+=TITLE=Conflicting markers of rule from raw
+=DEVICE=
+[[three_rules]]
+=NETSPOC=
+-- router
+[[three_rules]]
+-- router.raw
+[[prefix vsys2]]
+[[rules
+- name: r1
+  src: [any]
+  dst: [any]
+  srv: [any]
+  extra: "<REPLACE/><APPEND/>"
+]]
+[[postfix]]
+=ERROR=
+ERROR>>> While reading file router.raw: Must use only one of <APPEND>, <PREPEND>, <REPLACE>, <DELETE>, <BEFORE>, <AFTER> in rule r1
+=END=
+
 ############################################################
 =TITLE=Merge IPv4 and IPv6
 # Duplicate definition of service "tcp 80" from IPv4 and IPv6
@@ -2211,3 +2414,306 @@ ERROR>>> While reading file device: XML syntax error on line 3: element <entry>
 =ERROR=
 ERROR>>> While reading file router: XML syntax error on line 1: expected element name after <
 =END=
+
+############################################################
+=TITLE=Dump Netspoc config
+=NETSPOC=
+<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys2">
+<rulebase><security><rules>
+<entry name="r1">
+<action>allow</action>
+<from><member>z1</member></from>
+<to><member>z2</member></to>
+<source><member>NET_10.1.1.0_24</member></source>
+<destination><member>IP_10.1.2.10</member></destination>
+<service><member>tcp 80</member></service>
+<application><member>any</member></application>
+<rule-type>interzone</rule-type>
+</entry>
+</rules></security></rulebase>
+<address>
+<entry name="NET_10.1.1.0_24"><ip-netmask>10.1.1.0/24</ip-netmask></entry>
+<entry name="IP_10.1.2.10"><ip-netmask>10.1.2.10/32</ip-netmask></entry>
+</address>
+<service>
+<entry name="tcp 80"><protocol><tcp><port>80</port></tcp></protocol></entry>
+</service>
+</entry></vsys></entry></devices></config>
+=SETUP=
+cp code/router device
+=OPTIONS=--dump
+=OUTPUT=
+{
+	"Devices": {
+		"Entries": [
+			{
+				"Name": "localhost.localdomain",
+				"Hostname": "",
+				"Banner": "",
+				"Vsys": [
+					{
+						"Name": "vsys2",
+						"DisplayName": "",
+						"Rules": [
+							{
+								"Name": "r1",
+								"Action": "allow",
+								"From": [
+									"z1"
+								],
+								"To": [
+									"z2"
+								],
+								"Source": [
+									"NET_10.1.1.0_24"
+								],
+								"Destination": [
+									"IP_10.1.2.10"
+								],
+								"Service": [
+									"tcp 80"
+								],
+								"Application": [
+									"any"
+								],
+								"LogStart": "",
+								"LogEnd": "",
+								"LogSetting": "",
+								"RuleType": "interzone",
+								"Unknown": null,
+								"Append": null,
+								"Prepend": null,
+								"Replace": null,
+								"Delete": null,
+								"Before": "",
+								"After": ""
+							}
+						],
+						"Addresses": [
+							{
+								"Name": "NET_10.1.1.0_24",
+								"IpNetmask": "10.1.1.0/24",
+								"Unknown": null
+							},
+							{
+								"Name": "IP_10.1.2.10",
+								"IpNetmask": "10.1.2.10/32",
+								"Unknown": null
+							}
+						],
+						"AddressGroups": null,
+						"Services": [
+							{
+								"Name": "tcp 80",
+								"Protocol": {
+									"TCP": {
+										"Port": "80",
+										"Unknown": null
+									},
+									"UDP": null,
+									"Unknown": null
+								},
+								"Unknown": null
+							}
+						],
+						"ServiceGroups": null
+					}
+				]
+			}
+		]
+	}
+}
+=END=
+
+############################################################
+=TITLE=Export rules
+=NETSPOC=
+<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys2">
+<rulebase><security><rules>
+<entry name="r1">
+<action>allow</action>
+<from><member>z1</member></from>
+<to><member>z2</member></to>
+<source><member>g1</member></source>
+<destination><member>IP_10.1.2.10</member></destination>
+<service><member>tcp 80-85</member><member>udp 53</member></service>
+<application><member>any</member></application>
+<rule-type>interzone</rule-type>
+<log-end>yes</log-end>
+</entry>
+<entry name="r2">
+<action>drop</action>
+<from><member>any</member></from>
+<to><member>any</member></to>
+<source><member>any</member></source>
+<destination><member>any</member></destination>
+<service><member>application-default</member></service>
+<application><member>ssl</member></application>
+</entry>
+</rules></security></rulebase>
+<address>
+<entry name="NET_10.1.1.0_24"><ip-netmask>10.1.1.0/24</ip-netmask></entry>
+<entry name="IP_10.1.2.10"><ip-netmask>10.1.2.10/32</ip-netmask></entry>
+</address>
+<address-group>
+<entry name="g1"><static><member>NET_10.1.1.0_24</member><member>10.1.3.0/24</member></static></entry>
+</address-group>
+<service>
+<entry name="tcp 80-85"><protocol><tcp><port>80-85</port></tcp></protocol></entry>
+<entry name="udp 53"><protocol><udp><port>53</port></udp></protocol></entry>
+</service>
+</entry></vsys></entry></devices></config>
+=SETUP=
+cp code/router device
+=OPTIONS=--rules
+=OUTPUT=
+{
+	"rule_sets": [
+		{
+			"name": "vsys2",
+			"bindings": [
+				{}
+			],
+			"rules": [
+				{
+					"name": "r1",
+					"position": 1,
+					"action": "permit",
+					"src": {
+						"prefixes": [
+							"10.1.1.0/24",
+							"10.1.3.0/24"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"10.1.2.10/32"
+						]
+					},
+					"services": [
+						{
+							"proto": "tcp",
+							"dst_ports": [
+								{
+									"low": 80,
+									"high": 85
+								}
+							]
+						},
+						{
+							"proto": "udp",
+							"dst_ports": [
+								{
+									"low": 53,
+									"high": 53
+								}
+							]
+						}
+					],
+					"from": [
+						"z1"
+					],
+					"to": [
+						"z2"
+					],
+					"log": true,
+					"orig": "r1"
+				},
+				{
+					"name": "r2",
+					"position": 2,
+					"action": "deny",
+					"src": {
+						"prefixes": [
+							"0.0.0.0/0",
+							"::/0"
+						]
+					},
+					"dst": {
+						"prefixes": [
+							"0.0.0.0/0",
+							"::/0"
+						]
+					},
+					"services": [
+						{
+							"unknown": "application-default"
+						},
+						{
+							"unknown": "application ssl"
+						}
+					],
+					"orig": "r2"
+				}
+			],
+			"default": "deny"
+		}
+	]
+}
+=END=
+
+############################################################
+=TITLE=Trace flow between zones
+=DEVICE=
+<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys2">
+<rulebase><security><rules>
+<entry name="r1">
+<action>allow</action>
+<from><member>z1</member></from>
+<to><member>z3</member></to>
+<source><member>any</member></source>
+<destination><member>any</member></destination>
+<service><member>any</member></service>
+<application><member>any</member></application>
+</entry>
+</rules></security></rulebase>
+</entry></vsys></entry></devices></config>
+=NETSPOC=
+<config><devices><entry name="localhost.localdomain"><vsys><entry name="vsys2">
+<rulebase><security><rules>
+<entry name="r1">
+<action>allow</action>
+<from><member>z1</member></from>
+<to><member>z2</member></to>
+<source><member>NET_10.1.1.0_24</member></source>
+<destination><member>any</member></destination>
+<service><member>tcp 80</member></service>
+<application><member>any</member></application>
+</entry>
+</rules></security></rulebase>
+<address>
+<entry name="NET_10.1.1.0_24"><ip-netmask>10.1.1.0/24</ip-netmask></entry>
+</address>
+<service>
+<entry name="tcp 80"><protocol><tcp><port>80</port></tcp></protocol></entry>
+</service>
+</entry></vsys></entry></devices></config>
+=OPTIONS=--trace 10.1.1.1,10.9.9.9,tcp:80,z1:z2
+=OUTPUT=
+device: vsys2: default deny
+netspoc: vsys2: rule 1 permit: r1
+result: changed
+=END=
+
+############################################################
+=TITLE=Lint name of rule from raw
+=SCENARIO=NONE
+=NETSPOC=
+-- router
+[[prefix vsys2]]
+[[postfix]]
+-- router.raw
+[[prefix vsys2]]
+[[rules
+- name: r3-2-1
+  from: z0
+  to: z2
+  src: [any]
+  dst: [any]
+  srv: [any]
+]]
+[[postfix]]
+=PARAMS=--lint code/router
+=ERROR=
+ERROR>>> While reading file router.raw: Must not use rule name starting with 'r<NUM>': r3-2-1
+=END=
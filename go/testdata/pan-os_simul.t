@@ -253,6 +253,50 @@ DATA: key=xxx&type=op&cmd=<show><high-availability><state/></high-availability><
 
 =END=
 
+############################################################
+=TITLE=Check access with HA mode Active-Active
+=SCENARIO=
+[[checkHA
+mode: Active-Active
+state: active-primary
+]]
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=WARNING=
+access: login succeeded
+access: HA state: active-primary (Active-Active)
+=END=
+
+############################################################
+=TITLE=Check access with HA not enabled
+=SCENARIO=
+[[apikey]]
+POST /api/?type=op&cmd=<show><high-availability><state/></high-availability></show>
+<response status = 'success'>
+ <result>
+  <enabled>no</enabled>
+ </result>
+</response>
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=WARNING=
+access: login succeeded
+access: HA state: not enabled
+=END=
+
+############################################################
+=TITLE=Check access of passive device
+=SCENARIO=
+[[checkHA
+state: passive
+]]
+=NETSPOC=NONE
+=OPTIONS=--check-access
+=ERROR=
+WARNING>>> not in active state: 10.1.13.33 (router)
+ERROR>>> Devices unreachable: router
+=END=
+
 ############################################################
 =TITLE=Invalid HA mode
 =SCENARIO=
@@ -626,3 +670,71 @@ DATA: key=xxx&type=op&cmd=<show><jobs><id>6</id></jobs></show>
 </job></result></response>
 
 =END=
+
+############################################################
+=TITLE=Leave protected rule and its address unchanged
+=SCENARIO=
+[[checkHA]]
+POST /api/?type=config&action=get&xpath=/config/devices
+<response status = 'success'>
+ <result>
+  <devices>
+   <entry name="localhost.localdomain">
+    <deviceconfig><system><hostname>router</hostname></system></deviceconfig>
+    <vsys>
+     <entry name="vsys1">
+      <display-name>vsys1-managed-by-Netspoc</display-name>
+      <rulebase><security><rules>
+       <entry name="emerg">
+        <action>allow</action>
+        <from><member>z1</member></from>
+        <to><member>z2</member></to>
+        <source><member>EMERG-host</member></source>
+        <destination><member>any</member></destination>
+        <service><member>any</member></service>
+        <application><member>any</member></application>
+        <rule-type>interzone</rule-type>
+       </entry>
+      </rules></security></rulebase>
+      <address>
+       <entry name="EMERG-host"><ip-netmask>10.1.66.66/32</ip-netmask></entry>
+      </address>
+     </entry>
+    </vsys>
+   </entry>
+  </devices>
+ </result>
+</response>
+POST /api/?action=set&type=config
+<response status="success" code="20"></response>
+POST /api/?type=commit&action=partial
+<response status="success" code="19"><result><job>6</job></result></response>
+POST /api/?type=op&cmd=<show><jobs><id>6</id></jobs></show>
+<response status="success"><result><job>
+<result>OK</result>
+</job></result></response>
+=SETUP=
+echo 'router rule emerg' > protect
+=NETSPOC=
+[[minimal_netspoc]]
+=OUTPUT=
+--router.change
+TESTSERVER/api/
+DATA: key=xxx&action=set&type=config&xpath=/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/service/entry[@name='tcp 80']&element=<protocol><tcp><port>80</port></tcp></protocol>
+<response status="success" code="20"></response>
+
+TESTSERVER/api/
+DATA: key=xxx&action=set&type=config&xpath=/config/devices/entry[@name='localhost.localdomain']/vsys/entry[@name='vsys1']/rulebase/security/rules/entry[@name='r1']&element=<action>allow</action><from><member>z1</member></from><to><member>z2</member></to><source><member>any</member></source><destination><member>any</member></destination><service><member>tcp 80</member></service><application><member>any</member></application><rule-type>interzone</rule-type>
+<response status="success" code="20"></response>
+
+TESTSERVER/api/
+DATA: key=xxx&type=commit&action=partial&cmd=<commit><partial><admin><member>admin</member></admin></partial></commit>
+<response status="success" code="19"><result><job>6</job></result></response>
+
+TESTSERVER/api/
+DATA: key=xxx&type=op&cmd=<show><jobs><id>6</id></jobs></show>
+<response status="success"><result><job>
+<result>OK</result>
+</job></result></response>
+
+=END=